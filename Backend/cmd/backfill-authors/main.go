@@ -0,0 +1,44 @@
+// Comando backfill-authors atualiza nome e avatar gravados nas mensagens existentes
+// a partir do cadastro de usuários e marca as mensagens de usuários removidos.
+//
+// A leitura já resolve o autor pelo cadastro; o backfill mantém as colunas
+// denormalizadas coerentes para exportações e consultas diretas ao banco.
+//
+// Uso:
+//
+//	go run ./cmd/backfill-authors [caminho/do/banco.db]
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/rafael-bit/whatz/internal/database"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+func main() {
+	dbPath := os.Getenv("DB_PATH")
+	if len(os.Args) > 1 {
+		dbPath = os.Args[1]
+	}
+	if dbPath == "" {
+		dbPath = "./whatz.db"
+	}
+
+	db, err := database.NewDatabase(dbPath)
+	if err != nil {
+		log.Fatalf("❌ Erro ao conectar com banco de dados: %v", err)
+	}
+	defer db.Close()
+
+	messageRepo := repository.NewMessageRepository(db.DB)
+
+	updated, orphaned, err := messageRepo.BackfillAuthors()
+	if err != nil {
+		log.Fatalf("❌ Erro no backfill de autores: %v", err)
+	}
+
+	log.Printf("✅ %d mensagens atualizadas com o autor atual", updated)
+	log.Printf("👻 %d mensagens marcadas como \"%s\"", orphaned, repository.DeletedUserName)
+}
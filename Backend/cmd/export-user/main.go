@@ -0,0 +1,64 @@
+// Comando export-user gera o ZIP com os dados pessoais de um usuário
+// (perfil, tags, salas e mensagens), o mesmo produzido pela API.
+//
+// Uso:
+//
+//	go run ./cmd/export-user <user_id> [arquivo.zip]
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/rafael-bit/whatz/internal/database"
+	"github.com/rafael-bit/whatz/internal/repository"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatalf("❌ Uso: export-user <user_id> [arquivo.zip]")
+	}
+
+	userID := os.Args[1]
+	output := "whatz-export-" + userID + ".zip"
+	if len(os.Args) > 2 {
+		output = os.Args[2]
+	}
+
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "./whatz.db"
+	}
+
+	db, err := database.NewDatabase(dbPath)
+	if err != nil {
+		log.Fatalf("❌ Erro ao conectar com banco de dados: %v", err)
+	}
+	defer db.Close()
+
+	exportService := services.NewExportService(
+		repository.NewExportRepository(db.DB),
+		repository.NewUserRepository(db.DB),
+		repository.NewRoomRepository(db.DB),
+		repository.NewMessageRepository(db.DB),
+		"",
+	)
+
+	file, err := os.Create(output)
+	if err != nil {
+		log.Fatalf("❌ Erro ao criar %s: %v", output, err)
+	}
+
+	if err := exportService.WriteUserArchive(userID, file); err != nil {
+		file.Close()
+		os.Remove(output)
+		log.Fatalf("❌ Erro ao exportar usuário %s: %v", userID, err)
+	}
+
+	if err := file.Close(); err != nil {
+		log.Fatalf("❌ Erro ao gravar %s: %v", output, err)
+	}
+
+	log.Printf("📦 Dados do usuário %s exportados para %s", userID, output)
+}
@@ -16,13 +16,15 @@
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description Digite "Bearer" seguido de um espaço e o token JWT.
+// @description Digite "Bearer" seguido de um espaço e o token de API.
 
 package main
 
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -33,6 +35,8 @@ import (
 	"github.com/rafael-bit/whatz/internal/controllers"
 	"github.com/rafael-bit/whatz/internal/database"
 	"github.com/rafael-bit/whatz/internal/logger"
+	"github.com/rafael-bit/whatz/internal/middleware"
+	"github.com/rafael-bit/whatz/internal/models"
 	"github.com/rafael-bit/whatz/internal/repository"
 	"github.com/rafael-bit/whatz/internal/services"
 	"github.com/rafael-bit/whatz/internal/websocket"
@@ -61,22 +65,99 @@ func main() {
 	roomRepo := repository.NewRoomRepository(db.DB)
 	messageRepo := repository.NewMessageRepository(db.DB)
 	tagRepo := repository.NewTagRepository(db.DB)
+	eventRepo := repository.NewEventRepository(db.DB)
+	exportRepo := repository.NewExportRepository(db.DB)
+	legalHoldRepo := repository.NewLegalHoldRepository(db.DB)
+	pinRepo := repository.NewPinRepository(db.DB)
+	mentionRepo := repository.NewMentionRepository(db.DB)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	webhookRepo := repository.NewWebhookRepository(db.DB)
+	incomingWebhookRepo := repository.NewIncomingWebhookRepository(db.DB)
+	apiTokenRepo := repository.NewAPITokenRepository(db.DB)
+	commandRepo := repository.NewCommandRepository(db.DB)
+	linkPreviewRepo := repository.NewLinkPreviewRepository(db.DB)
+	moderationRepo := repository.NewModerationRepository(db.DB)
 
 	// Inicializar serviços
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, os.Getenv("USER_DELETION_MESSAGE_POLICY"))
 	roomService := services.NewRoomService(roomRepo)
 	messageService := services.NewMessageService(messageRepo)
 	tagService := services.NewTagService(tagRepo)
+	exportService := services.NewExportService(exportRepo, userRepo, roomRepo, messageRepo, getEnv("EXPORT_DIR", "./exports"))
+	legalHoldService := services.NewLegalHoldService(legalHoldRepo, userRepo, roomRepo)
+	retentionService := services.NewRetentionService(roomRepo, messageRepo, services.RetentionConfig{
+		DefaultDays: getEnvInt("RETENTION_DAYS", 0),
+		Mode:        getEnv("RETENTION_MODE", services.RetentionModeDelete),
+		Interval:    getEnvDuration("RETENTION_INTERVAL", time.Hour),
+		BatchSize:   getEnvInt("RETENTION_BATCH_SIZE", 500),
+		BatchPause:  getEnvDuration("RETENTION_BATCH_PAUSE", 50*time.Millisecond),
+	})
+	pinService := services.NewPinService(pinRepo, messageRepo, roomRepo, userRepo, getEnvInt("ROOM_PIN_LIMIT", 25))
+	notificationService := services.NewNotificationService(notificationRepo)
+	mentionService := services.NewMentionService(mentionRepo, userRepo, notificationService)
+	webhookService := services.NewWebhookService(webhookRepo, roomRepo, services.WebhookConfig{
+		MaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		RetryBase:    getEnvDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		RetryMax:     getEnvDuration("WEBHOOK_RETRY_MAX", time.Hour),
+		Timeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		PollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+	})
+	apiTokenService := services.NewAPITokenService(apiTokenRepo, userRepo)
+	commandService := services.NewCommandService(commandRepo, roomService, userRepo, notificationService, services.CommandConfig{
+		Timeout:      getEnvDuration("COMMAND_TIMEOUT", 5*time.Second),
+		AllowPrivate: os.Getenv("COMMAND_ALLOW_PRIVATE") == "true",
+	})
+	unfurlService := services.NewUnfurlService(linkPreviewRepo, messageRepo, services.UnfurlConfig{
+		Timeout:      getEnvDuration("UNFURL_TIMEOUT", 5*time.Second),
+		MaxBytes:     int64(getEnvInt("UNFURL_MAX_BYTES", 512*1024)),
+		MaxLinks:     getEnvInt("UNFURL_MAX_LINKS", 3),
+		CacheTTL:     getEnvDuration("UNFURL_CACHE_TTL", 24*time.Hour),
+		Workers:      getEnvInt("UNFURL_WORKERS", 2),
+		AllowPrivate: os.Getenv("UNFURL_ALLOW_PRIVATE") == "true",
+	})
+	moderationService := services.NewModerationService(moderationRepo, messageRepo, roomRepo, userRepo)
+	incomingWebhookService := services.NewIncomingWebhookService(incomingWebhookRepo, messageRepo, roomRepo, userRepo, moderationService)
+	presenceService := services.NewPresenceService(userRepo, getEnvDuration("PRESENCE_AWAY_AFTER", 5*time.Minute))
 
 	// Inicializar hub WebSocket
-	hub := websocket.NewHub()
+	hub := websocket.NewHub(eventRepo, presenceService)
+	presenceService.SetNotifier(hub)
+	userService.SetNotifier(hub)
+	userService.SetNotifications(notificationService)
+	notificationService.SetNotifier(hub)
+	hub.SetEventSink(webhookService)
+	roomService.SetNotifier(hub)
+	pinService.SetNotifier(hub)
+	unfurlService.SetNotifier(hub)
 	go hub.Run()
+	go presenceService.Run()
+	go exportService.Run()
+	go retentionService.Run()
+	go webhookService.Run()
+	go unfurlService.Run()
 
 	// Inicializar controllers
 	userController := controllers.NewUserController(userService)
-	roomController := controllers.NewRoomController(roomService, userService, messageService)
+	roomController := controllers.NewRoomController(roomService, userService, messageService, notificationService)
 	tagController := controllers.NewTagController(tagService)
-	wsHandler := websocket.NewHandler(hub, userRepo, messageRepo, roomRepo)
+	exportController := controllers.NewExportController(exportService)
+	retentionController := controllers.NewRetentionController(retentionService, roomService)
+	legalHoldController := controllers.NewLegalHoldController(legalHoldService)
+	pinController := controllers.NewPinController(pinService)
+	mentionController := controllers.NewMentionController(mentionService, userService)
+	notificationController := controllers.NewNotificationController(notificationService, userService)
+	webhookController := controllers.NewWebhookController(webhookService)
+	incomingWebhookController := controllers.NewIncomingWebhookController(incomingWebhookService)
+	apiTokenController := controllers.NewAPITokenController(apiTokenService, userService)
+	commandController := controllers.NewCommandController(commandService)
+	moderationController := controllers.NewModerationController(moderationService)
+	wsHandler := websocket.NewHandler(hub, userRepo, messageRepo, roomRepo, eventRepo, pinRepo, mentionService, notificationService, commandService, unfurlService, moderationService, websocket.Config{
+		PingInterval:   getEnvDuration("WS_PING_INTERVAL", 30*time.Second),
+		PongWait:       getEnvDuration("WS_PONG_WAIT", 60*time.Second),
+		WriteWait:      getEnvDuration("WS_WRITE_WAIT", 10*time.Second),
+		MaxMessageSize: int64(getEnvInt("WS_MAX_MESSAGE_SIZE", 64*1024)),
+	})
+	incomingWebhookService.SetNotifier(wsHandler)
 
 	// Configurar Fiber
 	app := fiber.New(fiber.Config{
@@ -102,8 +183,8 @@ func main() {
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: corsOrigin,
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-User-ID",
+		AllowMethods: "GET, POST, PUT, PATCH, DELETE, OPTIONS",
 	}))
 
 	// Swagger documentation
@@ -138,49 +219,130 @@ func main() {
 		})
 	})
 
+	// Autenticação por token de API (Authorization: Bearer) e escopos exigidos dos tokens.
+	// Toda rota declara o escopo que um token precisa ter; o restante exige admin.
+	tokenAuth := middleware.TokenAuth(apiTokenService)
+	readRooms := middleware.RequireScope(models.ScopeRoomsRead)
+	manageTags := middleware.RequireScope(models.ScopeTagsManage)
+	manageCommands := middleware.RequireScope(models.ScopeCommandsManage)
+	requireAdmin := middleware.RequireScope(models.ScopeAdmin)
+
+	// Contas de serviço não agem por X-User-ID/user_id, apenas com o próprio token
+	humanActor := middleware.ServiceAccountsRequireToken(userService)
+	humanActorIn := func(fields ...string) fiber.Handler {
+		return middleware.ServiceAccountsRequireToken(userService, fields...)
+	}
+
 	// API v1
-	api := app.Group("/api/v1")
+	api := app.Group("/api/v1", tokenAuth)
 
 	// Rotas de usuários
-	users := api.Group("/users")
+	users := api.Group("/users", requireAdmin)
 	users.Post("/", userController.Create)
 	users.Get("/", userController.GetAll)
 	users.Get("/:id", userController.GetByID)
 	users.Put("/:id", userController.Update)
+	users.Patch("/:id", userController.Patch)
 	users.Delete("/:id", userController.Delete)
+	users.Post("/:id/export", exportController.RequestUserExport)
+
+	// Rotas do usuário atual (identificado pelo token ou, sem token, por X-User-ID ou user_id)
+	me := api.Group("/me", readRooms, humanActor)
+	me.Get("/mentions", mentionController.GetMine)
+	me.Post("/mentions/read", mentionController.MarkRead)
+	me.Get("/notifications", notificationController.GetMine)
+	me.Delete("/notifications", notificationController.Clear)
+	me.Post("/notifications/read", notificationController.MarkRead)
+	me.Get("/notifications/preferences", notificationController.GetPreferences)
+	me.Put("/notifications/preferences", notificationController.UpdatePreferences)
+	me.Delete("/notifications/:id", notificationController.Delete)
+
+	// Rotas de exportação de dados pessoais
+	exports := api.Group("/exports", requireAdmin)
+	exports.Get("/:id", exportController.GetByID)
+	exports.Get("/:id/download", exportController.Download)
 
 	// Rotas de salas
 	rooms := api.Group("/rooms")
-	rooms.Post("/", roomController.Create)
-	rooms.Get("/", roomController.GetAll)
-	rooms.Get("/public", roomController.GetPublicRooms) // Deve vir antes de /:id
-	rooms.Get("/:id", roomController.GetByID)
-	rooms.Get("/:id/messages", roomController.GetMessages)
-	rooms.Put("/:id", roomController.Update)
-	rooms.Delete("/:id", roomController.Delete)
+	rooms.Post("/", requireAdmin, humanActorIn("created_by"), roomController.Create)
+	rooms.Get("/", readRooms, humanActor, roomController.GetAll)
+	rooms.Get("/public", readRooms, roomController.GetPublicRooms) // Deve vir antes de /:id
+	rooms.Get("/:id", readRooms, roomController.GetByID)
+	rooms.Get("/:id/messages", readRooms, roomController.GetMessages)
+	rooms.Get("/:id/export", readRooms, roomController.Export)
+	rooms.Post("/:id/invites", requireAdmin, humanActorIn("invited_by"), roomController.Invite)
+	rooms.Get("/:id/pins", readRooms, pinController.GetByRoom)
+	rooms.Post("/:id/pins", requireAdmin, humanActorIn("user_id"), pinController.Pin)
+	rooms.Delete("/:id/pins/:message_id", requireAdmin, humanActor, pinController.Unpin)
+	rooms.Get("/:id/hooks", requireAdmin, incomingWebhookController.GetByRoom)
+	rooms.Post("/:id/hooks", requireAdmin, humanActorIn("user_id"), incomingWebhookController.Create)
+	rooms.Delete("/:id/hooks/:hook_id", requireAdmin, humanActor, incomingWebhookController.Delete)
+	rooms.Get("/:id/moderation/rules", readRooms, moderationController.GetRules)
+	rooms.Post("/:id/moderation/rules", requireAdmin, humanActorIn("user_id"), moderationController.CreateRule)
+	rooms.Delete("/:id/moderation/rules/:rule_id", requireAdmin, humanActor, moderationController.DeleteRule)
+	rooms.Post("/:id/archive", requireAdmin, roomController.Archive)
+	rooms.Post("/:id/unarchive", requireAdmin, roomController.Unarchive)
+	rooms.Put("/:id", requireAdmin, roomController.Update)
+	rooms.Delete("/:id", requireAdmin, roomController.Delete)
+
+	// Webhooks de entrada: sistemas externos publicam mensagens de bots (autenticados pelo token do hook)
+	api.Post("/hooks/:token", incomingWebhookController.Post)
+
+	// Comandos de barra: listagem para autocomplete e registro por contas de serviço
+	commands := api.Group("/commands")
+	commands.Get("/", readRooms, commandController.GetAll)
+	commands.Post("/", manageCommands, commandController.Register)
+	commands.Delete("/:name", manageCommands, commandController.Unregister)
 
 	// Rotas de tags
 	tags := api.Group("/tags")
-	tags.Post("/", tagController.Create)
-	tags.Get("/", tagController.GetAll)
-	tags.Delete("/:id", tagController.Delete)
+	tags.Post("/", manageTags, tagController.Create)
+	tags.Get("/", readRooms, tagController.GetAll)
+	tags.Delete("/:id", manageTags, tagController.Delete)
 
 	// Rotas administrativas
-	admin := api.Group("/admin")
+	admin := api.Group("/admin", requireAdmin)
 	admin.Put("/users/:id/tags", userController.UpdateTags)
 	admin.Put("/users/:id/role", userController.UpdateRole)
 	admin.Get("/users/role/:role", userController.GetByRole)
 	admin.Post("/rooms", roomController.CreateWithAccess)
-
-	// WebSocket
+	admin.Put("/rooms/:id/retention", retentionController.UpdateRoomRetention)
+	admin.Get("/retention", retentionController.GetStats)
+	admin.Post("/retention/run", retentionController.Run)
+	admin.Post("/legal-holds", legalHoldController.Create)
+	admin.Get("/legal-holds", legalHoldController.GetAll)
+	admin.Get("/legal-holds/:id", legalHoldController.GetByID)
+	admin.Delete("/legal-holds/:id", legalHoldController.Release)
+	admin.Post("/webhooks", webhookController.Create)
+	admin.Get("/webhooks", webhookController.GetAll)
+	admin.Get("/webhooks/:id", webhookController.GetByID)
+	admin.Patch("/webhooks/:id", webhookController.Update)
+	admin.Delete("/webhooks/:id", webhookController.Delete)
+	admin.Get("/webhooks/:id/deliveries", webhookController.GetDeliveries)
+	admin.Get("/webhooks/:id/deliveries/:delivery_id", webhookController.GetDelivery)
+	admin.Post("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookController.Redeliver)
+	admin.Post("/bots", apiTokenController.CreateBot)
+	admin.Get("/bots", apiTokenController.GetBots)
+	admin.Post("/tokens", apiTokenController.Create)
+	admin.Get("/tokens", apiTokenController.GetAll)
+	admin.Delete("/tokens/:id", apiTokenController.Revoke)
+	admin.Get("/moderation/flags", moderationController.GetFlags)
+	admin.Post("/moderation/flags/:id/resolve", moderationController.ResolveFlag)
+
+	// Schema do protocolo WebSocket
+	api.Get("/ws/schema", websocket.SchemaHandler)
+
+	// WebSocket (tokens de API precisam do escopo rooms:read para conectar)
 	app.Use("/ws", func(c *fiber.Ctx) error {
 		if ws.IsWebSocketUpgrade(c) {
 			return c.Next()
 		}
 		return fiber.ErrUpgradeRequired
-	})
+	}, tokenAuth, readRooms)
 
-	app.Get("/ws", ws.New(wsHandler.HandleWebSocket))
+	app.Get("/ws", ws.New(wsHandler.HandleWebSocket, ws.Config{
+		Subprotocols: websocket.Subprotocols(),
+	}))
 
 	// Middleware 404
 	app.Use(func(c *fiber.Ctx) error {
@@ -203,3 +365,43 @@ func main() {
 		log.Fatalf("❌ Erro ao iniciar servidor: %v", err)
 	}
 }
+
+// getEnv lê uma variável de ambiente, usando o padrão se ausente
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvDuration lê uma duração (ex.: "30s") da variável de ambiente, usando o padrão se ausente ou inválida
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para %s: %s, usando %v", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return duration
+}
+
+// getEnvInt lê um inteiro da variável de ambiente, usando o padrão se ausente ou inválido
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para %s: %s, usando %d", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return number
+}
@@ -0,0 +1,51 @@
+// Comando slack-import importa usuários, canais e mensagens de um export de workspace
+// do Slack. Pode ser executado de novo sobre o mesmo arquivo sem duplicar dados.
+//
+// Uso:
+//
+//	go run ./cmd/slack-import <export.zip>
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/database"
+	"github.com/rafael-bit/whatz/internal/importer"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatalf("❌ Uso: slack-import <export.zip>")
+	}
+
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "./whatz.db"
+	}
+
+	db, err := database.NewDatabase(dbPath)
+	if err != nil {
+		log.Fatalf("❌ Erro ao conectar com banco de dados: %v", err)
+	}
+	defer db.Close()
+
+	slackImporter := importer.NewSlackImporter(
+		repository.NewUserRepository(db.DB),
+		repository.NewRoomRepository(db.DB),
+		repository.NewMessageRepository(db.DB),
+	)
+
+	start := time.Now()
+	result, err := slackImporter.Import(os.Args[1])
+	if err != nil {
+		log.Fatalf("❌ Erro na importação: %v", err)
+	}
+
+	log.Printf("👥 Usuários: %d criados, %d já existentes", result.UsersCreated, result.UsersMatched)
+	log.Printf("🏠 Salas: %d criadas, %d já existentes", result.RoomsCreated, result.RoomsExisting)
+	log.Printf("💬 Mensagens: %d importadas, %d ignoradas ou já existentes", result.MessagesCreated, result.MessagesSkipped)
+	log.Printf("✅ Importação concluída em %v", time.Since(start))
+}
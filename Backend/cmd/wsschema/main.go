@@ -0,0 +1,23 @@
+// Comando wsschema gera o JSON Schema do protocolo WebSocket.
+//
+// Uso:
+//
+//	go run ./cmd/wsschema > docs/websocket.schema.json
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/rafael-bit/whatz/internal/websocket"
+)
+
+func main() {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(websocket.GenerateSchema()); err != nil {
+		log.Fatalf("❌ Erro ao gerar schema: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// CreateBotRequest representa a requisição para criar uma conta de serviço
+// @Description Conta usada por integrações no lugar de um usuário humano
+type CreateBotRequest struct {
+	// @Description Nome da conta de serviço
+	// @Example "deploy-bot"
+	Username string `json:"username" validate:"required"`
+	// @Description URL do avatar
+	// @Example "https://example.com/bot.png"
+	Avatar string `json:"avatar"`
+}
+
+// CreateAPITokenRequest representa a requisição para emitir um token de API
+// @Description Usuário dono do token, nome descritivo e escopos concedidos
+type CreateAPITokenRequest struct {
+	// @Description ID do usuário (normalmente uma conta de serviço)
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	UserID string `json:"user_id" validate:"required"`
+	// @Description Nome descritivo do token
+	// @Example "CI"
+	Name string `json:"name" validate:"required"`
+	// @Description Escopos: rooms:read, messages:write, tags:manage, commands:manage, admin
+	// @Example ["rooms:read", "messages:write"]
+	Scopes []string `json:"scopes" validate:"required"`
+	// @Description Responsável pela emissão
+	// @Example "admin"
+	CreatedBy string `json:"created_by"`
+}
+
+type APITokenController struct {
+	tokenService *services.APITokenService
+	userService  *services.UserService
+}
+
+func NewAPITokenController(tokenService *services.APITokenService, userService *services.UserService) *APITokenController {
+	return &APITokenController{
+		tokenService: tokenService,
+		userService:  userService,
+	}
+}
+
+// CreateBot godoc
+// @Summary Criar conta de serviço
+// @Description Cria um usuário do tipo bot para integrações, que se autentica apenas com tokens de API
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param bot body CreateBotRequest true "Dados da conta de serviço"
+// @Success 201 {object} map[string]interface{} "Conta de serviço criada"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 409 {object} map[string]interface{} "Nome já está em uso"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/bots [post]
+func (c *APITokenController) CreateBot(ctx *fiber.Ctx) error {
+	var req CreateBotRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "username é obrigatório",
+		})
+	}
+
+	bot, err := c.userService.CreateBot(username, req.Avatar)
+	if err != nil {
+		if status, message, ok := profileConflict(err); ok {
+			return ctx.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao criar conta de serviço",
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Conta de serviço criada",
+		"user":    bot,
+	})
+}
+
+// GetBots godoc
+// @Summary Listar contas de serviço
+// @Description Retorna os usuários do tipo bot
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Contas de serviço"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/bots [get]
+func (c *APITokenController) GetBots(ctx *fiber.Ctx) error {
+	bots, err := c.userService.GetBots()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"users": bots,
+		"count": len(bots),
+	})
+}
+
+// Create godoc
+// @Summary Emitir token de API
+// @Description Emite um token de longa duração com escopos para o usuário. O token só é exibido nesta resposta; use-o em "Authorization: Bearer <token>" ou, no WebSocket, em ?access_token=
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param token body CreateAPITokenRequest true "Dados do token"
+// @Success 201 {object} map[string]interface{} "Token emitido"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/tokens [post]
+func (c *APITokenController) Create(ctx *fiber.Ctx) error {
+	var req CreateAPITokenRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	if req.UserID == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user_id é obrigatório",
+		})
+	}
+
+	token, raw, err := c.tokenService.Create(req.UserID, req.Name, req.Scopes, req.CreatedBy)
+	if err != nil {
+		return apiTokenError(ctx, err, "Erro ao emitir token de API")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":   "Token de API emitido",
+		"api_token": token,
+		"token":     raw,
+	})
+}
+
+// GetAll godoc
+// @Summary Listar tokens de API
+// @Description Retorna os tokens emitidos (sem o segredo), incluindo os revogados
+// @Tags admin
+// @Produce json
+// @Param user_id query string false "Filtrar pelo dono do token"
+// @Success 200 {object} map[string]interface{} "Tokens de API"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/tokens [get]
+func (c *APITokenController) GetAll(ctx *fiber.Ctx) error {
+	tokens, err := c.tokenService.GetAll(ctx.Query("user_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"tokens": tokens,
+		"count":  len(tokens),
+	})
+}
+
+// Revoke godoc
+// @Summary Revogar token de API
+// @Description Invalida o token imediatamente; o registro permanece para auditoria
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID do token"
+// @Success 200 {object} map[string]interface{} "Token revogado"
+// @Failure 404 {object} map[string]interface{} "Token não encontrado ou já revogado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/tokens/{id} [delete]
+func (c *APITokenController) Revoke(ctx *fiber.Ctx) error {
+	if err := c.tokenService.Revoke(ctx.Params("id")); err != nil {
+		return apiTokenError(ctx, err, "Erro ao revogar token de API")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Token de API revogado",
+	})
+}
+
+// apiTokenError traduz os erros do serviço de tokens em respostas HTTP
+func apiTokenError(ctx *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrAPITokenNameRequired),
+		errors.Is(err, services.ErrAPITokenScopeRequired),
+		errors.Is(err, services.ErrInvalidAPITokenScope):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrAPITokenUserNotFound),
+		errors.Is(err, services.ErrAPITokenNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/middleware"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// RegisterCommandRequest representa a requisição para registrar um comando de barra
+// @Description Comando de uma integração, executado por chamada ao callback_url
+type RegisterCommandRequest struct {
+	// @Description Nome do comando, sem a barra
+	// @Example "deploy"
+	Name string `json:"name" validate:"required"`
+	// @Description Descrição exibida no autocomplete
+	// @Example "Dispara o deploy de um serviço"
+	Description string `json:"description"`
+	// @Description Exemplo de uso
+	// @Example "/deploy <serviço>"
+	Usage string `json:"usage"`
+	// @Description URL chamada (POST) a cada execução
+	// @Example "https://ci.example.com/whatz/commands"
+	CallbackURL string `json:"callback_url" validate:"required"`
+}
+
+type CommandController struct {
+	commandService *services.CommandService
+}
+
+func NewCommandController(commandService *services.CommandService) *CommandController {
+	return &CommandController{
+		commandService: commandService,
+	}
+}
+
+// GetAll godoc
+// @Summary Listar comandos de barra
+// @Description Retorna os comandos disponíveis nas salas (embutidos e de integrações), para autocomplete
+// @Tags commands
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Comandos disponíveis"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /commands [get]
+func (c *CommandController) GetAll(ctx *fiber.Ctx) error {
+	commands, err := c.commandService.GetAll()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"commands": commands,
+		"count":    len(commands),
+	})
+}
+
+// Register godoc
+// @Summary Registrar comando de barra
+// @Description Registra (ou atualiza) um comando da conta de serviço autenticada pelo token de API (escopo commands:manage). O segredo que assina as chamadas ao callback só é exibido nesta resposta.
+// @Tags commands
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param command body RegisterCommandRequest true "Dados do comando"
+// @Success 201 {object} map[string]interface{} "Comando registrado"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 401 {object} map[string]interface{} "Token de API ausente ou inválido"
+// @Failure 403 {object} map[string]interface{} "Token sem escopo ou de usuário que não é conta de serviço"
+// @Failure 409 {object} map[string]interface{} "Comando já existe"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /commands [post]
+func (c *CommandController) Register(ctx *fiber.Ctx) error {
+	token := middleware.CurrentToken(ctx)
+	if token == nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Registre comandos com o token de API da conta de serviço",
+		})
+	}
+
+	var req RegisterCommandRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	command, err := c.commandService.Register(token.UserID, req.Name, req.Description, req.Usage, req.CallbackURL)
+	if err != nil {
+		return commandError(ctx, err, "Erro ao registrar comando")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Comando registrado",
+		"command":      command,
+		"callback_url": command.CallbackURL,
+		"secret":       command.Secret,
+	})
+}
+
+// Unregister godoc
+// @Summary Remover comando de barra
+// @Description Remove um comando da conta de serviço autenticada pelo token de API (escopo commands:manage)
+// @Tags commands
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Nome do comando"
+// @Success 200 {object} map[string]interface{} "Comando removido"
+// @Failure 401 {object} map[string]interface{} "Token de API ausente ou inválido"
+// @Failure 404 {object} map[string]interface{} "Comando não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /commands/{name} [delete]
+func (c *CommandController) Unregister(ctx *fiber.Ctx) error {
+	token := middleware.CurrentToken(ctx)
+	if token == nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Remova comandos com o token de API da conta de serviço",
+		})
+	}
+
+	if err := c.commandService.Unregister(token.UserID, ctx.Params("name")); err != nil {
+		return commandError(ctx, err, "Erro ao remover comando")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Comando removido",
+	})
+}
+
+// commandError traduz os erros do serviço de comandos em respostas HTTP
+func commandError(ctx *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCommandName),
+		errors.Is(err, services.ErrCommandCallbackInvalid):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrCommandBotRequired):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrCommandNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrCommandTaken):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
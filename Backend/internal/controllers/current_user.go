@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/middleware"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// resolveCurrentUser valida o usuário atual. Quando o usuário não é informado ou não existe,
+// a resposta de erro já é escrita e o ID retornado é vazio.
+func resolveCurrentUser(ctx *fiber.Ctx, userService *services.UserService) (string, error) {
+	userID := currentUserID(ctx)
+	if userID == "" {
+		return "", ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Informe o usuário no cabeçalho X-User-ID ou em user_id",
+		})
+	}
+
+	user, err := userService.GetByID(userID)
+	if err != nil {
+		return "", ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if user == nil {
+		return "", ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Usuário não encontrado",
+		})
+	}
+
+	return user.ID, nil
+}
+
+// actingUserID identifica quem executa a ação a partir do user_id informado. Com token de API
+// é sempre o dono do token, e um user_id diferente é recusado; sem token, é o próprio user_id.
+// Em caso de erro a resposta já é escrita e o ID retornado é vazio.
+func actingUserID(ctx *fiber.Ctx, userID string) (string, error) {
+	if token := middleware.CurrentToken(ctx); token != nil {
+		if userID != "" && userID != token.UserID {
+			return "", ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "user_id difere do usuário do token de API",
+			})
+		}
+		return token.UserID, nil
+	}
+
+	if userID == "" {
+		return "", ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user_id é obrigatório",
+		})
+	}
+	return userID, nil
+}
+
+// currentUserID identifica o usuário das rotas /me pelo token de API ou, sem token,
+// pelo cabeçalho X-User-ID e, na falta dele, por user_id
+func currentUserID(ctx *fiber.Ctx) string {
+	if token := middleware.CurrentToken(ctx); token != nil {
+		return token.UserID
+	}
+	if userID := ctx.Get("X-User-ID"); userID != "" {
+		return userID
+	}
+	return ctx.Query("user_id")
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/middleware"
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+func TestActingUserIDFollowsToken(t *testing.T) {
+	app := fiber.New()
+	app.Post("/", func(ctx *fiber.Ctx) error {
+		if ctx.Get(fiber.HeaderAuthorization) != "" {
+			ctx.Locals(middleware.TokenLocalsKey, &models.APIToken{UserID: "dono"})
+		}
+		var req PinMessageRequest
+		if err := ctx.BodyParser(&req); err != nil {
+			return err
+		}
+		userID, err := actingUserID(ctx, req.UserID)
+		if userID == "" {
+			return err
+		}
+		return ctx.SendString(userID)
+	})
+
+	cases := []struct {
+		token  bool
+		body   string
+		status int
+		user   string
+	}{
+		{true, `{"user_id": "admin"}`, fiber.StatusForbidden, ""},
+		{true, `{"user_id": "dono"}`, fiber.StatusOK, "dono"},
+		{true, `{}`, fiber.StatusOK, "dono"},
+		{false, `{"user_id": "admin"}`, fiber.StatusOK, "admin"},
+		{false, `{}`, fiber.StatusBadRequest, ""},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(tc.body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if tc.token {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer token")
+		}
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("erro na requisição: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tc.status {
+			t.Errorf("token=%v corpo=%s: status %d, esperado %d (%s)", tc.token, tc.body, resp.StatusCode, tc.status, body)
+			continue
+		}
+		if tc.user != "" && string(body) != tc.user {
+			t.Errorf("token=%v corpo=%s: usuário %q, esperado %q", tc.token, tc.body, body, tc.user)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+type ExportController struct {
+	exportService *services.ExportService
+}
+
+func NewExportController(exportService *services.ExportService) *ExportController {
+	return &ExportController{
+		exportService: exportService,
+	}
+}
+
+// RequestUserExport godoc
+// @Summary Solicitar exportação de dados pessoais
+// @Description Agenda a geração de um ZIP com perfil, tags, salas e mensagens do usuário. Acompanhe pelo status da exportação.
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do usuário"
+// @Success 202 {object} map[string]interface{} "Exportação agendada"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /users/{id}/export [post]
+func (c *ExportController) RequestUserExport(ctx *fiber.Ctx) error {
+	userID := ctx.Params("id")
+
+	export, err := c.exportService.Request(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrExportUserNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Usuário não encontrado",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao agendar exportação",
+		})
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Exportação agendada",
+		"export":  export,
+	})
+}
+
+// GetByID godoc
+// @Summary Status da exportação
+// @Description Retorna o andamento de uma exportação de dados pessoais (pending, running, completed, failed)
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da exportação"
+// @Success 200 {object} map[string]interface{} "Exportação encontrada"
+// @Failure 404 {object} map[string]interface{} "Exportação não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /exports/{id} [get]
+func (c *ExportController) GetByID(ctx *fiber.Ctx) error {
+	export, err := c.exportService.GetByID(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if export == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Exportação não encontrada",
+		})
+	}
+
+	response := fiber.Map{
+		"export": export,
+	}
+	if export.Status == models.ExportStatusCompleted {
+		response["download_url"] = fmt.Sprintf("/api/v1/exports/%s/download", export.ID)
+	}
+
+	return ctx.JSON(response)
+}
+
+// Download godoc
+// @Summary Baixar exportação
+// @Description Baixa o arquivo ZIP de uma exportação concluída
+// @Tags exports
+// @Produce application/zip
+// @Param id path string true "ID da exportação"
+// @Success 200 {file} file "Arquivo ZIP"
+// @Failure 404 {object} map[string]interface{} "Exportação não encontrada"
+// @Failure 409 {object} map[string]interface{} "Exportação ainda não concluída"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /exports/{id}/download [get]
+func (c *ExportController) Download(ctx *fiber.Ctx) error {
+	export, err := c.exportService.GetByID(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if export == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Exportação não encontrada",
+		})
+	}
+
+	if export.Status != models.ExportStatusCompleted {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  "Exportação ainda não concluída",
+			"status": export.Status,
+		})
+	}
+
+	return ctx.Download(export.FilePath, fmt.Sprintf("whatz-export-%s.zip", export.UserID))
+}
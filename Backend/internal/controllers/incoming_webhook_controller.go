@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// CreateIncomingWebhookRequest representa a requisição para criar um webhook de entrada
+// @Description Bot que publicará mensagens na sala e quem o está criando
+type CreateIncomingWebhookRequest struct {
+	// @Description Nome exibido como autor das mensagens
+	// @Example "Monitoramento"
+	Name string `json:"name" validate:"required"`
+	// @Description URL do avatar do bot
+	// @Example "https://example.com/bot.png"
+	Avatar string `json:"avatar"`
+	// @Description ID do usuário que cria o webhook (moderador da sala ou administrador); com token de API, o dono do token
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	UserID string `json:"user_id"`
+}
+
+type IncomingWebhookController struct {
+	hookService *services.IncomingWebhookService
+}
+
+func NewIncomingWebhookController(hookService *services.IncomingWebhookService) *IncomingWebhookController {
+	return &IncomingWebhookController{
+		hookService: hookService,
+	}
+}
+
+// GetByRoom godoc
+// @Summary Listar webhooks de entrada
+// @Description Retorna os webhooks de entrada da sala (sem os tokens)
+// @Tags rooms
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Success 200 {object} map[string]interface{} "Webhooks de entrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/hooks [get]
+func (c *IncomingWebhookController) GetByRoom(ctx *fiber.Ctx) error {
+	hooks, err := c.hookService.GetByRoom(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"hooks": hooks,
+		"count": len(hooks),
+	})
+}
+
+// Create godoc
+// @Summary Criar webhook de entrada
+// @Description Gera um token que permite a sistemas externos publicar mensagens na sala via POST /hooks/{token}; permitido ao moderador (criador) da sala e a administradores. O token só é exibido nesta resposta.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param hook body CreateIncomingWebhookRequest true "Dados do webhook"
+// @Success 201 {object} map[string]interface{} "Webhook de entrada criado"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 403 {object} map[string]interface{} "Usuário sem permissão"
+// @Failure 404 {object} map[string]interface{} "Sala ou usuário não encontrado"
+// @Failure 409 {object} map[string]interface{} "Sala arquivada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/hooks [post]
+func (c *IncomingWebhookController) Create(ctx *fiber.Ctx) error {
+	var req CreateIncomingWebhookRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	userID, err := actingUserID(ctx, req.UserID)
+	if userID == "" {
+		return err
+	}
+
+	hook, token, err := c.hookService.Create(ctx.Params("id"), req.Name, req.Avatar, userID)
+	if err != nil {
+		return incomingWebhookError(ctx, err, "Erro ao criar webhook de entrada")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Webhook de entrada criado",
+		"hook":    hook,
+		"token":   token,
+	})
+}
+
+// Delete godoc
+// @Summary Revogar webhook de entrada
+// @Description Revoga o token; mensagens já publicadas permanecem. Permitido ao moderador (criador) da sala e a administradores
+// @Tags rooms
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param hook_id path string true "ID do webhook de entrada"
+// @Param user_id query string false "ID do usuário que revoga o webhook (com token de API, o dono do token)"
+// @Success 200 {object} map[string]interface{} "Webhook de entrada revogado"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 403 {object} map[string]interface{} "Usuário sem permissão"
+// @Failure 404 {object} map[string]interface{} "Sala, usuário ou webhook não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/hooks/{hook_id} [delete]
+func (c *IncomingWebhookController) Delete(ctx *fiber.Ctx) error {
+	userID, err := actingUserID(ctx, ctx.Query("user_id"))
+	if userID == "" {
+		return err
+	}
+
+	if err := c.hookService.Delete(ctx.Params("id"), ctx.Params("hook_id"), userID); err != nil {
+		return incomingWebhookError(ctx, err, "Erro ao revogar webhook de entrada")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Webhook de entrada revogado",
+	})
+}
+
+// Post godoc
+// @Summary Publicar mensagem via webhook
+// @Description Publica uma mensagem de bot (type "bot") na sala do token e a transmite aos clientes conectados como "new_message"
+// @Tags hooks
+// @Accept json
+// @Produce json
+// @Param token path string true "Token do webhook de entrada"
+// @Param message body services.IncomingMessage true "Mensagem a publicar"
+// @Success 201 {object} map[string]interface{} "Mensagem publicada"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Token inválido ou sala não encontrada"
+// @Failure 409 {object} map[string]interface{} "Sala arquivada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /hooks/{token} [post]
+func (c *IncomingWebhookController) Post(ctx *fiber.Ctx) error {
+	var req services.IncomingMessage
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	message, err := c.hookService.Post(ctx.Params("token"), &req)
+	if err != nil {
+		return incomingWebhookError(ctx, err, "Erro ao publicar mensagem")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": message,
+	})
+}
+
+// incomingWebhookError traduz os erros do serviço de webhooks de entrada em respostas HTTP
+func incomingWebhookError(ctx *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrIncomingWebhookInvalidName),
+		errors.Is(err, services.ErrIncomingWebhookInvalidAvatar),
+		errors.Is(err, services.ErrIncomingMessageEmpty),
+		errors.Is(err, services.ErrIncomingMessageTooLong):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrIncomingWebhookNotFound),
+		errors.Is(err, services.ErrIncomingWebhookRoomNotFound),
+		errors.Is(err, services.ErrIncomingWebhookUserNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrIncomingWebhookForbidden):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrIncomingWebhookRoomArchived):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrIncomingMessageBlocked):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// CreateLegalHoldRequest representa a requisição para aplicar uma retenção legal
+// @Description Sala ou usuário cujo conteúdo não pode ser removido
+type CreateLegalHoldRequest struct {
+	// @Description Escopo da retenção (room ou user)
+	// @Example "room"
+	Scope string `json:"scope" validate:"required,oneof=room user"`
+	// @Description ID da sala ou do usuário
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	TargetID string `json:"target_id" validate:"required"`
+	// @Description Motivo da retenção
+	// @Example "Processo 1234/2024"
+	Reason string `json:"reason"`
+	// @Description Responsável pela retenção
+	// @Example "juridico@example.com"
+	PlacedBy string `json:"placed_by" validate:"required"`
+}
+
+type LegalHoldController struct {
+	legalHoldService *services.LegalHoldService
+}
+
+func NewLegalHoldController(legalHoldService *services.LegalHoldService) *LegalHoldController {
+	return &LegalHoldController{
+		legalHoldService: legalHoldService,
+	}
+}
+
+// Create godoc
+// @Summary Aplicar retenção legal
+// @Description Impede a remoção de mensagens, da sala ou do usuário (inclusive pela política de retenção) até que seja liberada
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param hold body CreateLegalHoldRequest true "Dados da retenção"
+// @Success 201 {object} map[string]interface{} "Retenção aplicada"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Sala ou usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/legal-holds [post]
+func (c *LegalHoldController) Create(ctx *fiber.Ctx) error {
+	var req CreateLegalHoldRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	if req.TargetID == "" || req.PlacedBy == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "target_id e placed_by são obrigatórios",
+		})
+	}
+
+	hold, err := c.legalHoldService.Place(req.Scope, req.TargetID, req.Reason, req.PlacedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidHoldScope):
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Escopo inválido (use room ou user)",
+			})
+		case errors.Is(err, services.ErrHoldTargetNotFound):
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Sala ou usuário não encontrado",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao aplicar retenção legal",
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Retenção legal aplicada",
+		"hold":    hold,
+	})
+}
+
+// GetAll godoc
+// @Summary Listar retenções legais
+// @Description Lista as retenções legais, incluindo as liberadas (trilha de auditoria)
+// @Tags admin
+// @Produce json
+// @Param active query bool false "Apenas retenções ativas"
+// @Success 200 {object} map[string]interface{} "Lista de retenções"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/legal-holds [get]
+func (c *LegalHoldController) GetAll(ctx *fiber.Ctx) error {
+	holds, err := c.legalHoldService.GetAll(ctx.QueryBool("active"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"holds": holds,
+		"count": len(holds),
+	})
+}
+
+// GetByID godoc
+// @Summary Buscar retenção legal
+// @Description Retorna uma retenção legal pelo ID
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID da retenção"
+// @Success 200 {object} map[string]interface{} "Retenção encontrada"
+// @Failure 404 {object} map[string]interface{} "Retenção não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/legal-holds/{id} [get]
+func (c *LegalHoldController) GetByID(ctx *fiber.Ctx) error {
+	hold, err := c.legalHoldService.GetByID(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if hold == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Retenção legal não encontrada",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"hold": hold,
+	})
+}
+
+// Release godoc
+// @Summary Liberar retenção legal
+// @Description Libera a retenção; o registro é mantido com data e responsável pela liberação
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID da retenção"
+// @Param released_by query string true "Responsável pela liberação"
+// @Success 200 {object} map[string]interface{} "Retenção liberada"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Retenção não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/legal-holds/{id} [delete]
+func (c *LegalHoldController) Release(ctx *fiber.Ctx) error {
+	releasedBy := ctx.Query("released_by")
+	if releasedBy == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "released_by é obrigatório",
+		})
+	}
+
+	hold, err := c.legalHoldService.Release(ctx.Params("id"), releasedBy)
+	if err != nil {
+		if errors.Is(err, services.ErrHoldNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Retenção legal não encontrada",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao liberar retenção legal",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Retenção legal liberada",
+		"hold":    hold,
+	})
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// MarkMentionsReadRequest representa a requisição para marcar menções como lidas
+// @Description Menções a marcar como lidas; sem ids, todas as menções do usuário
+type MarkMentionsReadRequest struct {
+	// @Description IDs das menções
+	// @Example ["123e4567-e89b-12d3-a456-426614174000"]
+	IDs []string `json:"ids"`
+}
+
+type MentionController struct {
+	mentionService *services.MentionService
+	userService    *services.UserService
+}
+
+func NewMentionController(mentionService *services.MentionService, userService *services.UserService) *MentionController {
+	return &MentionController{
+		mentionService: mentionService,
+		userService:    userService,
+	}
+}
+
+// GetMine godoc
+// @Summary Caixa de menções
+// @Description Lista as menções ao usuário atual (@username, @room, @here), da mais recente para a mais antiga, com o total de não lidas
+// @Tags me
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Param unread query bool false "Apenas menções não lidas"
+// @Param limit query int false "Limite de menções (padrão: 50)"
+// @Param offset query int false "Offset para paginação (padrão: 0)"
+// @Success 200 {object} map[string]interface{} "Menções do usuário"
+// @Failure 400 {object} map[string]interface{} "Usuário não informado"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/mentions [get]
+func (c *MentionController) GetMine(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	limit, err := strconv.Atoi(ctx.Query("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	mentions, unread, err := c.mentionService.GetForUser(userID, ctx.QueryBool("unread"), limit, offset)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"mentions":     mentions,
+		"count":        len(mentions),
+		"unread_count": unread,
+		"pagination": fiber.Map{
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// MarkRead godoc
+// @Summary Marcar menções como lidas
+// @Description Marca como lidas as menções informadas do usuário atual, ou todas quando ids não é enviado
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Param request body MarkMentionsReadRequest false "Menções a marcar"
+// @Success 200 {object} map[string]interface{} "Menções marcadas como lidas"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/mentions/read [post]
+func (c *MentionController) MarkRead(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	var req MarkMentionsReadRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Dados inválidos",
+			})
+		}
+	}
+
+	marked, err := c.mentionService.MarkRead(userID, req.IDs)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao marcar menções como lidas",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Menções marcadas como lidas",
+		"marked":  marked,
+	})
+}
@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// CreateModerationRuleRequest representa a requisição para criar uma regra de moderação
+// @Description Filtro de conteúdo da sala e quem o está criando
+type CreateModerationRuleRequest struct {
+	// @Description Tipo do filtro: words (lista de palavras separadas por vírgula ou quebra de linha) ou regex (sintaxe RE2)
+	// @Example "words"
+	Kind string `json:"kind" validate:"required"`
+	// @Description Palavras ou expressão regular
+	// @Example "spam, golpe"
+	Pattern string `json:"pattern" validate:"required"`
+	// @Description Ação quando o filtro encontra o conteúdo: block, mask ou flag
+	// @Example "mask"
+	Action string `json:"action" validate:"required"`
+	// @Description ID do usuário que cria a regra (moderador da sala ou administrador); com token de API, o dono do token
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	UserID string `json:"user_id"`
+}
+
+type ModerationController struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationController(moderationService *services.ModerationService) *ModerationController {
+	return &ModerationController{
+		moderationService: moderationService,
+	}
+}
+
+// GetRules godoc
+// @Summary Listar regras de moderação
+// @Description Retorna os filtros de conteúdo da sala, na ordem em que são aplicados
+// @Tags rooms
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Success 200 {object} map[string]interface{} "Regras de moderação"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/moderation/rules [get]
+func (c *ModerationController) GetRules(ctx *fiber.Ctx) error {
+	rules, err := c.moderationService.GetRules(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// CreateRule godoc
+// @Summary Criar regra de moderação
+// @Description Cadastra um filtro aplicado às mensagens enviadas e editadas na sala; permitido ao moderador (criador) da sala e a administradores
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param rule body CreateModerationRuleRequest true "Dados da regra"
+// @Success 201 {object} map[string]interface{} "Regra criada"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 403 {object} map[string]interface{} "Usuário sem permissão"
+// @Failure 404 {object} map[string]interface{} "Sala ou usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/moderation/rules [post]
+func (c *ModerationController) CreateRule(ctx *fiber.Ctx) error {
+	var req CreateModerationRuleRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	userID, err := actingUserID(ctx, req.UserID)
+	if userID == "" {
+		return err
+	}
+
+	rule, err := c.moderationService.CreateRule(ctx.Params("id"), req.Kind, req.Pattern, req.Action, userID)
+	if err != nil {
+		return moderationError(ctx, err, "Erro ao criar regra de moderação")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Regra de moderação criada",
+		"rule":    rule,
+	})
+}
+
+// DeleteRule godoc
+// @Summary Remover regra de moderação
+// @Description Remove o filtro da sala; permitido ao moderador (criador) da sala e a administradores
+// @Tags rooms
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param rule_id path string true "ID da regra"
+// @Param user_id query string false "ID do usuário que remove a regra (com token de API, o dono do token)"
+// @Success 200 {object} map[string]interface{} "Regra removida"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 403 {object} map[string]interface{} "Usuário sem permissão"
+// @Failure 404 {object} map[string]interface{} "Sala, usuário ou regra não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/moderation/rules/{rule_id} [delete]
+func (c *ModerationController) DeleteRule(ctx *fiber.Ctx) error {
+	userID, err := actingUserID(ctx, ctx.Query("user_id"))
+	if userID == "" {
+		return err
+	}
+
+	if err := c.moderationService.DeleteRule(ctx.Params("id"), ctx.Params("rule_id"), userID); err != nil {
+		return moderationError(ctx, err, "Erro ao remover regra de moderação")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Regra de moderação removida",
+	})
+}
+
+// GetFlags godoc
+// @Summary Listar mensagens sinalizadas
+// @Description Fila de revisão: mensagens publicadas que acionaram regras com a ação flag, das mais recentes para as mais antigas
+// @Tags admin
+// @Produce json
+// @Param status query string false "pending (padrão), resolved ou all"
+// @Param limit query int false "Limite de sinalizações (padrão: 50)"
+// @Param offset query int false "Offset para paginação (padrão: 0)"
+// @Success 200 {object} map[string]interface{} "Sinalizações"
+// @Failure 400 {object} map[string]interface{} "Status inválido"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/moderation/flags [get]
+func (c *ModerationController) GetFlags(ctx *fiber.Ctx) error {
+	status := ctx.Query("status", models.ModerationFlagPending)
+	switch status {
+	case models.ModerationFlagPending, models.ModerationFlagResolved:
+	case "all":
+		status = ""
+	default:
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status deve ser pending, resolved ou all",
+		})
+	}
+
+	limit, err := strconv.Atoi(ctx.Query("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	flags, err := c.moderationService.GetFlags(status, limit, offset)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"flags": flags,
+		"count": len(flags),
+		"pagination": fiber.Map{
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// ResolveFlag godoc
+// @Summary Resolver sinalização
+// @Description Tira a mensagem da fila de revisão, registrando data e responsável
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID da sinalização"
+// @Param reviewed_by query string true "Responsável pela revisão"
+// @Success 200 {object} map[string]interface{} "Sinalização resolvida"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Sinalização não encontrada ou já resolvida"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/moderation/flags/{id}/resolve [post]
+func (c *ModerationController) ResolveFlag(ctx *fiber.Ctx) error {
+	reviewedBy := ctx.Query("reviewed_by")
+	if reviewedBy == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reviewed_by é obrigatório",
+		})
+	}
+
+	flag, err := c.moderationService.ResolveFlag(ctx.Params("id"), reviewedBy)
+	if err != nil {
+		return moderationError(ctx, err, "Erro ao resolver sinalização")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Sinalização resolvida",
+		"flag":    flag,
+	})
+}
+
+// moderationError traduz os erros do serviço de moderação em respostas HTTP
+func moderationError(ctx *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidModerationKind),
+		errors.Is(err, services.ErrInvalidModerationAction),
+		errors.Is(err, services.ErrInvalidModerationPattern):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrModerationRoomNotFound),
+		errors.Is(err, services.ErrModerationUserNotFound),
+		errors.Is(err, services.ErrModerationRuleNotFound),
+		errors.Is(err, services.ErrModerationFlagNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrModerationForbidden):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
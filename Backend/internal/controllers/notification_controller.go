@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// NotificationIDsRequest representa uma seleção de notificações
+// @Description Notificações afetadas; sem ids, todas as notificações do usuário
+type NotificationIDsRequest struct {
+	// @Description IDs das notificações
+	// @Example ["123e4567-e89b-12d3-a456-426614174000"]
+	IDs []string `json:"ids"`
+}
+
+type NotificationController struct {
+	notificationService *services.NotificationService
+	userService         *services.UserService
+}
+
+func NewNotificationController(notificationService *services.NotificationService, userService *services.UserService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+		userService:         userService,
+	}
+}
+
+// GetMine godoc
+// @Summary Listar notificações
+// @Description Lista as notificações do usuário atual, da mais recente para a mais antiga, com o total de não lidas
+// @Tags me
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Param unread query bool false "Apenas notificações não lidas"
+// @Param limit query int false "Limite de notificações (padrão: 50)"
+// @Param offset query int false "Offset para paginação (padrão: 0)"
+// @Success 200 {object} map[string]interface{} "Notificações do usuário"
+// @Failure 400 {object} map[string]interface{} "Usuário não informado"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/notifications [get]
+func (c *NotificationController) GetMine(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	limit, err := strconv.Atoi(ctx.Query("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	notifications, unread, err := c.notificationService.GetForUser(userID, ctx.QueryBool("unread"), limit, offset)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"notifications": notifications,
+		"count":         len(notifications),
+		"unread_count":  unread,
+		"pagination": fiber.Map{
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// MarkRead godoc
+// @Summary Marcar notificações como lidas
+// @Description Marca como lidas as notificações informadas do usuário atual, ou todas quando ids não é enviado
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Param request body NotificationIDsRequest false "Notificações a marcar"
+// @Success 200 {object} map[string]interface{} "Notificações marcadas como lidas"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/notifications/read [post]
+func (c *NotificationController) MarkRead(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	var req NotificationIDsRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Dados inválidos",
+			})
+		}
+	}
+
+	marked, err := c.notificationService.MarkRead(userID, req.IDs)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao marcar notificações como lidas",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Notificações marcadas como lidas",
+		"marked":  marked,
+	})
+}
+
+// Clear godoc
+// @Summary Limpar notificações
+// @Description Remove as notificações informadas do usuário atual, ou todas quando ids não é enviado
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Param read_only query bool false "Remover apenas notificações já lidas"
+// @Param request body NotificationIDsRequest false "Notificações a remover"
+// @Success 200 {object} map[string]interface{} "Notificações removidas"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/notifications [delete]
+func (c *NotificationController) Clear(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	var req NotificationIDsRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Dados inválidos",
+			})
+		}
+	}
+
+	removed, err := c.notificationService.Clear(userID, req.IDs, ctx.QueryBool("read_only"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao limpar notificações",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Notificações removidas",
+		"removed": removed,
+	})
+}
+
+// Delete godoc
+// @Summary Remover notificação
+// @Description Remove uma notificação do usuário atual
+// @Tags me
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Param id path string true "ID da notificação"
+// @Success 200 {object} map[string]interface{} "Notificação removida"
+// @Failure 404 {object} map[string]interface{} "Usuário ou notificação não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/notifications/{id} [delete]
+func (c *NotificationController) Delete(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	removed, err := c.notificationService.Clear(userID, []string{ctx.Params("id")}, false)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao remover notificação",
+		})
+	}
+
+	if removed == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Notificação não encontrada",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Notificação removida",
+	})
+}
+
+// GetPreferences godoc
+// @Summary Preferências de notificação
+// @Description Retorna, para cada tipo (mention, reply, invite, role_change), se o usuário atual recebe notificações
+// @Tags me
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Success 200 {object} map[string]interface{} "Preferências"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/notifications/preferences [get]
+func (c *NotificationController) GetPreferences(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	preferences, err := c.notificationService.Preferences(userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"preferences": preferences,
+	})
+}
+
+// UpdatePreferences godoc
+// @Summary Atualizar preferências de notificação
+// @Description Habilita ou desabilita tipos de notificação do usuário atual; tipos omitidos não mudam
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "ID do usuário atual"
+// @Param user_id query string false "ID do usuário atual (alternativa ao cabeçalho X-User-ID)"
+// @Param preferences body map[string]bool true "Tipo -> habilitado"
+// @Success 200 {object} map[string]interface{} "Preferências atualizadas"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /me/notifications/preferences [put]
+func (c *NotificationController) UpdatePreferences(ctx *fiber.Ctx) error {
+	userID, err := resolveCurrentUser(ctx, c.userService)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	var req map[string]bool
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	preferences, err := c.notificationService.UpdatePreferences(userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidNotificationType) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Tipo de notificação inválido (use mention, reply, invite ou role_change)",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao atualizar preferências",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message":     "Preferências atualizadas",
+		"preferences": preferences,
+	})
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// PinMessageRequest representa a requisição para fixar uma mensagem
+// @Description Mensagem a ser fixada e quem está fixando
+type PinMessageRequest struct {
+	// @Description ID da mensagem
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	MessageID string `json:"message_id" validate:"required"`
+	// @Description ID do usuário que fixa a mensagem (moderador da sala ou administrador); com token de API, o dono do token
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	UserID string `json:"user_id"`
+}
+
+type PinController struct {
+	pinService *services.PinService
+}
+
+func NewPinController(pinService *services.PinService) *PinController {
+	return &PinController{
+		pinService: pinService,
+	}
+}
+
+// GetByRoom godoc
+// @Summary Listar mensagens fixadas
+// @Description Retorna as mensagens fixadas da sala, da fixação mais recente para a mais antiga
+// @Tags rooms
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Success 200 {object} map[string]interface{} "Mensagens fixadas"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/pins [get]
+func (c *PinController) GetByRoom(ctx *fiber.Ctx) error {
+	pins, err := c.pinService.GetByRoom(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"pins":  pins,
+		"count": len(pins),
+		"limit": c.pinService.Limit(),
+	})
+}
+
+// Pin godoc
+// @Summary Fixar mensagem
+// @Description Fixa uma mensagem da sala; permitido ao moderador (criador) da sala e a administradores
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param pin body PinMessageRequest true "Mensagem a fixar"
+// @Success 201 {object} map[string]interface{} "Mensagem fixada"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 403 {object} map[string]interface{} "Usuário sem permissão"
+// @Failure 404 {object} map[string]interface{} "Sala, mensagem ou usuário não encontrado"
+// @Failure 409 {object} map[string]interface{} "Limite de fixações atingido ou sala arquivada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/pins [post]
+func (c *PinController) Pin(ctx *fiber.Ctx) error {
+	var req PinMessageRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	if req.MessageID == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "message_id é obrigatório",
+		})
+	}
+
+	userID, err := actingUserID(ctx, req.UserID)
+	if userID == "" {
+		return err
+	}
+
+	pin, err := c.pinService.Pin(ctx.Params("id"), req.MessageID, userID)
+	if err != nil {
+		return pinError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Mensagem fixada",
+		"pin":     pin,
+	})
+}
+
+// Unpin godoc
+// @Summary Desafixar mensagem
+// @Description Remove a fixação de uma mensagem; permitido ao moderador (criador) da sala e a administradores
+// @Tags rooms
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param message_id path string true "ID da mensagem"
+// @Param user_id query string false "ID do usuário que desafixa a mensagem (com token de API, o dono do token)"
+// @Success 200 {object} map[string]interface{} "Mensagem desafixada"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 403 {object} map[string]interface{} "Usuário sem permissão"
+// @Failure 404 {object} map[string]interface{} "Sala, usuário ou fixação não encontrada"
+// @Failure 409 {object} map[string]interface{} "Sala arquivada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/pins/{message_id} [delete]
+func (c *PinController) Unpin(ctx *fiber.Ctx) error {
+	userID, err := actingUserID(ctx, ctx.Query("user_id"))
+	if userID == "" {
+		return err
+	}
+
+	if err := c.pinService.Unpin(ctx.Params("id"), ctx.Params("message_id"), userID); err != nil {
+		return pinError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Mensagem desafixada",
+	})
+}
+
+// pinError traduz os erros do serviço de fixação em respostas HTTP
+func pinError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPinRoomNotFound),
+		errors.Is(err, services.ErrPinMessageNotFound),
+		errors.Is(err, services.ErrPinUserNotFound),
+		errors.Is(err, services.ErrPinNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrPinForbidden):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrPinLimitReached), errors.Is(err, services.ErrPinRoomArchived):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Erro ao atualizar mensagens fixadas",
+	})
+}
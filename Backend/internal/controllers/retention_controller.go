@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// UpdateRetentionRequest representa a retenção de uma sala
+// @Description Dias de retenção; null volta a usar a política global e 0 mantém para sempre
+type UpdateRetentionRequest struct {
+	// @Description Dias de retenção das mensagens da sala
+	// @Example 90
+	RetentionDays *int `json:"retention_days"`
+}
+
+type RetentionController struct {
+	retentionService *services.RetentionService
+	roomService      *services.RoomService
+}
+
+func NewRetentionController(retentionService *services.RetentionService, roomService *services.RoomService) *RetentionController {
+	return &RetentionController{
+		retentionService: retentionService,
+		roomService:      roomService,
+	}
+}
+
+// GetStats godoc
+// @Summary Estado da retenção de mensagens
+// @Description Retorna a política global, o total removido desde a inicialização e o relatório da última execução
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Estado da retenção"
+// @Router /admin/retention [get]
+func (c *RetentionController) GetStats(ctx *fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{
+		"retention": c.retentionService.Stats(),
+	})
+}
+
+// Run godoc
+// @Summary Executar retenção agora
+// @Description Dispara o expurgo de mensagens antigas sem esperar o próximo ciclo; o resultado aparece em GET /admin/retention
+// @Tags admin
+// @Produce json
+// @Success 202 {object} map[string]interface{} "Execução agendada"
+// @Failure 409 {object} map[string]interface{} "Já existe uma execução em andamento"
+// @Router /admin/retention/run [post]
+func (c *RetentionController) Run(ctx *fiber.Ctx) error {
+	if !c.retentionService.Trigger() {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Já existe uma execução em andamento",
+		})
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Execução da retenção agendada",
+	})
+}
+
+// UpdateRoomRetention godoc
+// @Summary Definir retenção da sala
+// @Description Define por quantos dias as mensagens da sala são mantidas (null usa a política global, 0 mantém para sempre)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param retention body UpdateRetentionRequest true "Retenção da sala"
+// @Success 200 {object} map[string]interface{} "Retenção atualizada com sucesso"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Sala não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/rooms/{id}/retention [put]
+func (c *RetentionController) UpdateRoomRetention(ctx *fiber.Ctx) error {
+	roomID := ctx.Params("id")
+
+	var req UpdateRetentionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	if req.RetentionDays != nil && *req.RetentionDays < 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "retention_days não pode ser negativo",
+		})
+	}
+
+	room, err := c.roomService.GetByID(roomID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if room == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Sala não encontrada",
+		})
+	}
+
+	if err := c.retentionService.SetRoomRetention(roomID, req.RetentionDays); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao atualizar retenção",
+		})
+	}
+
+	room.RetentionDays = req.RetentionDays
+
+	return ctx.JSON(fiber.Map{
+		"message":                  "Retenção atualizada com sucesso",
+		"room":                     room,
+		"effective_retention_days": c.retentionService.EffectiveDays(room),
+	})
+}
@@ -1,13 +1,18 @@
 package controllers
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
 	"github.com/rafael-bit/whatz/internal/services"
 )
 
@@ -65,17 +70,30 @@ type CreateRoomWithAccessRequest struct {
 	CreatedBy string `json:"created_by" validate:"required"`
 }
 
+// InviteToRoomRequest representa a requisição para convidar um usuário para a sala
+// @Description Usuário convidado e quem convida
+type InviteToRoomRequest struct {
+	// @Description ID do usuário convidado
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	UserID string `json:"user_id" validate:"required"`
+	// @Description ID de quem convida
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	InvitedBy string `json:"invited_by" validate:"required"`
+}
+
 type RoomController struct {
-	roomService    *services.RoomService
-	userService    *services.UserService
-	messageService *services.MessageService
+	roomService         *services.RoomService
+	userService         *services.UserService
+	messageService      *services.MessageService
+	notificationService *services.NotificationService
 }
 
-func NewRoomController(roomService *services.RoomService, userService *services.UserService, messageService *services.MessageService) *RoomController {
+func NewRoomController(roomService *services.RoomService, userService *services.UserService, messageService *services.MessageService, notificationService *services.NotificationService) *RoomController {
 	return &RoomController{
-		roomService:    roomService,
-		userService:    userService,
-		messageService: messageService,
+		roomService:         roomService,
+		userService:         userService,
+		messageService:      messageService,
+		notificationService: notificationService,
 	}
 }
 
@@ -150,12 +168,14 @@ func (c *RoomController) GetByID(ctx *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param user_id query string false "ID do usuário para filtrar salas acessíveis"
+// @Param include_archived query bool false "Incluir salas arquivadas (padrão: false)"
 // @Success 200 {object} map[string]interface{} "Lista de salas"
 // @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
 // @Router /rooms [get]
 func (c *RoomController) GetAll(ctx *fiber.Ctx) error {
 	userID := ctx.Query("user_id")
+	includeArchived := ctx.QueryBool("include_archived")
 	if userID == "" {
 		rooms, err := c.roomService.GetPublicRooms()
 		if err != nil {
@@ -164,10 +184,8 @@ func (c *RoomController) GetAll(ctx *fiber.Ctx) error {
 			})
 		}
 
-		// Ensure rooms is never null
-		if rooms == nil {
-			rooms = []*models.Room{}
-		}
+		// Salas arquivadas ficam fora da listagem padrão (o filtro nunca devolve null)
+		rooms = services.VisibleRooms(rooms, includeArchived)
 
 		return ctx.JSON(fiber.Map{
 			"rooms": rooms,
@@ -185,10 +203,8 @@ func (c *RoomController) GetAll(ctx *fiber.Ctx) error {
 			})
 		}
 
-		// Ensure rooms is never null
-		if rooms == nil {
-			rooms = []*models.Room{}
-		}
+		// Salas arquivadas ficam fora da listagem padrão (o filtro nunca devolve null)
+		rooms = services.VisibleRooms(rooms, includeArchived)
 
 		return ctx.JSON(fiber.Map{
 			"rooms": rooms,
@@ -223,10 +239,8 @@ func (c *RoomController) GetAll(ctx *fiber.Ctx) error {
 			})
 		}
 
-		// Ensure rooms is never null
-		if rooms == nil {
-			rooms = []*models.Room{}
-		}
+		// Salas arquivadas ficam fora da listagem padrão (o filtro nunca devolve null)
+		rooms = services.VisibleRooms(rooms, includeArchived)
 
 		return ctx.JSON(fiber.Map{
 			"rooms": rooms,
@@ -242,10 +256,8 @@ func (c *RoomController) GetAll(ctx *fiber.Ctx) error {
 		})
 	}
 
-	// Ensure rooms is never null
-	if rooms == nil {
-		rooms = []*models.Room{}
-	}
+	// Salas arquivadas ficam fora da listagem padrão (o filtro nunca devolve null)
+	rooms = services.VisibleRooms(rooms, includeArchived)
 
 	return ctx.JSON(fiber.Map{
 		"rooms":     rooms,
@@ -261,10 +273,12 @@ func (c *RoomController) GetAll(ctx *fiber.Ctx) error {
 // @Tags rooms
 // @Accept json
 // @Produce json
+// @Param include_archived query bool false "Incluir salas arquivadas (padrão: false)"
 // @Success 200 {object} map[string]interface{} "Lista de salas públicas"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
 // @Router /rooms/public [get]
 func (c *RoomController) GetPublicRooms(ctx *fiber.Ctx) error {
+	includeArchived := ctx.QueryBool("include_archived")
 	rooms, err := c.roomService.GetPublicRooms()
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -272,10 +286,8 @@ func (c *RoomController) GetPublicRooms(ctx *fiber.Ctx) error {
 		})
 	}
 
-	// Ensure rooms is never null
-	if rooms == nil {
-		rooms = []*models.Room{}
-	}
+	// Salas arquivadas ficam fora da listagem padrão (o filtro nunca devolve null)
+	rooms = services.VisibleRooms(rooms, includeArchived)
 
 	return ctx.JSON(fiber.Map{
 		"rooms": rooms,
@@ -349,6 +361,96 @@ func (c *RoomController) GetMessages(ctx *fiber.Ctx) error {
 	})
 }
 
+// Export godoc
+// @Summary Exportar transcrição da sala
+// @Description Gera a transcrição da sala em JSON, CSV, Markdown ou HTML. As mensagens são lidas e enviadas aos poucos, sem carregar a sala inteira em memória.
+// @Tags rooms
+// @Produce json
+// @Produce text/csv
+// @Produce text/markdown
+// @Produce text/html
+// @Param id path string true "ID da sala"
+// @Param format query string false "Formato: json, csv, md ou html (padrão: json)"
+// @Param from query string false "Início do período (RFC3339 ou AAAA-MM-DD)"
+// @Param to query string false "Fim do período, inclusivo (RFC3339 ou AAAA-MM-DD)"
+// @Success 200 {file} file "Transcrição da sala"
+// @Failure 400 {object} map[string]interface{} "Parâmetros inválidos"
+// @Failure 404 {object} map[string]interface{} "Sala não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/export [get]
+func (c *RoomController) Export(ctx *fiber.Ctx) error {
+	roomID := ctx.Params("id")
+
+	format := ctx.Query("format", "json")
+	transcriptFormat, ok := services.TranscriptFormats[format]
+	if !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Formato inválido (use json, csv, md ou html)",
+		})
+	}
+
+	var period services.TranscriptRange
+	var err error
+	if period.From, err = parseTranscriptDate(ctx.Query("from"), false); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Parâmetro from inválido",
+		})
+	}
+	if period.To, err = parseTranscriptDate(ctx.Query("to"), true); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Parâmetro to inválido",
+		})
+	}
+
+	room, err := c.roomService.GetByID(roomID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if room == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Sala não encontrada",
+		})
+	}
+
+	// Attachment define o Content-Type pela extensão; o charset é aplicado em seguida
+	ctx.Attachment(fmt.Sprintf("sala-%s.%s", room.ID, transcriptFormat.Extension))
+	ctx.Set(fiber.HeaderContentType, transcriptFormat.ContentType)
+
+	// O corpo é gerado depois que o handler retorna, uma página de mensagens por vez
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := c.messageService.WriteTranscript(w, room, format, period); err != nil {
+			log.Printf("❌ Erro ao exportar transcrição da sala %s: %v", room.ID, err)
+		}
+		w.Flush()
+	})
+
+	return nil
+}
+
+// parseTranscriptDate aceita RFC3339 ou uma data simples; como fim de período, a data
+// simples cobre o dia inteiro
+func parseTranscriptDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
 // Update godoc
 // @Summary Atualizar sala
 // @Description Atualiza os dados de uma sala existente
@@ -428,12 +530,18 @@ func (c *RoomController) Update(ctx *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "ID da sala"
 // @Success 200 {object} map[string]interface{} "Sala deletada com sucesso"
+// @Failure 423 {object} map[string]interface{} "Sala sob retenção legal"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
 // @Router /rooms/{id} [delete]
 func (c *RoomController) Delete(ctx *fiber.Ctx) error {
 	roomID := ctx.Params("id")
 
 	if err := c.roomService.Delete(roomID); err != nil {
+		if errors.Is(err, repository.ErrLegalHold) {
+			return ctx.Status(fiber.StatusLocked).JSON(fiber.Map{
+				"error": "Sala sob retenção legal não pode ser deletada",
+			})
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Erro ao deletar sala",
 		})
@@ -444,6 +552,144 @@ func (c *RoomController) Delete(ctx *fiber.Ctx) error {
 	})
 }
 
+// Invite godoc
+// @Summary Convidar usuário para a sala
+// @Description Envia ao usuário uma notificação de convite para a sala (o acesso a salas privadas continua definido pelas tags)
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Param invite body InviteToRoomRequest true "Convite"
+// @Success 201 {object} map[string]interface{} "Convite enviado"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Sala ou usuário não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/invites [post]
+func (c *RoomController) Invite(ctx *fiber.Ctx) error {
+	var req InviteToRoomRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	if req.UserID == "" || req.InvitedBy == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user_id e invited_by são obrigatórios",
+		})
+	}
+
+	room, err := c.roomService.GetByID(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if room == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Sala não encontrada",
+		})
+	}
+
+	invitee, err := c.userService.GetByID(req.UserID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	inviter, err := c.userService.GetByID(req.InvitedBy)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if invitee == nil || inviter == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Usuário não encontrado",
+		})
+	}
+
+	notification, err := c.notificationService.NotifyInvite(room, invitee.ID, inviter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao enviar convite",
+		})
+	}
+
+	// notification é nil quando o convidado desabilitou convites nas preferências
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Convite enviado",
+		"notification": notification,
+		"delivered":    notification != nil,
+	})
+}
+
+// Archive godoc
+// @Summary Arquivar sala
+// @Description Torna a sala somente leitura: o histórico continua disponível, mas novas mensagens são recusadas
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Success 200 {object} map[string]interface{} "Sala arquivada com sucesso"
+// @Failure 404 {object} map[string]interface{} "Sala não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/archive [post]
+func (c *RoomController) Archive(ctx *fiber.Ctx) error {
+	return c.setArchived(ctx, true)
+}
+
+// Unarchive godoc
+// @Summary Reativar sala
+// @Description Remove o arquivamento da sala, permitindo novas mensagens
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da sala"
+// @Success 200 {object} map[string]interface{} "Sala reativada com sucesso"
+// @Failure 404 {object} map[string]interface{} "Sala não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /rooms/{id}/unarchive [post]
+func (c *RoomController) Unarchive(ctx *fiber.Ctx) error {
+	return c.setArchived(ctx, false)
+}
+
+func (c *RoomController) setArchived(ctx *fiber.Ctx, archived bool) error {
+	room, err := c.roomService.GetByID(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if room == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Sala não encontrada",
+		})
+	}
+
+	message := "Sala arquivada com sucesso"
+	if archived {
+		err = c.roomService.Archive(room)
+	} else {
+		message = "Sala reativada com sucesso"
+		err = c.roomService.Unarchive(room)
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao arquivar sala",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": message,
+		"room":    room,
+	})
+}
+
 // CreateWithAccess godoc
 // @Summary Criar sala com controle de acesso
 // @Description Cria uma nova sala com controle de acesso por tags (rota administrativa)
@@ -2,10 +2,13 @@ package controllers
 
 import (
 	"encoding/json"
-	"time"
+	"errors"
+	"net/mail"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
 	"github.com/rafael-bit/whatz/internal/services"
 )
 
@@ -52,6 +55,29 @@ type UpdateUserRequest struct {
 	Tags []string `json:"tags"`
 }
 
+// PatchUserRequest representa uma atualização parcial do usuário
+// @Description Apenas os campos enviados são alterados
+type PatchUserRequest struct {
+	// @Description Nome de usuário (3-50 caracteres)
+	// @Example "joao123"
+	Username *string `json:"username" validate:"omitempty,min=3,max=50"`
+	// @Description Email do usuário
+	// @Example "joao@example.com"
+	Email *string `json:"email" validate:"omitempty,email"`
+	// @Description URL do avatar do usuário
+	// @Example "https://example.com/avatar.jpg"
+	Avatar *string `json:"avatar"`
+	// @Description Status do usuário (online, offline, away)
+	// @Example "away"
+	Status *string `json:"status" validate:"omitempty,oneof=online offline away"`
+	// @Description Função do usuário (user ou admin)
+	// @Example "user"
+	Role *string `json:"role" validate:"omitempty,oneof=user admin"`
+	// @Description Lista de tags do usuário
+	// @Example ["dev", "admin"]
+	Tags *[]string `json:"tags"`
+}
+
 type UserController struct {
 	userService *services.UserService
 }
@@ -71,6 +97,7 @@ func NewUserController(userService *services.UserService) *UserController {
 // @Param user body CreateUserRequest true "Dados do usuário"
 // @Success 201 {object} map[string]interface{} "Usuário criado com sucesso"
 // @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 409 {object} map[string]interface{} "Nome de usuário ou email já em uso"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
 // @Router /users [post]
 func (c *UserController) Create(ctx *fiber.Ctx) error {
@@ -101,6 +128,11 @@ func (c *UserController) Create(ctx *fiber.Ctx) error {
 	}
 
 	if err := c.userService.Create(user); err != nil {
+		if status, message, ok := profileConflict(err); ok {
+			return ctx.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Erro ao criar usuário",
 		})
@@ -174,7 +206,7 @@ func (c *UserController) GetAll(ctx *fiber.Ctx) error {
 
 // Update godoc
 // @Summary Atualizar usuário
-// @Description Atualiza os dados de um usuário existente
+// @Description Substitui os dados de perfil de um usuário existente; status, role e tags omitidos mantêm os valores atuais
 // @Tags users
 // @Accept json
 // @Produce json
@@ -183,6 +215,7 @@ func (c *UserController) GetAll(ctx *fiber.Ctx) error {
 // @Success 200 {object} map[string]interface{} "Usuário atualizado com sucesso"
 // @Failure 400 {object} map[string]interface{} "Dados inválidos"
 // @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 409 {object} map[string]interface{} "Nome de usuário ou email já em uso"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
 // @Router /users/{id} [put]
 func (c *UserController) Update(ctx *fiber.Ctx) error {
@@ -208,50 +241,225 @@ func (c *UserController) Update(ctx *fiber.Ctx) error {
 		})
 	}
 
+	// Status, role e tags omitidos mantêm os valores atuais
+	if req.Status == "" {
+		req.Status = user.Status
+	}
+	role := user.Role
+	if req.Role != "" {
+		role = req.Role
+	}
+
 	user.Username = req.Username
 	user.Email = req.Email
 	user.Avatar = req.Avatar
 	user.Status = req.Status
-	user.Role = req.Role
-	user.UpdatedAt = time.Now()
 
-	// Convert tags to JSON string
-	if len(req.Tags) > 0 {
-		tagsJSON, err := json.Marshal(req.Tags)
-		if err != nil {
+	if req.Tags != nil {
+		if err := setUserTags(user, req.Tags); err != nil {
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Erro interno do servidor",
 			})
 		}
-		user.Tags = string(tagsJSON)
+	}
+
+	return c.saveProfile(ctx, user, role)
+}
+
+// Patch godoc
+// @Summary Atualizar parcialmente usuário
+// @Description Altera apenas os campos enviados; nome e avatar novos são propagados aos clientes conectados
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do usuário"
+// @Param user body PatchUserRequest true "Campos a alterar"
+// @Success 200 {object} map[string]interface{} "Usuário atualizado com sucesso"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 409 {object} map[string]interface{} "Nome de usuário ou email já em uso"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /users/{id} [patch]
+func (c *UserController) Patch(ctx *fiber.Ctx) error {
+	userID := ctx.Params("id")
+
+	var req PatchUserRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	user, err := c.userService.GetByID(userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if user == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Usuário não encontrado",
+		})
+	}
+
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
+	if req.Email != nil {
+		user.Email = *req.Email
+	}
+	if req.Avatar != nil {
+		user.Avatar = *req.Avatar
+	}
+	if req.Status != nil {
+		user.Status = *req.Status
+	}
+	role := user.Role
+	if req.Role != nil {
+		role = *req.Role
+	}
+	if req.Tags != nil {
+		if err := setUserTags(user, *req.Tags); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Erro interno do servidor",
+			})
+		}
+	}
+
+	return c.saveProfile(ctx, user, role)
+}
+
+// saveProfile valida e persiste o perfil, respondendo 409 em conflitos de username/email.
+// Uma role diferente da atual é aplicada por UserService.UpdateRole, que notifica o usuário.
+func (c *UserController) saveProfile(ctx *fiber.Ctx, user *models.User, role string) error {
+	message := validateProfile(user)
+	if message == "" && role != "user" && role != "admin" {
+		message = "Role inválida"
+	}
+	if message != "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": message,
+		})
 	}
 
 	if err := c.userService.Update(user); err != nil {
+		if status, message, ok := profileConflict(err); ok {
+			return ctx.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Erro ao atualizar usuário",
 		})
 	}
 
+	if role != user.Role {
+		if err := c.userService.UpdateRole(user.ID, role); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Erro ao atualizar role",
+			})
+		}
+		user.Role = role
+	}
+
 	return ctx.JSON(fiber.Map{
 		"message": "Usuário atualizado com sucesso",
 		"user":    user,
 	})
 }
 
+func validateProfile(user *models.User) string {
+	username := strings.TrimSpace(user.Username)
+	if len(username) < 3 || len(username) > 50 {
+		return "Nome de usuário deve ter entre 3 e 50 caracteres"
+	}
+	if _, err := mail.ParseAddress(user.Email); err != nil {
+		return "Email inválido"
+	}
+	switch user.Status {
+	case "online", "offline", "away":
+	default:
+		return "Status inválido"
+	}
+	return ""
+}
+
+func setUserTags(user *models.User, tags []string) error {
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	user.Tags = string(tagsJSON)
+	return nil
+}
+
+// profileConflict traduz conflitos de unicidade para 409
+func profileConflict(err error) (int, string, bool) {
+	switch {
+	case errors.Is(err, repository.ErrUsernameTaken):
+		return fiber.StatusConflict, "Nome de usuário já está em uso", true
+	case errors.Is(err, repository.ErrEmailTaken):
+		return fiber.StatusConflict, "Email já está em uso", true
+	}
+	return 0, "", false
+}
+
 // Delete godoc
 // @Summary Deletar usuário
-// @Description Remove um usuário do sistema
+// @Description Remove a conta em uma única transação: encerra as sessões WebSocket, anonimiza ou remove as mensagens do usuário e transfere as salas criadas por ele
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path string true "ID do usuário"
+// @Param messages query string false "Política para as mensagens (anonymize ou delete); padrão definido por USER_DELETION_MESSAGE_POLICY"
+// @Param reassign_to query string false "ID do usuário que recebe as salas; padrão é o administrador mais antigo"
 // @Success 200 {object} map[string]interface{} "Usuário deletado com sucesso"
+// @Failure 400 {object} map[string]interface{} "Parâmetros inválidos"
+// @Failure 404 {object} map[string]interface{} "Usuário não encontrado"
+// @Failure 409 {object} map[string]interface{} "Nenhum usuário disponível para receber as salas"
+// @Failure 423 {object} map[string]interface{} "Usuário ou salas dele sob retenção legal"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
 // @Router /users/{id} [delete]
 func (c *UserController) Delete(ctx *fiber.Ctx) error {
 	userID := ctx.Params("id")
 
-	if err := c.userService.Delete(userID); err != nil {
+	user, err := c.userService.GetByID(userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if user == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Usuário não encontrado",
+		})
+	}
+
+	result, err := c.userService.Delete(userID, repository.AccountDeletion{
+		MessagePolicy: ctx.Query("messages"),
+		ReassignTo:    ctx.Query("reassign_to"),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMessagePolicy):
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Política de mensagens inválida (use anonymize ou delete)",
+			})
+		case errors.Is(err, services.ErrInvalidRoomHeir):
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Usuário para receber as salas não encontrado",
+			})
+		case errors.Is(err, repository.ErrLegalHold):
+			return ctx.Status(fiber.StatusLocked).JSON(fiber.Map{
+				"error": "Usuário ou salas dele estão sob retenção legal",
+			})
+		case errors.Is(err, repository.ErrNoRoomHeir):
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "O usuário possui salas e não há administrador para recebê-las; informe reassign_to",
+			})
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Erro ao deletar usuário",
 		})
@@ -259,6 +467,7 @@ func (c *UserController) Delete(ctx *fiber.Ctx) error {
 
 	return ctx.JSON(fiber.Map{
 		"message": "Usuário deletado com sucesso",
+		"result":  result,
 	})
 }
 
@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// CreateWebhookRequest representa a requisição para cadastrar um webhook
+// @Description Endpoint que receberá os eventos das salas
+type CreateWebhookRequest struct {
+	// @Description URL que recebe os eventos (http ou https)
+	// @Example "https://ci.example.com/hooks/whatz"
+	URL string `json:"url" validate:"required"`
+	// @Description Segredo do HMAC-SHA256; gerado quando omitido
+	// @Example "s3cr3t"
+	Secret string `json:"secret"`
+	// @Description Tipos de evento entregues; vazio entrega todos
+	// @Example ["new_message", "message_deleted"]
+	EventTypes []string `json:"event_types"`
+	// @Description Sala filtrada; vazio entrega eventos de todas as salas
+	// @Example "123e4567-e89b-12d3-a456-426614174000"
+	RoomID string `json:"room_id"`
+	// @Description Responsável pelo cadastro
+	// @Example "admin"
+	CreatedBy string `json:"created_by"`
+}
+
+// UpdateWebhookRequest representa a atualização parcial de um webhook
+// @Description Campos omitidos não mudam
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url"`
+	EventTypes []string `json:"event_types"`
+	RoomID     *string  `json:"room_id"`
+	Active     *bool    `json:"active"`
+}
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+// Create godoc
+// @Summary Cadastrar webhook
+// @Description Cadastra um endpoint que recebe os eventos das salas em JSON, assinados com HMAC-SHA256 no cabeçalho X-Whatz-Signature. O segredo só é exibido nesta resposta.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param webhook body CreateWebhookRequest true "Dados do webhook"
+// @Success 201 {object} map[string]interface{} "Webhook cadastrado"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Sala não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks [post]
+func (c *WebhookController) Create(ctx *fiber.Ctx) error {
+	var req CreateWebhookRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	webhook, err := c.webhookService.Register(req.URL, req.Secret, req.EventTypes, req.RoomID, req.CreatedBy)
+	if err != nil {
+		return webhookError(ctx, err, "Erro ao cadastrar webhook")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Webhook cadastrado",
+		"webhook": webhook,
+		"secret":  webhook.Secret,
+	})
+}
+
+// GetAll godoc
+// @Summary Listar webhooks
+// @Description Lista os webhooks cadastrados (sem os segredos)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Lista de webhooks"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks [get]
+func (c *WebhookController) GetAll(ctx *fiber.Ctx) error {
+	webhooks, err := c.webhookService.GetAll()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"webhooks": webhooks,
+		"count":    len(webhooks),
+	})
+}
+
+// GetByID godoc
+// @Summary Buscar webhook
+// @Description Retorna um webhook pelo ID (sem o segredo)
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID do webhook"
+// @Success 200 {object} map[string]interface{} "Webhook encontrado"
+// @Failure 404 {object} map[string]interface{} "Webhook não encontrado"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks/{id} [get]
+func (c *WebhookController) GetByID(ctx *fiber.Ctx) error {
+	webhook, err := c.webhookService.GetByID(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if webhook == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Webhook não encontrado",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"webhook": webhook,
+	})
+}
+
+// Update godoc
+// @Summary Atualizar webhook
+// @Description Altera URL, filtros ou estado (active) do webhook; campos omitidos não mudam
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do webhook"
+// @Param webhook body UpdateWebhookRequest true "Campos a alterar"
+// @Success 200 {object} map[string]interface{} "Webhook atualizado"
+// @Failure 400 {object} map[string]interface{} "Dados inválidos"
+// @Failure 404 {object} map[string]interface{} "Webhook ou sala não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks/{id} [patch]
+func (c *WebhookController) Update(ctx *fiber.Ctx) error {
+	var req UpdateWebhookRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dados inválidos",
+		})
+	}
+
+	webhook, err := c.webhookService.Update(ctx.Params("id"), req.URL, req.EventTypes, req.RoomID, req.Active)
+	if err != nil {
+		return webhookError(ctx, err, "Erro ao atualizar webhook")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Webhook atualizado",
+		"webhook": webhook,
+	})
+}
+
+// Delete godoc
+// @Summary Remover webhook
+// @Description Remove o webhook com sua fila de entregas e histórico de tentativas
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID do webhook"
+// @Success 200 {object} map[string]interface{} "Webhook removido"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks/{id} [delete]
+func (c *WebhookController) Delete(ctx *fiber.Ctx) error {
+	if err := c.webhookService.Delete(ctx.Params("id")); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao remover webhook",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Webhook removido",
+	})
+}
+
+// GetDeliveries godoc
+// @Summary Listar entregas do webhook
+// @Description Lista as entregas do webhook, das mais recentes para as mais antigas
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID do webhook"
+// @Param limit query int false "Limite de entregas (padrão: 50)"
+// @Param offset query int false "Offset para paginação (padrão: 0)"
+// @Success 200 {object} map[string]interface{} "Entregas"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (c *WebhookController) GetDeliveries(ctx *fiber.Ctx) error {
+	limit, err := strconv.Atoi(ctx.Query("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(ctx.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	deliveries, err := c.webhookService.GetDeliveries(ctx.Params("id"), limit, offset)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+		"pagination": fiber.Map{
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// GetDelivery godoc
+// @Summary Buscar entrega
+// @Description Retorna a entrega com o histórico de tentativas (status HTTP, erro e duração)
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID do webhook"
+// @Param delivery_id path string true "ID da entrega"
+// @Success 200 {object} map[string]interface{} "Entrega encontrada"
+// @Failure 404 {object} map[string]interface{} "Entrega não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id} [get]
+func (c *WebhookController) GetDelivery(ctx *fiber.Ctx) error {
+	delivery, err := c.webhookService.GetDelivery(ctx.Params("id"), ctx.Params("delivery_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro interno do servidor",
+		})
+	}
+
+	if delivery == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Entrega não encontrada",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"delivery": delivery,
+	})
+}
+
+// Redeliver godoc
+// @Summary Reenviar entrega
+// @Description Recoloca a entrega na fila para envio imediato, com novo ciclo de tentativas; o corpo enviado é o original
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID do webhook"
+// @Param delivery_id path string true "ID da entrega"
+// @Success 202 {object} map[string]interface{} "Entrega reenfileirada"
+// @Failure 404 {object} map[string]interface{} "Entrega não encontrada"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (c *WebhookController) Redeliver(ctx *fiber.Ctx) error {
+	delivery, err := c.webhookService.Redeliver(ctx.Params("id"), ctx.Params("delivery_id"))
+	if err != nil {
+		return webhookError(ctx, err, "Erro ao reenviar entrega")
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":  "Entrega reenfileirada",
+		"delivery": delivery,
+	})
+}
+
+// webhookError traduz os erros do serviço de webhooks em respostas HTTP
+func webhookError(ctx *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhookURL):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "URL inválida (use http ou https)",
+		})
+	case errors.Is(err, services.ErrWebhookNotFound),
+		errors.Is(err, services.ErrWebhookRoomNotFound),
+		errors.Is(err, services.ErrWebhookDeliveryMissing):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
@@ -57,6 +57,8 @@ func (d *Database) migrate() error {
 		status TEXT DEFAULT 'online',
 		role TEXT DEFAULT 'user',
 		tags TEXT DEFAULT '[]',
+		kind TEXT NOT NULL DEFAULT 'human',
+		last_seen_at DATETIME,
 		created_at DATETIME NOT NULL,
 		updated_at DATETIME NOT NULL
 	);`
@@ -70,6 +72,8 @@ func (d *Database) migrate() error {
 		type TEXT DEFAULT 'public',
 		access_tags TEXT DEFAULT '[]',
 		created_by TEXT NOT NULL,
+		retention_days INTEGER,
+		archived_at DATETIME,
 		created_at DATETIME NOT NULL,
 		updated_at DATETIME NOT NULL,
 		FOREIGN KEY (created_by) REFERENCES users (id)
@@ -85,6 +89,10 @@ func (d *Database) migrate() error {
 		avatar TEXT,
 		type TEXT DEFAULT 'text',
 		room_id TEXT NOT NULL,
+		thread_id TEXT NOT NULL DEFAULT '',
+		entities TEXT NOT NULL DEFAULT '[]',
+		html TEXT NOT NULL DEFAULT '',
+		previews TEXT NOT NULL DEFAULT '[]',
 		created_at DATETIME NOT NULL,
 		updated_at DATETIME NOT NULL,
 		FOREIGN KEY (user_id) REFERENCES users (id),
@@ -100,12 +108,251 @@ func (d *Database) migrate() error {
 		updated_at DATETIME NOT NULL
 	);`
 
+	// Criar log de eventos por sala (sequência usada para retomar sessões)
+	createRoomEventsTable := `
+	CREATE TABLE IF NOT EXISTS room_events (
+		room_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (room_id, seq),
+		FOREIGN KEY (room_id) REFERENCES rooms (id)
+	);`
+
+	// Eventos gravados aguardando a distribuição aos webhooks; gravados junto com o evento
+	// e removidos quando as entregas são enfileiradas
+	createRoomEventOutboxTable := `
+	CREATE TABLE IF NOT EXISTS room_event_outbox (
+		id INTEGER PRIMARY KEY,
+		room_id TEXT NOT NULL,
+		seq INTEGER NOT NULL
+	);`
+
+	// Criar tabela de exportações de dados pessoais
+	createDataExportsTable := `
+	CREATE TABLE IF NOT EXISTS data_exports (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		file_path TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		completed_at DATETIME
+	);`
+
+	// Criar arquivo de mensagens removidas pela política de retenção
+	createMessagesArchiveTable := `
+	CREATE TABLE IF NOT EXISTS messages_archive (
+		id TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		username TEXT NOT NULL,
+		avatar TEXT,
+		type TEXT DEFAULT 'text',
+		room_id TEXT NOT NULL,
+		thread_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		archived_at DATETIME NOT NULL
+	);`
+
+	// Criar tabela de retenções legais (registros mantidos após a liberação para auditoria)
+	createLegalHoldsTable := `
+	CREATE TABLE IF NOT EXISTS legal_holds (
+		id TEXT PRIMARY KEY,
+		scope TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		placed_by TEXT NOT NULL DEFAULT '',
+		placed_at DATETIME NOT NULL,
+		released_by TEXT NOT NULL DEFAULT '',
+		released_at DATETIME
+	);`
+
+	// Criar tabela de mensagens fixadas por sala
+	createPinnedMessagesTable := `
+	CREATE TABLE IF NOT EXISTS pinned_messages (
+		room_id TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		pinned_by TEXT NOT NULL,
+		pinned_at DATETIME NOT NULL,
+		PRIMARY KEY (room_id, message_id)
+	);`
+
+	// Criar tabela de menções (@usuário, @room, @here)
+	createMentionsTable := `
+	CREATE TABLE IF NOT EXISTS mentions (
+		id TEXT PRIMARY KEY,
+		message_id TEXT NOT NULL,
+		room_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		author_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		read_at DATETIME,
+		UNIQUE (message_id, user_id)
+	);`
+
+	// Criar tabelas da central de notificações
+	createNotificationsTable := `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL DEFAULT '',
+		room_id TEXT NOT NULL DEFAULT '',
+		message_id TEXT NOT NULL DEFAULT '',
+		actor_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		read_at DATETIME
+	);`
+
+	createNotificationPreferencesTable := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, type)
+	);`
+
+	// Criar tabelas de webhooks de saída (endpoints, fila de entrega e tentativas)
+	createWebhooksTable := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id TEXT PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		event_types TEXT NOT NULL DEFAULT '[]',
+		room_id TEXT NOT NULL DEFAULT '',
+		active BOOLEAN NOT NULL DEFAULT 1,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);`
+
+	createWebhookDeliveriesTable := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		webhook_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		room_id TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at INTEGER,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		delivered_at DATETIME
+	);`
+
+	createWebhookAttemptsTable := `
+	CREATE TABLE IF NOT EXISTS webhook_attempts (
+		id TEXT PRIMARY KEY,
+		delivery_id TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);`
+
+	// Criar tabela de webhooks de entrada (tokens que publicam mensagens de bots nas salas)
+	createIncomingWebhooksTable := `
+	CREATE TABLE IF NOT EXISTS incoming_webhooks (
+		id TEXT PRIMARY KEY,
+		room_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		avatar TEXT NOT NULL DEFAULT '',
+		token_hash TEXT NOT NULL UNIQUE,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		last_used_at DATETIME
+	);`
+
+	// Criar tabela de tokens de API (apenas o hash do token é gravado)
+	createAPITokensTable := `
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT NOT NULL DEFAULT '[]',
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		last_used_at DATETIME,
+		revoked_at DATETIME
+	);`
+
+	// Criar tabela de comandos de barra registrados por integrações
+	createSlashCommandsTable := `
+	CREATE TABLE IF NOT EXISTS slash_commands (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		usage TEXT NOT NULL DEFAULT '',
+		bot_id TEXT NOT NULL,
+		callback_url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);`
+
+	// Criar tabela de cache das prévias de links (inclui buscas que falharam)
+	createLinkPreviewsTable := `
+	CREATE TABLE IF NOT EXISTS link_previews (
+		url TEXT PRIMARY KEY,
+		title TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		image TEXT NOT NULL DEFAULT '',
+		site_name TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		fetched_at DATETIME NOT NULL
+	);`
+
+	// Criar tabelas de moderação: regras por sala e fila de mensagens sinalizadas
+	createModerationRulesTable := `
+	CREATE TABLE IF NOT EXISTS moderation_rules (
+		id TEXT PRIMARY KEY,
+		room_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		pattern TEXT NOT NULL,
+		action TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);`
+
+	createModerationFlagsTable := `
+	CREATE TABLE IF NOT EXISTS moderation_flags (
+		id TEXT PRIMARY KEY,
+		message_id TEXT NOT NULL,
+		room_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		rule_id TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		created_at DATETIME NOT NULL,
+		reviewed_by TEXT NOT NULL DEFAULT '',
+		reviewed_at DATETIME
+	);`
+
 	// Criar índices para performance
 	createIndexes := `
 	CREATE INDEX IF NOT EXISTS idx_messages_room_id ON messages (room_id);
 	CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at);
 	CREATE INDEX IF NOT EXISTS idx_users_username ON users (username);
 	CREATE INDEX IF NOT EXISTS idx_rooms_type ON rooms (type);
+	CREATE INDEX IF NOT EXISTS idx_messages_user_id ON messages (user_id);
+	CREATE INDEX IF NOT EXISTS idx_legal_holds_target ON legal_holds (scope, target_id);
+	CREATE INDEX IF NOT EXISTS idx_mentions_user_id ON mentions (user_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id);
+	CREATE INDEX IF NOT EXISTS idx_webhook_attempts_delivery ON webhook_attempts (delivery_id);
+	CREATE INDEX IF NOT EXISTS idx_incoming_webhooks_room_id ON incoming_webhooks (room_id);
+	CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens (user_id);
+	CREATE INDEX IF NOT EXISTS idx_moderation_rules_room_id ON moderation_rules (room_id);
+	CREATE INDEX IF NOT EXISTS idx_moderation_flags_status ON moderation_flags (status, created_at);
 	`
 
 	queries := []string{
@@ -113,6 +360,24 @@ func (d *Database) migrate() error {
 		createRoomsTable,
 		createMessagesTable,
 		createTagsTable,
+		createRoomEventsTable,
+		createRoomEventOutboxTable,
+		createDataExportsTable,
+		createMessagesArchiveTable,
+		createLegalHoldsTable,
+		createPinnedMessagesTable,
+		createMentionsTable,
+		createNotificationsTable,
+		createNotificationPreferencesTable,
+		createWebhooksTable,
+		createWebhookDeliveriesTable,
+		createWebhookAttemptsTable,
+		createIncomingWebhooksTable,
+		createAPITokensTable,
+		createSlashCommandsTable,
+		createLinkPreviewsTable,
+		createModerationRulesTable,
+		createModerationFlagsTable,
 		createIndexes,
 	}
 
@@ -122,10 +387,109 @@ func (d *Database) migrate() error {
 		}
 	}
 
+	// Colunas adicionadas depois da criação das tabelas (bancos existentes não as recebem via CREATE TABLE)
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"users", "last_seen_at", "DATETIME"},
+		{"messages", "thread_id", "TEXT NOT NULL DEFAULT ''"},
+		{"rooms", "retention_days", "INTEGER"},
+		{"rooms", "archived_at", "DATETIME"},
+		{"users", "kind", "TEXT NOT NULL DEFAULT 'human'"},
+		{"messages", "entities", "TEXT NOT NULL DEFAULT '[]'"},
+		{"messages", "html", "TEXT NOT NULL DEFAULT ''"},
+		{"messages", "previews", "TEXT NOT NULL DEFAULT '[]'"},
+	}
+
+	for _, c := range columns {
+		if err := d.addColumnIfMissing(c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("erro ao adicionar coluna %s.%s: %v", c.table, c.column, err)
+		}
+	}
+
+	// Índices sobre colunas adicionadas acima
+	if _, err := d.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_thread_id ON messages (thread_id)`); err != nil {
+		return fmt.Errorf("erro ao criar índices: %v", err)
+	}
+
+	if err := d.normalizeMessageTimestamps(); err != nil {
+		return fmt.Errorf("erro ao normalizar datas das mensagens: %v", err)
+	}
+	if _, err := d.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_room_created_at ON messages (room_id, created_at, id)`); err != nil {
+		return fmt.Errorf("erro ao criar índices: %v", err)
+	}
+
 	log.Printf("✅ Migrações executadas com sucesso em %v", time.Since(start))
 	return nil
 }
 
+// normalizeMessageTimestamps regrava em UTC o created_at das mensagens gravadas com o fuso
+// de origem. Com todas as datas no mesmo fuso, a ordem textual do SQLite é a cronológica e
+// os filtros por data podem ser feitos na consulta.
+func (d *Database) normalizeMessageTimestamps() error {
+	rows, err := d.DB.Query(`SELECT id, created_at FROM messages WHERE created_at NOT LIKE '% +0000 UTC'`)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		id        string
+		createdAt time.Time
+	}
+	var messages []pending
+	for rows.Next() {
+		var message pending
+		if err := rows.Scan(&message.id, &message.createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, message := range messages {
+		if _, err := tx.Exec(`UPDATE messages SET created_at = ? WHERE id = ?`, message.createdAt.UTC(), message.id); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("🕒 %d mensagens com created_at convertido para UTC", len(messages))
+	return nil
+}
+
+func (d *Database) addColumnIfMissing(table, column, definition string) error {
+	var count int
+	query := `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`
+	if err := d.DB.QueryRow(query, table, column).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	_, err := d.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
 func (d *Database) Close() error {
 	log.Printf("🔌 Fechando conexão com banco de dados...")
 	return d.DB.Close()
@@ -0,0 +1,457 @@
+// Package importer traz histórico de outras ferramentas de chat para o Whatz.
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// Namespace dos IDs gerados na importação. IDs derivados dos IDs do Slack fazem com que
+// uma nova execução encontre os registros já importados em vez de duplicá-los.
+var slackNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://slack.com/whatz-import"))
+
+func slackID(kind, id string) string {
+	return uuid.NewSHA1(slackNamespace, []byte(kind+":"+id)).String()
+}
+
+// Subtipos importados como mensagens de sistema; os demais subtipos desconhecidos são ignorados
+var slackSystemSubtypes = map[string]bool{
+	"channel_join":    true,
+	"channel_leave":   true,
+	"channel_topic":   true,
+	"channel_purpose": true,
+	"channel_name":    true,
+	"group_join":      true,
+	"group_leave":     true,
+}
+
+var slackTextSubtypes = map[string]bool{
+	"":                 true,
+	"thread_broadcast": true,
+	"bot_message":      true,
+	"me_message":       true,
+	"file_share":       true,
+}
+
+type slackUser struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Deleted bool   `json:"deleted"`
+	IsBot   bool   `json:"is_bot"`
+	Profile struct {
+		Email       string `json:"email"`
+		DisplayName string `json:"display_name"`
+		RealName    string `json:"real_name"`
+		Image192    string `json:"image_192"`
+		Image72     string `json:"image_72"`
+	} `json:"profile"`
+}
+
+type slackChannel struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Created int64  `json:"created"`
+	Creator string `json:"creator"`
+	Purpose struct {
+		Value string `json:"value"`
+	} `json:"purpose"`
+	Topic struct {
+		Value string `json:"value"`
+	} `json:"topic"`
+}
+
+type slackMessage struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	User     string `json:"user"`
+	Username string `json:"username"`
+	BotID    string `json:"bot_id"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+	Files    []struct {
+		Name       string `json:"name"`
+		URLPrivate string `json:"url_private"`
+	} `json:"files"`
+}
+
+// SlackImportResult conta o que foi criado e o que já existia de execuções anteriores
+type SlackImportResult struct {
+	UsersCreated    int
+	UsersMatched    int
+	RoomsCreated    int
+	RoomsExisting   int
+	MessagesCreated int
+	MessagesSkipped int
+}
+
+// SlackImporter lê um export de workspace do Slack (ZIP com users.json, channels.json,
+// groups.json e uma pasta por canal com um JSON por dia) e grava usuários, salas e
+// mensagens pelos repositórios
+type SlackImporter struct {
+	userRepo    *repository.UserRepository
+	roomRepo    *repository.RoomRepository
+	messageRepo *repository.MessageRepository
+
+	// ID do Slack -> usuário do Whatz
+	users map[string]*models.User
+}
+
+func NewSlackImporter(userRepo *repository.UserRepository, roomRepo *repository.RoomRepository, messageRepo *repository.MessageRepository) *SlackImporter {
+	return &SlackImporter{
+		userRepo:    userRepo,
+		roomRepo:    roomRepo,
+		messageRepo: messageRepo,
+		users:       make(map[string]*models.User),
+	}
+}
+
+// Import processa o arquivo de export. Pode ser executado novamente sobre o mesmo arquivo
+// (ou um export mais recente): registros já importados são reconhecidos e ignorados.
+func (i *SlackImporter) Import(archivePath string) (*SlackImportResult, error) {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir export do Slack: %v", err)
+	}
+	defer archive.Close()
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[strings.TrimPrefix(file.Name, "./")] = file
+	}
+
+	result := &SlackImportResult{}
+
+	var users []slackUser
+	if err := readJSON(files, "users.json", &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if err := i.importUser(user, result); err != nil {
+			return nil, err
+		}
+	}
+
+	var channels []slackChannel
+	if err := readJSON(files, "channels.json", &channels); err != nil {
+		return nil, err
+	}
+	var groups []slackChannel
+	if _, ok := files["groups.json"]; ok {
+		if err := readJSON(files, "groups.json", &groups); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, channel := range channels {
+		if err := i.importChannel(files, channel, "public", result); err != nil {
+			return nil, err
+		}
+	}
+	for _, group := range groups {
+		if err := i.importChannel(files, group, "private", result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (i *SlackImporter) importUser(su slackUser, result *SlackImportResult) error {
+	id := slackID("user", su.ID)
+
+	existing, err := i.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	// Usuário que já tem conta no Whatz com o mesmo email fica como autor das mensagens dele
+	if existing == nil && su.Profile.Email != "" {
+		existing, err = i.userRepo.GetByEmail(su.Profile.Email)
+		if err != nil {
+			return err
+		}
+	}
+
+	if existing != nil {
+		i.users[su.ID] = existing
+		result.UsersMatched++
+		return nil
+	}
+
+	email := su.Profile.Email
+	if email == "" {
+		email = fmt.Sprintf("slack-%s@import.invalid", strings.ToLower(su.ID))
+	}
+
+	avatar := su.Profile.Image192
+	if avatar == "" {
+		avatar = su.Profile.Image72
+	}
+
+	username, err := i.availableUsername(slackUsername(su))
+	if err != nil {
+		return err
+	}
+
+	user := models.NewUser(username, email, avatar)
+	user.ID = id
+	user.Status = "offline"
+
+	if err := i.userRepo.Create(user); err != nil {
+		return fmt.Errorf("erro ao importar usuário %s: %v", su.ID, err)
+	}
+
+	i.users[su.ID] = user
+	result.UsersCreated++
+	return nil
+}
+
+func slackUsername(su slackUser) string {
+	for _, name := range []string{su.Profile.DisplayName, su.Name, su.Profile.RealName, su.ID} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return su.ID
+}
+
+// availableUsername acrescenta um sufixo quando o nome já pertence a outro usuário
+func (i *SlackImporter) availableUsername(base string) (string, error) {
+	if len(base) < 3 {
+		base = "slack-" + base
+	}
+
+	candidate := base
+	for n := 2; ; n++ {
+		existing, err := i.userRepo.GetByUsername(candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		candidate = base + strconv.Itoa(n)
+	}
+}
+
+func (i *SlackImporter) importChannel(files map[string]*zip.File, channel slackChannel, roomType string, result *SlackImportResult) error {
+	roomID := slackID("channel", channel.ID)
+
+	room, err := i.roomRepo.GetByID(roomID)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		description := channel.Purpose.Value
+		if description == "" {
+			description = channel.Topic.Value
+		}
+
+		createdBy := ""
+		if creator := i.users[channel.Creator]; creator != nil {
+			createdBy = creator.ID
+		}
+
+		room = models.NewRoom(channel.Name, description, roomType, createdBy)
+		room.ID = roomID
+		if channel.Created > 0 {
+			room.CreatedAt = time.Unix(channel.Created, 0).UTC()
+			room.UpdatedAt = room.CreatedAt
+		}
+
+		if err := i.roomRepo.Create(room); err != nil {
+			return fmt.Errorf("erro ao importar canal %s: %v", channel.Name, err)
+		}
+		result.RoomsCreated++
+	} else {
+		result.RoomsExisting++
+	}
+
+	// Um arquivo por dia: <canal>/AAAA-MM-DD.json, processados em ordem
+	var days []string
+	for name := range files {
+		if path.Dir(name) == channel.Name && path.Ext(name) == ".json" {
+			days = append(days, name)
+		}
+	}
+	sort.Strings(days)
+
+	created := 0
+	for _, day := range days {
+		var messages []slackMessage
+		if err := readJSON(files, day, &messages); err != nil {
+			return err
+		}
+
+		for _, sm := range messages {
+			ok, err := i.importMessage(room, channel, sm)
+			if err != nil {
+				return err
+			}
+			if ok {
+				created++
+				result.MessagesCreated++
+			} else {
+				result.MessagesSkipped++
+			}
+		}
+	}
+
+	log.Printf("📥 #%s: %d mensagens importadas", channel.Name, created)
+	return nil
+}
+
+// importMessage grava uma mensagem do canal; retorna false se ela já existia ou foi ignorada
+func (i *SlackImporter) importMessage(room *models.Room, channel slackChannel, sm slackMessage) (bool, error) {
+	if sm.Type != "message" || sm.TS == "" {
+		return false, nil
+	}
+
+	messageType := "text"
+	switch {
+	case slackSystemSubtypes[sm.Subtype]:
+		messageType = "system"
+	case !slackTextSubtypes[sm.Subtype]:
+		return false, nil
+	}
+
+	id := slackID("message", channel.ID+":"+sm.TS)
+
+	existing, err := i.messageRepo.GetByID(id)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	content := i.convertText(sm.Text)
+	for _, file := range sm.Files {
+		content = strings.TrimSpace(content + "\n📎 " + file.Name + " " + file.URLPrivate)
+	}
+	if strings.TrimSpace(content) == "" {
+		return false, nil
+	}
+
+	createdAt, err := parseSlackTS(sm.TS)
+	if err != nil {
+		return false, fmt.Errorf("timestamp inválido %q em #%s: %v", sm.TS, channel.Name, err)
+	}
+
+	// Autor: usuário mapeado; bots e integrações sem usuário ficam só com o nome gravado
+	userID, username, avatar := "", sm.Username, ""
+	if user := i.users[sm.User]; user != nil {
+		userID, username, avatar = user.ID, user.Username, user.Avatar
+	}
+	if username == "" {
+		username = "Slack"
+	}
+
+	message := models.NewMessage(content, userID, username, avatar, messageType, room.ID)
+	message.ID = id
+	message.CreatedAt = createdAt
+	message.UpdatedAt = createdAt
+
+	// Respostas apontam para a mensagem que abriu a thread
+	if sm.ThreadTS != "" && sm.ThreadTS != sm.TS {
+		message.ThreadID = slackID("message", channel.ID+":"+sm.ThreadTS)
+	}
+
+	if err := i.messageRepo.Create(message); err != nil {
+		return false, fmt.Errorf("erro ao importar mensagem %s de #%s: %v", sm.TS, channel.Name, err)
+	}
+
+	return true, nil
+}
+
+var (
+	slackUserMention    = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|([^>]+))?>`)
+	slackChannelMention = regexp.MustCompile(`<#[A-Z0-9]+\|([^>]+)>`)
+	slackSpecialMention = regexp.MustCompile(`<!(here|channel|everyone)(?:\|[^>]*)?>`)
+	slackLink           = regexp.MustCompile(`<((?:https?|mailto):[^|>]+)(?:\|([^>]+))?>`)
+)
+
+// convertText troca a marcação do Slack (<@U123>, <#C123|geral>, <url|texto>) por texto simples
+func (i *SlackImporter) convertText(text string) string {
+	text = slackUserMention.ReplaceAllStringFunc(text, func(match string) string {
+		parts := slackUserMention.FindStringSubmatch(match)
+		if user := i.users[parts[1]]; user != nil {
+			return "@" + user.Username
+		}
+		if parts[2] != "" {
+			return "@" + parts[2]
+		}
+		return "@" + parts[1]
+	})
+	text = slackChannelMention.ReplaceAllString(text, "#$1")
+	text = slackSpecialMention.ReplaceAllString(text, "@$1")
+	text = slackLink.ReplaceAllStringFunc(text, func(match string) string {
+		parts := slackLink.FindStringSubmatch(match)
+		url := strings.TrimPrefix(parts[1], "mailto:")
+		if parts[2] == "" || parts[2] == url {
+			return url
+		}
+		return parts[2] + " (" + url + ")"
+	})
+
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(text)
+}
+
+// parseSlackTS converte o ts do Slack ("1512085950.000216") em horário UTC
+func parseSlackTS(ts string) (time.Time, error) {
+	seconds, fraction, _ := strings.Cut(ts, ".")
+
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var micro int64
+	if fraction != "" {
+		fraction = (fraction + "000000")[:6]
+		if micro, err = strconv.ParseInt(fraction, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Unix(sec, micro*int64(time.Microsecond)).UTC(), nil
+}
+
+func readJSON(files map[string]*zip.File, name string, dst interface{}) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("arquivo %s não encontrado no export", name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir %s: %v", name, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %v", name, err)
+	}
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("erro ao interpretar %s: %v", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,509 @@
+// Package markdown interpreta o subconjunto de Markdown aceito nas mensagens e gera
+// a lista de entidades e um HTML seguro para exibição.
+//
+// Subconjunto suportado:
+//
+//	**negrito**  *itálico* ou _itálico_  ~~riscado~~  `código`
+//	```linguagem
+//	bloco de código
+//	```
+//	[texto](https://exemplo.com)  https://exemplo.com  @usuario
+//
+// O HTML é montado a partir do texto escapado: nenhuma marcação do usuário chega ao
+// resultado, e links só aceitam http, https e mailto.
+//
+// A interpretação é linear no tamanho do conteúdo: as posições de cada marcador são
+// pré-calculadas em uma única passada, e nenhuma busca percorre o texto de novo.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength é o tamanho máximo (em caracteres) interpretado; conteúdos maiores são
+// exibidos como texto simples, sem entidades
+const MaxLength = 10000
+
+// Tamanho máximo (em caracteres) de uma URL reconhecida em links e autolinks
+const maxURLLength = 2048
+
+// Tipos de entidade
+const (
+	EntityBold          = "bold"
+	EntityItalic        = "italic"
+	EntityStrikethrough = "strikethrough"
+	EntityCode          = "code"
+	EntityPre           = "pre"
+	EntityLink          = "link"
+	EntityMention       = "mention"
+)
+
+// Entity é um trecho formatado do conteúdo. Offset e Length contam caracteres (runas)
+// do conteúdo original, incluindo os marcadores.
+type Entity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	// Destino dos links
+	URL string `json:"url,omitempty"`
+	// Usuário mencionado (sem o @)
+	Username string `json:"username,omitempty"`
+	// Linguagem declarada no bloco de código
+	Language string `json:"language,omitempty"`
+}
+
+// Render interpreta o conteúdo e retorna as entidades, em ordem de início, e o HTML seguro
+func Render(content string) ([]Entity, string) {
+	if utf8.RuneCountInString(content) > MaxLength {
+		return []Entity{}, plainHTML(content)
+	}
+	return render(content)
+}
+
+// PlainText retorna o texto exibido do conteúdo, sem marcadores e com os escapes resolvidos,
+// e a posição (em caracteres) de cada um dos seus caracteres no conteúdo. As URLs dos links,
+// que não aparecem no texto, vêm no fim, separadas por espaços sem posição (-1).
+func PlainText(content string) (string, []int) {
+	if utf8.RuneCountInString(content) > MaxLength {
+		positions := make([]int, 0, len(content))
+		for i := range []rune(content) {
+			positions = append(positions, i)
+		}
+		return content, positions
+	}
+
+	r := newRenderer(content)
+	r.run()
+	for _, span := range r.hidden {
+		r.separate()
+		r.showRange(span[0], span[1])
+	}
+	return string(r.plain), r.plainAt
+}
+
+func render(content string) ([]Entity, string) {
+	r := newRenderer(content)
+	r.run()
+	return r.entities, r.out.String()
+}
+
+func (r *renderer) run() {
+	r.block()
+
+	sort.SliceStable(r.entities, func(i, j int) bool {
+		return r.entities[i].Offset < r.entities[j].Offset
+	})
+	if r.entities == nil {
+		r.entities = []Entity{}
+	}
+}
+
+type renderer struct {
+	runes    []rune
+	entities []Entity
+	out      strings.Builder
+
+	// Texto exibido e a posição de cada caractere no conteúdo (veja PlainText)
+	plain   []rune
+	plainAt []int
+	// URLs dos links, [início, fim), que não fazem parte do texto exibido
+	hidden [][2]int
+
+	// Próxima ocorrência de cada marcador a partir de uma posição
+	backticks occurrences // `
+	bolds     occurrences // **
+	strikes   occurrences // ~~
+	linkMids  occurrences // ](
+	parens    occurrences // )
+	newlines  occurrences // \n
+	breaks    occurrences // espaço, < ou >: fim de um autolink
+	// Marcadores que podem fechar um itálico (veja closingEmphasis)
+	starClosers       occurrences
+	underscoreClosers occurrences
+}
+
+// occurrences guarda, para cada posição i, a primeira posição >= i em que o padrão começa,
+// ou o tamanho do texto se não houver
+type occurrences []int
+
+func newRenderer(content string) *renderer {
+	r := &renderer{runes: []rune(content)}
+	n := len(r.runes)
+
+	all := []*occurrences{&r.backticks, &r.bolds, &r.strikes, &r.linkMids, &r.parens, &r.newlines, &r.breaks, &r.starClosers, &r.underscoreClosers}
+	for _, o := range all {
+		*o = make(occurrences, n+1)
+		(*o)[n] = n
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		c := r.runes[i]
+		var next rune
+		if i+1 < n {
+			next = r.runes[i+1]
+		}
+		closer := i > 0 && !unicode.IsSpace(r.runes[i-1])
+
+		r.backticks.mark(i, c == '`')
+		r.bolds.mark(i, c == '*' && next == '*')
+		r.strikes.mark(i, c == '~' && next == '~')
+		r.linkMids.mark(i, c == ']' && next == '(')
+		r.parens.mark(i, c == ')')
+		r.newlines.mark(i, c == '\n')
+		r.breaks.mark(i, unicode.IsSpace(c) || c == '<' || c == '>')
+		r.starClosers.mark(i, closer && c == '*' && next != '*')
+		r.underscoreClosers.mark(i, closer && c == '_' && (i+1 == n || !isWordRune(next)))
+	}
+
+	return r
+}
+
+func (o occurrences) mark(i int, match bool) {
+	if match {
+		o[i] = i
+	} else {
+		o[i] = o[i+1]
+	}
+}
+
+// find retorna a primeira ocorrência, inteira dentro de [from, end), de um padrão com
+// width caracteres, ou -1
+func (o occurrences) find(width, from, end int) int {
+	if from >= end {
+		return -1
+	}
+	if j := o[from]; j+width <= end {
+		return j
+	}
+	return -1
+}
+
+// block separa os blocos de código cercados (```) do texto corrido
+func (r *renderer) block() {
+	start := 0
+	for i := 0; i < len(r.runes); i++ {
+		if !r.fenceAt(i) || (i > 0 && r.runes[i-1] != '\n') {
+			continue
+		}
+
+		end := r.closingFence(i + 3)
+		if end < 0 {
+			break
+		}
+
+		r.inline(start, i)
+
+		// Linguagem: o restante da linha de abertura
+		bodyStart := i + 3
+		for bodyStart < end && r.runes[bodyStart] != '\n' {
+			bodyStart++
+		}
+		language := strings.TrimSpace(string(r.runes[i+3 : bodyStart]))
+		if bodyStart < end {
+			bodyStart++
+		}
+		body := strings.TrimSuffix(string(r.runes[bodyStart:end]), "\n")
+		r.showRange(bodyStart, bodyStart+utf8.RuneCountInString(body))
+
+		r.entities = append(r.entities, Entity{Type: EntityPre, Offset: i, Length: end + 3 - i, Language: language})
+		if language != "" {
+			r.out.WriteString(`<pre><code class="language-` + html.EscapeString(language) + `">`)
+		} else {
+			r.out.WriteString("<pre><code>")
+		}
+		r.out.WriteString(html.EscapeString(body))
+		r.out.WriteString("</code></pre>")
+
+		i = end + 2
+		start = end + 3
+		// A quebra de linha após o bloco pertence ao bloco
+		if start < len(r.runes) && r.runes[start] == '\n' {
+			start++
+			i++
+		}
+	}
+	r.inline(start, len(r.runes))
+}
+
+func (r *renderer) fenceAt(i int) bool {
+	return i+2 < len(r.runes) && r.runes[i] == '`' && r.runes[i+1] == '`' && r.runes[i+2] == '`'
+}
+
+// closingFence procura o ``` que fecha o bloco, no início de uma linha
+func (r *renderer) closingFence(from int) int {
+	for j := from; j < len(r.runes); j++ {
+		if r.fenceAt(j) && r.runes[j-1] == '\n' {
+			return j
+		}
+	}
+	return -1
+}
+
+// inline renderiza o trecho [start, end) com formatação de linha
+func (r *renderer) inline(start, end int) {
+	var text strings.Builder
+	flush := func() {
+		r.out.WriteString(html.EscapeString(text.String()))
+		text.Reset()
+	}
+
+	for i := start; i < end; {
+		c := r.runes[i]
+
+		switch {
+		case c == '\\' && i+1 < end && isEscapable(r.runes[i+1]):
+			text.WriteRune(r.runes[i+1])
+			r.show(i + 1)
+			i += 2
+			continue
+
+		case c == '\n':
+			flush()
+			r.out.WriteString("<br>")
+			r.show(i)
+			i++
+			continue
+
+		case c == '`':
+			if j := r.backticks.find(1, i+1, end); j > i+1 {
+				flush()
+				r.entities = append(r.entities, Entity{Type: EntityCode, Offset: i, Length: j + 1 - i})
+				r.out.WriteString("<code>" + html.EscapeString(string(r.runes[i+1:j])) + "</code>")
+				r.showRange(i+1, j)
+				i = j + 1
+				continue
+			}
+
+		case c == '*' && r.at("**", i, end):
+			if j := r.bolds.find(2, i+2, end); j > i+2 {
+				flush()
+				r.wrap(EntityBold, "strong", i, j+2, 2)
+				i = j + 2
+				continue
+			}
+
+		case c == '~' && r.at("~~", i, end):
+			if j := r.strikes.find(2, i+2, end); j > i+2 {
+				flush()
+				r.wrap(EntityStrikethrough, "del", i, j+2, 2)
+				i = j + 2
+				continue
+			}
+
+		case c == '*' || (c == '_' && !r.wordBefore(i)):
+			if j := r.closingEmphasis(c, i+1, end); j > 0 {
+				flush()
+				r.wrap(EntityItalic, "em", i, j+1, 1)
+				i = j + 1
+				continue
+			}
+
+		case c == '[':
+			if textEnd, urlEnd, link := r.link(i, end); urlEnd > 0 {
+				flush()
+				r.entities = append(r.entities, Entity{Type: EntityLink, Offset: i, Length: urlEnd + 1 - i, URL: link})
+				r.out.WriteString(anchorOpen(link))
+				r.inline(i+1, textEnd)
+				r.out.WriteString("</a>")
+				r.hidden = append(r.hidden, [2]int{textEnd + 2, urlEnd})
+				i = urlEnd + 1
+				continue
+			}
+
+		case (c == 'h' || c == 'H') && !r.wordBefore(i):
+			if j := r.autolink(i, end); j > i {
+				flush()
+				link := string(r.runes[i:j])
+				r.entities = append(r.entities, Entity{Type: EntityLink, Offset: i, Length: j - i, URL: link})
+				r.out.WriteString(anchorOpen(link) + html.EscapeString(link) + "</a>")
+				r.showRange(i, j)
+				i = j
+				continue
+			}
+
+		case c == '@' && !r.mentionBlocked(i):
+			if j := r.mentionEnd(i+1, end); j > i+1 {
+				flush()
+				username := string(r.runes[i+1 : j])
+				r.entities = append(r.entities, Entity{Type: EntityMention, Offset: i, Length: j - i, Username: username})
+				r.out.WriteString(`<span class="mention" data-username="` + html.EscapeString(username) + `">@` + html.EscapeString(username) + `</span>`)
+				r.showRange(i, j)
+				i = j
+				continue
+			}
+		}
+
+		text.WriteRune(c)
+		r.show(i)
+		i++
+	}
+	flush()
+}
+
+// show registra o caractere i como parte do texto exibido
+func (r *renderer) show(i int) {
+	r.plain = append(r.plain, r.runes[i])
+	r.plainAt = append(r.plainAt, i)
+}
+
+func (r *renderer) showRange(start, end int) {
+	for k := start; k < end; k++ {
+		r.show(k)
+	}
+}
+
+// separate acrescenta ao texto exibido um espaço que não existe no conteúdo
+func (r *renderer) separate() {
+	r.plain = append(r.plain, ' ')
+	r.plainAt = append(r.plainAt, -1)
+}
+
+// wrap registra a entidade de [start, end) e renderiza o conteúdo entre os marcadores
+func (r *renderer) wrap(entityType, tag string, start, end, marker int) {
+	r.entities = append(r.entities, Entity{Type: entityType, Offset: start, Length: end - start})
+	r.out.WriteString("<" + tag + ">")
+	r.inline(start+marker, end-marker)
+	r.out.WriteString("</" + tag + ">")
+}
+
+func (r *renderer) at(pattern string, i, end int) bool {
+	p := []rune(pattern)
+	if i+len(p) > end {
+		return false
+	}
+	for k, c := range p {
+		if r.runes[i+k] != c {
+			return false
+		}
+	}
+	return true
+}
+
+// closingEmphasis procura, na mesma linha, o marcador que fecha o itálico. O texto não pode
+// começar nem terminar com espaço, "*" não fecha antes de outro "*", e "_" só fecha antes
+// de algo que não seja letra ou número. No fim do trecho (end-1) o caractere seguinte não
+// conta, pois pertence ao marcador externo.
+func (r *renderer) closingEmphasis(marker rune, from, end int) int {
+	if from >= end || unicode.IsSpace(r.runes[from]) || r.runes[from] == marker {
+		return -1
+	}
+
+	closers := r.starClosers
+	if marker == '_' {
+		closers = r.underscoreClosers
+	}
+
+	lineEnd := min(end, r.newlines[from+1])
+	if j := closers[from+1]; j < lineEnd && j < end-1 {
+		return j
+	}
+
+	last := end - 1
+	if last > from && last < lineEnd && r.runes[last] == marker && !unicode.IsSpace(r.runes[last-1]) {
+		return last
+	}
+	return -1
+}
+
+// link reconhece [texto](url) a partir de i; retorna o fim do texto, o ")" final e a URL.
+// O texto fica numa única linha.
+func (r *renderer) link(i, end int) (int, int, string) {
+	textEnd := r.linkMids.find(2, i+1, end)
+	if textEnd <= i+1 || r.newlines[i+1] < textEnd {
+		return 0, -1, ""
+	}
+
+	urlEnd := r.parens.find(1, textEnd+2, end)
+	if urlEnd < 0 || urlEnd-textEnd-2 > maxURLLength {
+		return 0, -1, ""
+	}
+
+	link := strings.TrimSpace(string(r.runes[textEnd+2 : urlEnd]))
+	if !safeURL(link) {
+		return 0, -1, ""
+	}
+	return textEnd, urlEnd, link
+}
+
+// autolink reconhece URLs http(s) soltas no texto; retorna o fim da URL ou -1
+func (r *renderer) autolink(i, end int) int {
+	rest := strings.ToLower(string(r.runes[i:min(end, i+8)]))
+	if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+		return -1
+	}
+
+	j := min(end, r.breaks[i])
+	if j-i > maxURLLength {
+		return -1
+	}
+	// Pontuação final costuma pertencer à frase, não à URL
+	for j > i && strings.ContainsRune(".,;:!?)'\"", r.runes[j-1]) {
+		j--
+	}
+
+	if !safeURL(string(r.runes[i:j])) {
+		return -1
+	}
+	return j
+}
+
+// mentionBlocked evita tratar emails e "@@" como menções. As menções gravadas e notificadas
+// vêm destas entidades (services.ParseMentions).
+func (r *renderer) mentionBlocked(i int) bool {
+	if i == 0 {
+		return false
+	}
+	prev := r.runes[i-1]
+	return isWordRune(prev) || prev == '.' || prev == '@' || prev == '-'
+}
+
+func (r *renderer) mentionEnd(from, end int) int {
+	j := from
+	for j < end && (isWordRune(r.runes[j]) || r.runes[j] == '.' || r.runes[j] == '-') {
+		j++
+	}
+	for j > from && (r.runes[j-1] == '.' || r.runes[j-1] == '-') {
+		j--
+	}
+	return j
+}
+
+func (r *renderer) wordBefore(i int) bool {
+	return i > 0 && isWordRune(r.runes[i-1])
+}
+
+// plainHTML exibe o conteúdo como texto simples, preservando as quebras de linha
+func plainHTML(content string) string {
+	return strings.ReplaceAll(html.EscapeString(content), "\n", "<br>")
+}
+
+func anchorOpen(link string) string {
+	return `<a href="` + html.EscapeString(link) + `" rel="nofollow noopener noreferrer" target="_blank">`
+}
+
+func safeURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.Host != ""
+	case "mailto":
+		return parsed.Opaque != ""
+	}
+	return false
+}
+
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+}
+
+func isEscapable(c rune) bool {
+	return strings.ContainsRune("\\`*_~[]()@", c)
+}
@@ -0,0 +1,121 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	const anchor = `rel="nofollow noopener noreferrer" target="_blank"`
+
+	cases := []struct {
+		content  string
+		entities []Entity
+		html     string
+	}{
+		{
+			content:  "**negrito** e *itálico*",
+			entities: []Entity{{Type: EntityBold, Offset: 0, Length: 11}, {Type: EntityItalic, Offset: 14, Length: 9}},
+			html:     "<strong>negrito</strong> e <em>itálico</em>",
+		},
+		{
+			content:  "_ênfase_ em snake_case_var",
+			entities: []Entity{{Type: EntityItalic, Offset: 0, Length: 8}},
+			html:     "<em>ênfase</em> em snake_case_var",
+		},
+		{
+			content:  "~~riscado~~ `a <b>`",
+			entities: []Entity{{Type: EntityStrikethrough, Offset: 0, Length: 11}, {Type: EntityCode, Offset: 12, Length: 7}},
+			html:     "<del>riscado</del> <code>a &lt;b&gt;</code>",
+		},
+		{
+			content:  "```go\nfmt.Println(\"<oi>\")\n```",
+			entities: []Entity{{Type: EntityPre, Offset: 0, Length: 29, Language: "go"}},
+			html:     `<pre><code class="language-go">fmt.Println(&#34;&lt;oi&gt;&#34;)</code></pre>`,
+		},
+		{
+			content: "[site](https://exemplo.com/a?b=1&c=2) e https://exemplo.com/x.",
+			entities: []Entity{
+				{Type: EntityLink, Offset: 0, Length: 37, URL: "https://exemplo.com/a?b=1&c=2"},
+				{Type: EntityLink, Offset: 40, Length: 21, URL: "https://exemplo.com/x"},
+			},
+			html: `<a href="https://exemplo.com/a?b=1&amp;c=2" ` + anchor + `>site</a> e <a href="https://exemplo.com/x" ` + anchor + `>https://exemplo.com/x</a>.`,
+		},
+		{
+			content:  "[xss](javascript:alert(1))",
+			entities: []Entity{},
+			html:     "[xss](javascript:alert(1))",
+		},
+		{
+			content:  "oi @maria, e-mail joao@ex.com",
+			entities: []Entity{{Type: EntityMention, Offset: 3, Length: 6, Username: "maria"}},
+			html:     `oi <span class="mention" data-username="maria">@maria</span>, e-mail joao@ex.com`,
+		},
+		{
+			content:  "<script>alert('x')</script>\nlinha",
+			entities: []Entity{},
+			html:     "&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;<br>linha",
+		},
+		{
+			content:  `\*literal\*`,
+			entities: []Entity{},
+			html:     "*literal*",
+		},
+		{
+			// Offsets contam caracteres, não bytes
+			content:  "😀 **ç**",
+			entities: []Entity{{Type: EntityBold, Offset: 2, Length: 5}},
+			html:     "😀 <strong>ç</strong>",
+		},
+	}
+
+	for _, tc := range cases {
+		entities, html := Render(tc.content)
+		if !reflect.DeepEqual(entities, tc.entities) {
+			t.Errorf("Render(%q) entidades = %+v, esperado %+v", tc.content, entities, tc.entities)
+		}
+		if html != tc.html {
+			t.Errorf("Render(%q) html = %q, esperado %q", tc.content, html, tc.html)
+		}
+	}
+}
+
+func TestRenderTooLongIsPlainText(t *testing.T) {
+	content := "**<b>**\n" + strings.Repeat("a", MaxLength)
+
+	entities, html := Render(content)
+	if len(entities) != 0 {
+		t.Errorf("entidades = %+v, esperado nenhuma", entities)
+	}
+	if want := "**&lt;b&gt;**<br>" + strings.Repeat("a", MaxLength); html != want {
+		t.Errorf("html não é o texto escapado: %.40q...", html)
+	}
+}
+
+// Entradas que levavam a varreduras quadráticas; render ignora o limite de tamanho
+func TestRenderAdversarialInputsAreLinear(t *testing.T) {
+	const size = 200000
+
+	inputs := map[string]string{
+		"colchetes":       strings.Repeat("[", size),
+		"links abertos":   strings.Repeat("[a](", size/4),
+		"itálicos":        strings.Repeat("*a ", size/3),
+		"sublinhados":     strings.Repeat("_a ", size/3),
+		"negritos":        strings.Repeat("**a", size/3),
+		"riscados":        strings.Repeat("~~a", size/3),
+		"crases":          strings.Repeat("`a", size/2),
+		"autolinks":       strings.Repeat("http://a:x", size/10),
+		"blocos abertos":  strings.Repeat("```\na\n", size/6),
+		"textos de links": strings.Repeat("[a\n", size/3) + "](https://exemplo.com)",
+	}
+
+	for name, content := range inputs {
+		start := time.Now()
+		render(content)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: render levou %v para %d caracteres", name, elapsed, len(content))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	ws "github.com/gofiber/websocket/v2"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// TokenLocalsKey guarda o token autenticado no contexto da requisição (e da conexão WebSocket)
+const TokenLocalsKey = "api_token"
+
+// TokenAuth autentica requisições com "Authorization: Bearer <token>". No upgrade do
+// WebSocket o token também é aceito em ?access_token=, já que navegadores não enviam
+// cabeçalhos nessa requisição. Requisições sem token seguem o fluxo por user_id.
+func TokenAuth(tokens *services.APITokenService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		raw := bearerToken(ctx)
+		if raw == "" {
+			return ctx.Next()
+		}
+
+		token, err := tokens.Authenticate(raw)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIToken) {
+				return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Erro interno do servidor",
+			})
+		}
+
+		ctx.Locals(TokenLocalsKey, token)
+		return ctx.Next()
+	}
+}
+
+// RequireScope recusa com 403 requisições autenticadas por token sem o escopo informado.
+// Requisições sem token não são afetadas.
+func RequireScope(scope string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if token := CurrentToken(ctx); token != nil && !token.HasScope(scope) {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Token sem o escopo " + scope,
+			})
+		}
+		return ctx.Next()
+	}
+}
+
+// ServiceAccountsRequireToken recusa com 403 requisições sem token que agem em nome de uma
+// conta de serviço, identificada em X-User-ID, em ?user_id= ou nos campos informados do corpo
+// JSON. Contas de serviço só usam a API com o próprio token, como no WebSocket.
+func ServiceAccountsRequireToken(users *services.UserService, bodyFields ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if CurrentToken(ctx) != nil {
+			return ctx.Next()
+		}
+
+		userIDs := []string{ctx.Get("X-User-ID"), ctx.Query("user_id")}
+		if len(bodyFields) > 0 {
+			var body map[string]interface{}
+			if json.Unmarshal(ctx.Body(), &body) == nil {
+				for _, field := range bodyFields {
+					if userID, ok := body[field].(string); ok {
+						userIDs = append(userIDs, userID)
+					}
+				}
+			}
+		}
+
+		for _, userID := range userIDs {
+			if userID == "" {
+				continue
+			}
+
+			user, err := users.GetByID(userID)
+			if err != nil {
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Erro interno do servidor",
+				})
+			}
+			if user != nil && user.IsBot() {
+				return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Contas de serviço devem se autenticar com token de API",
+				})
+			}
+		}
+
+		return ctx.Next()
+	}
+}
+
+// CurrentToken retorna o token que autenticou a requisição, ou nil
+func CurrentToken(ctx *fiber.Ctx) *models.APIToken {
+	token, _ := ctx.Locals(TokenLocalsKey).(*models.APIToken)
+	return token
+}
+
+func bearerToken(ctx *fiber.Ctx) string {
+	header := ctx.Get(fiber.HeaderAuthorization)
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	if ws.IsWebSocketUpgrade(ctx) {
+		return ctx.Query("access_token")
+	}
+	return ""
+}
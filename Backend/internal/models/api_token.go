@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Escopos concedidos aos tokens de API
+const (
+	ScopeRoomsRead     = "rooms:read"
+	ScopeMessagesWrite = "messages:write"
+	ScopeTagsManage    = "tags:manage"
+	// Registrar comandos de barra (apenas contas de serviço)
+	ScopeCommandsManage = "commands:manage"
+	// Rotas administrativas e alterações de usuários e salas
+	ScopeAdmin = "admin"
+)
+
+// APITokenScopes lista os escopos aceitos
+var APITokenScopes = []string{ScopeRoomsRead, ScopeMessagesWrite, ScopeTagsManage, ScopeCommandsManage, ScopeAdmin}
+
+// IsAPITokenScope indica se o escopo é conhecido
+func IsAPITokenScope(scope string) bool {
+	for _, s := range APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken é uma credencial de longa duração de um usuário (normalmente um bot)
+type APIToken struct {
+	ID     string `json:"id" db:"id"`
+	UserID string `json:"user_id" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// Início do token, para identificá-lo nas listagens sem expor o segredo
+	Prefix string `json:"prefix" db:"prefix"`
+	// Apenas o hash SHA-256 do token é gravado
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Scopes     string     `json:"scopes" db:"scopes"` // JSON array de escopos
+	CreatedBy  string     `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+func NewAPIToken(userID, name, prefix, tokenHash, scopes, createdBy string) *APIToken {
+	return &APIToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// HasScope indica se o token concede o escopo
+func (t *APIToken) HasScope(scope string) bool {
+	var scopes []string
+	if err := json.Unmarshal([]byte(t.Scopes), &scopes); err != nil {
+		return false
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoked indica se o token foi revogado
+func (t *APIToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Origem dos comandos de barra
+const (
+	CommandSourceBuiltin     = "builtin"
+	CommandSourceIntegration = "integration"
+)
+
+// SlashCommand é um comando disponível nas salas ("/nome argumentos")
+type SlashCommand struct {
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+	Usage       string `json:"usage" db:"usage"`
+	Source      string `json:"source" db:"-"`
+	// Conta de serviço dona do comando (apenas integrações)
+	BotID string `json:"bot_id,omitempty" db:"bot_id"`
+	// Endpoint chamado na execução e segredo do HMAC; expostos apenas no cadastro
+	CallbackURL string     `json:"-" db:"callback_url"`
+	Secret      string     `json:"-" db:"secret"`
+	CreatedAt   *time.Time `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+func NewIntegrationCommand(name, description, usage, botID, callbackURL, secret string) *SlashCommand {
+	now := time.Now().UTC()
+	return &SlashCommand{
+		Name:        name,
+		Description: description,
+		Usage:       usage,
+		Source:      CommandSourceIntegration,
+		BotID:       botID,
+		CallbackURL: callbackURL,
+		Secret:      secret,
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+	}
+}
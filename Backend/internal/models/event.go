@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// RoomEvent é um evento persistido no log da sala, identificado por uma sequência crescente por sala
+type RoomEvent struct {
+	RoomID    string    `json:"room_id" db:"room_id"`
+	Seq       int64     `json:"seq" db:"seq"`
+	Type      string    `json:"type" db:"type"`       // new_message, message_edited, message_deleted
+	Payload   string    `json:"payload" db:"payload"` // JSON do payload enviado aos clientes
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
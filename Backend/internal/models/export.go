@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status de uma exportação de dados pessoais
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// DataExport é um pedido de exportação dos dados de um usuário, processado em segundo plano
+type DataExport struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Status      string     `json:"status" db:"status"`
+	FilePath    string     `json:"-" db:"file_path"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+}
+
+func NewDataExport(userID string) *DataExport {
+	return &DataExport{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    ExportStatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+}
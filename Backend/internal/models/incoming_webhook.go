@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IncomingWebhook é um token que permite a sistemas externos publicar mensagens numa sala
+type IncomingWebhook struct {
+	ID     string `json:"id" db:"id"`
+	RoomID string `json:"room_id" db:"room_id"`
+	// Nome e avatar padrão do bot que assina as mensagens
+	Name   string `json:"name" db:"name"`
+	Avatar string `json:"avatar" db:"avatar"`
+	// Apenas o hash SHA-256 do token é gravado; o token aparece só na criação
+	TokenHash  string     `json:"-" db:"token_hash"`
+	CreatedBy  string     `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+func NewIncomingWebhook(roomID, name, avatar, tokenHash, createdBy string) *IncomingWebhook {
+	return &IncomingWebhook{
+		ID:        uuid.New().String(),
+		RoomID:    roomID,
+		Name:      name,
+		Avatar:    avatar,
+		TokenHash: tokenHash,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Escopos de retenção legal
+const (
+	LegalHoldScopeRoom = "room"
+	LegalHoldScopeUser = "user"
+)
+
+// LegalHold impede a remoção do conteúdo de uma sala ou de um usuário enquanto estiver ativa.
+// O registro é mantido depois da liberação como trilha de auditoria.
+type LegalHold struct {
+	ID         string     `json:"id" db:"id"`
+	Scope      string     `json:"scope" db:"scope"` // room ou user
+	TargetID   string     `json:"target_id" db:"target_id"`
+	Reason     string     `json:"reason" db:"reason"`
+	PlacedBy   string     `json:"placed_by" db:"placed_by"`
+	PlacedAt   time.Time  `json:"placed_at" db:"placed_at"`
+	ReleasedBy string     `json:"released_by,omitempty" db:"released_by"`
+	ReleasedAt *time.Time `json:"released_at" db:"released_at"`
+}
+
+func NewLegalHold(scope, targetID, reason, placedBy string) *LegalHold {
+	return &LegalHold{
+		ID:       uuid.New().String(),
+		Scope:    scope,
+		TargetID: targetID,
+		Reason:   reason,
+		PlacedBy: placedBy,
+		PlacedAt: time.Now().UTC(),
+	}
+}
+
+// Active indica se a retenção ainda não foi liberada
+func (h *LegalHold) Active() bool {
+	return h.ReleasedAt == nil
+}
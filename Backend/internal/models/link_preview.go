@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LinkPreview é o cartão de prévia de um link (metadados OpenGraph/Twitter Card da página)
+type LinkPreview struct {
+	URL         string `json:"url" db:"url"`
+	Title       string `json:"title" db:"title"`
+	Description string `json:"description,omitempty" db:"description"`
+	Image       string `json:"image,omitempty" db:"image"`
+	SiteName    string `json:"site_name,omitempty" db:"site_name"`
+	// Falha da última busca (cache negativo); vazio quando a prévia é válida
+	Error     string    `json:"-" db:"error"`
+	FetchedAt time.Time `json:"-" db:"fetched_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de menção
+const (
+	MentionKindUser = "user" // @username
+	MentionKindRoom = "room" // @room: todos os participantes da sala
+	MentionKindHere = "here" // @here: quem está conectado na sala
+)
+
+// Mention registra que um usuário foi mencionado em uma mensagem
+type Mention struct {
+	ID        string     `json:"id" db:"id"`
+	MessageID string     `json:"message_id" db:"message_id"`
+	RoomID    string     `json:"room_id" db:"room_id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	AuthorID  string     `json:"author_id" db:"author_id"`
+	Kind      string     `json:"kind" db:"kind"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ReadAt    *time.Time `json:"read_at" db:"read_at"`
+	Message   *Message   `json:"message,omitempty"`
+}
+
+func NewMention(message *Message, userID, kind string) *Mention {
+	return &Mention{
+		ID:        uuid.New().String(),
+		MessageID: message.ID,
+		RoomID:    message.RoomID,
+		UserID:    userID,
+		AuthorID:  message.UserID,
+		Kind:      kind,
+		CreatedAt: time.Now().UTC(),
+		Message:   message,
+	}
+}
+
+// Unread indica se a menção ainda não foi lida
+func (m *Mention) Unread() bool {
+	return m.ReadAt == nil
+}
@@ -4,25 +4,40 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rafael-bit/whatz/internal/markdown"
 )
 
+// MaxMessageLength limita o tamanho (em caracteres) do conteúdo enviado por usuários
+const MaxMessageLength = 4000
+
 type Message struct {
 	ID        string    `json:"id" db:"id"`
 	Content   string    `json:"content" db:"content"`
 	UserID    string    `json:"user_id" db:"user_id"`
 	Username  string    `json:"username" db:"username"`
 	Avatar    string    `json:"avatar" db:"avatar"`
-	Type      string    `json:"type" db:"type"` // text, image, file, system
+	Type      string    `json:"type" db:"type"` // text, image, file, system, bot, action
 	RoomID    string    `json:"room_id" db:"room_id"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Mensagem que abriu a conversa (thread); vazio fora de threads
+	ThreadID string `json:"thread_id,omitempty" db:"thread_id"`
+	// Autor removido: username traz o placeholder em vez do nome original
+	AuthorDeleted bool `json:"author_deleted,omitempty" db:"-"`
+
+	// Formatação extraída do Markdown de Content (offsets em caracteres)
+	Entities []markdown.Entity `json:"entities" db:"entities"`
+	// Renderização HTML sanitizada de Content
+	HTML string `json:"html" db:"html"`
+	// Prévias dos links, preenchidas em segundo plano após o envio
+	Previews []LinkPreview `json:"previews,omitempty" db:"previews"`
 }
 
 func NewMessage(content, userID, username, avatar, messageType, roomID string) *Message {
 	now := time.Now().UTC()
-	return &Message{
+	message := &Message{
 		ID:        uuid.New().String(),
-		Content:   content,
 		UserID:    userID,
 		Username:  username,
 		Avatar:    avatar,
@@ -31,4 +46,12 @@ func NewMessage(content, userID, username, avatar, messageType, roomID string) *
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
+	message.SetContent(content)
+	return message
+}
+
+// SetContent troca o conteúdo e recalcula as entidades e o HTML correspondentes
+func (m *Message) SetContent(content string) {
+	m.Content = content
+	m.Entities, m.HTML = markdown.Render(content)
 }
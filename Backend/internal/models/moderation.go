@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de regra de moderação
+const (
+	ModerationKindWords = "words" // lista de palavras separadas por vírgula ou quebra de linha
+	ModerationKindRegex = "regex" // expressão regular (sintaxe RE2)
+)
+
+// Ações aplicadas quando uma regra encontra o conteúdo
+const (
+	ModerationActionBlock = "block" // a mensagem não é gravada e o remetente é avisado
+	ModerationActionMask  = "mask"  // os trechos encontrados são trocados por •
+	ModerationActionFlag  = "flag"  // a mensagem é publicada e vai para a fila de revisão
+)
+
+// Situação das mensagens sinalizadas
+const (
+	ModerationFlagPending  = "pending"
+	ModerationFlagResolved = "resolved"
+)
+
+// ModerationRule é um filtro de conteúdo configurado para uma sala
+type ModerationRule struct {
+	ID        string    `json:"id" db:"id"`
+	RoomID    string    `json:"room_id" db:"room_id"`
+	Kind      string    `json:"kind" db:"kind"`
+	Pattern   string    `json:"pattern" db:"pattern"`
+	Action    string    `json:"action" db:"action"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+func NewModerationRule(roomID, kind, pattern, action, createdBy string) *ModerationRule {
+	return &ModerationRule{
+		ID:        uuid.New().String(),
+		RoomID:    roomID,
+		Kind:      kind,
+		Pattern:   pattern,
+		Action:    action,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// ModerationFlag é uma mensagem publicada que aguarda revisão de um administrador
+type ModerationFlag struct {
+	ID        string `json:"id" db:"id"`
+	MessageID string `json:"message_id" db:"message_id"`
+	RoomID    string `json:"room_id" db:"room_id"`
+	UserID    string `json:"user_id" db:"user_id"`
+	// Regra que sinalizou; vazio para filtros que não vêm de regras da sala
+	RuleID     string     `json:"rule_id,omitempty" db:"rule_id"`
+	Reason     string     `json:"reason" db:"reason"`
+	Status     string     `json:"status" db:"status"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ReviewedBy string     `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	// Mensagem sinalizada; nil se já foi removida
+	Message *Message `json:"message,omitempty" db:"-"`
+}
+
+func NewModerationFlag(message *Message, ruleID, reason string) *ModerationFlag {
+	return &ModerationFlag{
+		ID:        uuid.New().String(),
+		MessageID: message.ID,
+		RoomID:    message.RoomID,
+		UserID:    message.UserID,
+		RuleID:    ruleID,
+		Reason:    reason,
+		Status:    ModerationFlagPending,
+		CreatedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de notificação
+const (
+	NotificationTypeMention    = "mention"
+	NotificationTypeReply      = "reply"
+	NotificationTypeInvite     = "invite"
+	NotificationTypeRoleChange = "role_change"
+)
+
+// NotificationTypes lista os tipos aceitos nas preferências
+var NotificationTypes = []string{
+	NotificationTypeMention,
+	NotificationTypeReply,
+	NotificationTypeInvite,
+	NotificationTypeRoleChange,
+}
+
+// Notification avisa o usuário sobre algo que aconteceu fora da sala que ele tem aberta
+type Notification struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	Type      string     `json:"type" db:"type"`
+	Title     string     `json:"title" db:"title"`
+	Body      string     `json:"body" db:"body"`
+	RoomID    string     `json:"room_id,omitempty" db:"room_id"`
+	MessageID string     `json:"message_id,omitempty" db:"message_id"`
+	ActorID   string     `json:"actor_id,omitempty" db:"actor_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ReadAt    *time.Time `json:"read_at" db:"read_at"`
+}
+
+func NewNotification(userID, notificationType, title, body string) *Notification {
+	return &Notification{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      notificationType,
+		Title:     title,
+		Body:      body,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// IsNotificationType indica se o tipo de notificação é conhecido
+func IsNotificationType(notificationType string) bool {
+	for _, t := range NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PinnedMessage é uma mensagem fixada no topo da sala
+type PinnedMessage struct {
+	RoomID    string    `json:"room_id" db:"room_id"`
+	MessageID string    `json:"message_id" db:"message_id"`
+	PinnedBy  string    `json:"pinned_by" db:"pinned_by"`
+	PinnedAt  time.Time `json:"pinned_at" db:"pinned_at"`
+	Message   *Message  `json:"message"`
+}
+
+func NewPinnedMessage(message *Message, pinnedBy string) *PinnedMessage {
+	return &PinnedMessage{
+		RoomID:    message.RoomID,
+		MessageID: message.ID,
+		PinnedBy:  pinnedBy,
+		PinnedAt:  time.Now().UTC(),
+		Message:   message,
+	}
+}
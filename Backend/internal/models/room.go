@@ -15,6 +15,16 @@ type Room struct {
 	CreatedBy   string    `json:"created_by" db:"created_by"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	// Dias de retenção das mensagens; nil usa a política global e 0 mantém para sempre
+	RetentionDays *int `json:"retention_days" db:"retention_days"`
+	// Sala arquivada fica somente leitura; nil enquanto ativa
+	ArchivedAt *time.Time `json:"archived_at" db:"archived_at"`
+}
+
+// Archived indica se a sala está arquivada (somente leitura)
+func (r *Room) Archived() bool {
+	return r.ArchivedAt != nil
 }
 
 func NewRoom(name, description, roomType, createdBy string) *Room {
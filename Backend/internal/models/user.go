@@ -6,16 +6,26 @@ import (
 	"github.com/google/uuid"
 )
 
+// Tipos de conta
+const (
+	UserKindHuman = "human"
+	// Contas de serviço usadas por integrações; autenticam-se apenas por token de API
+	UserKindBot = "bot"
+)
+
 type User struct {
-	ID        string    `json:"id" db:"id"`
-	Username  string    `json:"username" db:"username"`
-	Email     string    `json:"email" db:"email"`
-	Avatar    string    `json:"avatar" db:"avatar"`
-	Status    string    `json:"status" db:"status"`
-	Role      string    `json:"role" db:"role"` // admin, user
-	Tags      string    `json:"tags" db:"tags"` // JSON array de tags
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID       string `json:"id" db:"id"`
+	Username string `json:"username" db:"username"`
+	Email    string `json:"email" db:"email"`
+	Avatar   string `json:"avatar" db:"avatar"`
+	Status   string `json:"status" db:"status"`
+	Role     string `json:"role" db:"role"` // admin, user
+	Tags     string `json:"tags" db:"tags"` // JSON array de tags
+	Kind     string `json:"kind" db:"kind"` // human, bot
+	// Última atividade registrada pelo serviço de presença
+	LastSeenAt *time.Time `json:"last_seen_at" db:"last_seen_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 func NewUser(username, email, avatar string) *User {
@@ -28,7 +38,23 @@ func NewUser(username, email, avatar string) *User {
 		Status:    "online",
 		Role:      "user",
 		Tags:      "[]", // Array vazio de tags
+		Kind:      UserKindHuman,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 }
+
+// NewBotUser cria uma conta de serviço. Bots não têm email real: o endereço
+// reservado derivado do ID apenas satisfaz a unicidade da coluna.
+func NewBotUser(username, avatar string) *User {
+	user := NewUser(username, "", avatar)
+	user.Email = user.ID + "@bots.whatz.local"
+	user.Status = "offline"
+	user.Kind = UserKindBot
+	return user
+}
+
+// IsBot indica se a conta é de serviço
+func (u *User) IsBot() bool {
+	return u.Kind == UserKindBot
+}
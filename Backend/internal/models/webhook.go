@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Estados de uma entrega de webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook é um endpoint externo que recebe os eventos das salas
+type Webhook struct {
+	ID  string `json:"id" db:"id"`
+	URL string `json:"url" db:"url"`
+	// Segredo do HMAC-SHA256; exposto apenas na criação
+	Secret string `json:"-" db:"secret"`
+	// Tipos de evento aceitos (JSON); vazio recebe todos
+	EventTypes string `json:"event_types" db:"event_types"`
+	// Sala filtrada; vazio recebe todas
+	RoomID    string    `json:"room_id" db:"room_id"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func NewWebhook(url, secret, eventTypes, roomID, createdBy string) *Webhook {
+	now := time.Now().UTC()
+	return &Webhook{
+		ID:         uuid.New().String(),
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		RoomID:     roomID,
+		Active:     true,
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// WebhookDelivery é um evento na fila de entrega de um webhook
+type WebhookDelivery struct {
+	ID        string `json:"id" db:"id"`
+	WebhookID string `json:"webhook_id" db:"webhook_id"`
+	EventType string `json:"event_type" db:"event_type"`
+	RoomID    string `json:"room_id" db:"room_id"`
+	// Corpo JSON enviado (o mesmo em todas as tentativas)
+	Payload     string     `json:"payload" db:"payload"`
+	Status      string     `json:"status" db:"status"`
+	Attempts    int        `json:"attempts" db:"attempts"`
+	NextAttempt *time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError   string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at" db:"delivered_at"`
+
+	AttemptLog []*WebhookAttempt `json:"attempt_log,omitempty"`
+}
+
+// WebhookAttempt registra uma tentativa de entrega
+type WebhookAttempt struct {
+	ID         string    `json:"id" db:"id"`
+	DeliveryID string    `json:"delivery_id" db:"delivery_id"`
+	StatusCode int       `json:"status_code" db:"status_code"` // 0 quando não houve resposta
+	Error      string    `json:"error,omitempty" db:"error"`
+	DurationMs int64     `json:"duration_ms" db:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
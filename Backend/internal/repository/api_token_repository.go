@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+const apiTokenSelect = `
+		SELECT id, user_id, name, prefix, token_hash, scopes, created_by, created_at, last_used_at, revoked_at
+		FROM api_tokens
+	`
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	token := &models.APIToken{}
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.TokenHash, &token.Scopes, &token.CreatedBy, &token.CreatedAt, &lastUsedAt, &revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+type APITokenRepository struct {
+	db *sql.DB
+}
+
+func NewAPITokenRepository(db *sql.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+func (r *APITokenRepository) Create(token *models.APIToken) error {
+	query := `
+		INSERT INTO api_tokens (id, user_id, name, prefix, token_hash, scopes, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, token.ID, token.UserID, token.Name, token.Prefix, token.TokenHash, token.Scopes, token.CreatedBy, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar token de API: %v", err)
+	}
+
+	return nil
+}
+
+func (r *APITokenRepository) GetByID(id string) (*models.APIToken, error) {
+	return r.getOne(`WHERE id = ?`, id)
+}
+
+func (r *APITokenRepository) GetByHash(tokenHash string) (*models.APIToken, error) {
+	return r.getOne(`WHERE token_hash = ?`, tokenHash)
+}
+
+func (r *APITokenRepository) getOne(where string, arg string) (*models.APIToken, error) {
+	token, err := scanAPIToken(r.db.QueryRow(apiTokenSelect+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar token de API: %v", err)
+	}
+
+	return token, nil
+}
+
+// GetAll lista os tokens, do mais recente para o mais antigo; com userID, apenas os do usuário
+func (r *APITokenRepository) GetAll(userID string) ([]*models.APIToken, error) {
+	query := apiTokenSelect
+	var args []interface{}
+	if userID != "" {
+		query += `WHERE user_id = ? `
+		args = append(args, userID)
+	}
+	query += `ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tokens de API: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []*models.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear token de API: %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// Revoke marca o token como revogado; retorna false se ele não existir ou já estiver revogado
+func (r *APITokenRepository) Revoke(id string, revokedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, revokedAt, id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar token de API: %v", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// Touch registra o último uso do token
+func (r *APITokenRepository) Touch(id string, usedAt time.Time) error {
+	if _, err := r.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id); err != nil {
+		return fmt.Errorf("erro ao atualizar uso do token de API: %v", err)
+	}
+	return nil
+}
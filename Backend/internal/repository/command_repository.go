@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+const commandSelect = `
+		SELECT name, description, usage, bot_id, callback_url, secret, created_at, updated_at
+		FROM slash_commands
+	`
+
+func scanCommand(row rowScanner) (*models.SlashCommand, error) {
+	command := &models.SlashCommand{Source: models.CommandSourceIntegration}
+	var createdAt, updatedAt sql.NullTime
+	err := row.Scan(
+		&command.Name, &command.Description, &command.Usage, &command.BotID, &command.CallbackURL, &command.Secret, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		command.CreatedAt = &createdAt.Time
+	}
+	if updatedAt.Valid {
+		command.UpdatedAt = &updatedAt.Time
+	}
+	return command, nil
+}
+
+type CommandRepository struct {
+	db *sql.DB
+}
+
+func NewCommandRepository(db *sql.DB) *CommandRepository {
+	return &CommandRepository{db: db}
+}
+
+// Save cadastra o comando ou, se ele já existir, atualiza descrição, uso e destino
+func (r *CommandRepository) Save(command *models.SlashCommand) error {
+	query := `
+		INSERT INTO slash_commands (name, description, usage, bot_id, callback_url, secret, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			description = excluded.description,
+			usage = excluded.usage,
+			callback_url = excluded.callback_url,
+			secret = excluded.secret,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := r.db.Exec(query, command.Name, command.Description, command.Usage, command.BotID, command.CallbackURL, command.Secret, command.CreatedAt, command.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar comando: %v", err)
+	}
+
+	return nil
+}
+
+func (r *CommandRepository) GetByName(name string) (*models.SlashCommand, error) {
+	command, err := scanCommand(r.db.QueryRow(commandSelect+`WHERE name = ?`, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar comando: %v", err)
+	}
+
+	return command, nil
+}
+
+func (r *CommandRepository) GetAll() ([]*models.SlashCommand, error) {
+	rows, err := r.db.Query(commandSelect + `ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar comandos: %v", err)
+	}
+	defer rows.Close()
+
+	commands := []*models.SlashCommand{}
+	for rows.Next() {
+		command, err := scanCommand(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear comando: %v", err)
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, rows.Err()
+}
+
+// Delete remove o comando da conta de serviço; retorna false se ele não existir ou for de outra conta
+func (r *CommandRepository) Delete(name, botID string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM slash_commands WHERE name = ? AND bot_id = ?`, name, botID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover comando: %v", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
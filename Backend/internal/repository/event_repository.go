@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+type EventRepository struct {
+	db *sql.DB
+	// Serializa a alocação de sequências para não gerar números duplicados por sala
+	mutex sync.Mutex
+}
+
+func NewEventRepository(db *sql.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// Append grava um evento no log da sala com a próxima sequência disponível e o registra no
+// outbox dos webhooks, na mesma transação
+func (r *EventRepository) Append(roomID, eventType string, payload interface{}) (*models.RoomEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar evento: %v", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	err = tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) + 1 FROM room_events WHERE room_id = ?`, roomID).Scan(&seq)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter sequência da sala: %v", err)
+	}
+
+	event := &models.RoomEvent{
+		RoomID:    roomID,
+		Seq:       seq,
+		Type:      eventType,
+		Payload:   string(data),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	query := `
+		INSERT INTO room_events (room_id, seq, type, payload, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	if _, err := tx.Exec(query, event.RoomID, event.Seq, event.Type, event.Payload, event.CreatedAt); err != nil {
+		return nil, fmt.Errorf("erro ao gravar evento: %v", err)
+	}
+
+	// Outbox lido pela distribuição aos webhooks, fora do caminho de publicação
+	if _, err := tx.Exec(`INSERT INTO room_event_outbox (room_id, seq) VALUES (?, ?)`, event.RoomID, event.Seq); err != nil {
+		return nil, fmt.Errorf("erro ao gravar evento no outbox: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar evento: %v", err)
+	}
+
+	return event, nil
+}
+
+// GetSince retorna os eventos da sala com sequência maior que afterSeq, em ordem
+func (r *EventRepository) GetSince(roomID string, afterSeq int64, limit int) ([]*models.RoomEvent, error) {
+	query := `
+		SELECT room_id, seq, type, payload, created_at
+		FROM room_events WHERE room_id = ? AND seq > ? ORDER BY seq ASC LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, roomID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar eventos da sala: %v", err)
+	}
+	defer rows.Close()
+
+	var events []*models.RoomEvent
+	for rows.Next() {
+		event := &models.RoomEvent{}
+		err := rows.Scan(&event.RoomID, &event.Seq, &event.Type, &event.Payload, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear evento: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// GetLastSeq retorna a última sequência registrada para a sala (0 se não houver eventos)
+func (r *EventRepository) GetLastSeq(roomID string) (int64, error) {
+	query := `SELECT COALESCE(MAX(seq), 0) FROM room_events WHERE room_id = ?`
+
+	var seq int64
+	if err := r.db.QueryRow(query, roomID).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("erro ao buscar última sequência: %v", err)
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+type ExportRepository struct {
+	db *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+const exportSelect = `
+		SELECT id, user_id, status, file_path, error, created_at, completed_at
+		FROM data_exports
+	`
+
+func scanExport(row rowScanner) (*models.DataExport, error) {
+	export := &models.DataExport{}
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&export.ID, &export.UserID, &export.Status, &export.FilePath, &export.Error, &export.CreatedAt, &completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		export.CompletedAt = &completedAt.Time
+	}
+	return export, nil
+}
+
+func (r *ExportRepository) Create(export *models.DataExport) error {
+	query := `
+		INSERT INTO data_exports (id, user_id, status, file_path, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, export.ID, export.UserID, export.Status, export.FilePath, export.Error, export.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar exportação: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ExportRepository) GetByID(id string) (*models.DataExport, error) {
+	export, err := scanExport(r.db.QueryRow(exportSelect+`WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar exportação: %v", err)
+	}
+
+	return export, nil
+}
+
+// GetUnfinished retorna as exportações pendentes ou interrompidas, para reprocessar ao iniciar
+func (r *ExportRepository) GetUnfinished() ([]*models.DataExport, error) {
+	rows, err := r.db.Query(exportSelect+`WHERE status IN (?, ?) ORDER BY created_at ASC`,
+		models.ExportStatusPending, models.ExportStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar exportações pendentes: %v", err)
+	}
+	defer rows.Close()
+
+	var exports []*models.DataExport
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear exportação: %v", err)
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
+
+// UpdateStatus grava o andamento da exportação; completedAt é preenchido nos estados finais
+func (r *ExportRepository) UpdateStatus(export *models.DataExport) error {
+	query := `
+		UPDATE data_exports SET status = ?, file_path = ?, error = ?, completed_at = ? WHERE id = ?
+	`
+
+	var completedAt interface{}
+	if export.CompletedAt != nil {
+		completedAt = *export.CompletedAt
+	}
+
+	_, err := r.db.Exec(query, export.Status, export.FilePath, export.Error, completedAt, export.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar exportação: %v", err)
+	}
+
+	return nil
+}
+
+// MarkFinished marca a exportação como concluída ou com falha
+func (r *ExportRepository) MarkFinished(export *models.DataExport, status, filePath, errMessage string) error {
+	now := time.Now().UTC()
+	export.Status = status
+	export.FilePath = filePath
+	export.Error = errMessage
+	export.CompletedAt = &now
+	return r.UpdateStatus(export)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+const incomingWebhookSelect = `
+		SELECT id, room_id, name, avatar, token_hash, created_by, created_at, last_used_at
+		FROM incoming_webhooks
+	`
+
+func scanIncomingWebhook(row rowScanner) (*models.IncomingWebhook, error) {
+	hook := &models.IncomingWebhook{}
+	var lastUsedAt sql.NullTime
+	err := row.Scan(
+		&hook.ID, &hook.RoomID, &hook.Name, &hook.Avatar, &hook.TokenHash, &hook.CreatedBy, &hook.CreatedAt, &lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		hook.LastUsedAt = &lastUsedAt.Time
+	}
+	return hook, nil
+}
+
+type IncomingWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewIncomingWebhookRepository(db *sql.DB) *IncomingWebhookRepository {
+	return &IncomingWebhookRepository{db: db}
+}
+
+func (r *IncomingWebhookRepository) Create(hook *models.IncomingWebhook) error {
+	query := `
+		INSERT INTO incoming_webhooks (id, room_id, name, avatar, token_hash, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, hook.ID, hook.RoomID, hook.Name, hook.Avatar, hook.TokenHash, hook.CreatedBy, hook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar webhook de entrada: %v", err)
+	}
+
+	return nil
+}
+
+func (r *IncomingWebhookRepository) GetByTokenHash(tokenHash string) (*models.IncomingWebhook, error) {
+	hook, err := scanIncomingWebhook(r.db.QueryRow(incomingWebhookSelect+`WHERE token_hash = ?`, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar webhook de entrada: %v", err)
+	}
+
+	return hook, nil
+}
+
+func (r *IncomingWebhookRepository) GetByRoom(roomID string) ([]*models.IncomingWebhook, error) {
+	rows, err := r.db.Query(incomingWebhookSelect+`WHERE room_id = ? ORDER BY created_at`, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar webhooks de entrada da sala: %v", err)
+	}
+	defer rows.Close()
+
+	hooks := []*models.IncomingWebhook{}
+	for rows.Next() {
+		hook, err := scanIncomingWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear webhook de entrada: %v", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+// Touch registra o último uso do token
+func (r *IncomingWebhookRepository) Touch(id string, usedAt time.Time) error {
+	if _, err := r.db.Exec(`UPDATE incoming_webhooks SET last_used_at = ? WHERE id = ?`, usedAt, id); err != nil {
+		return fmt.Errorf("erro ao atualizar uso do webhook de entrada: %v", err)
+	}
+	return nil
+}
+
+// Delete revoga o token da sala; retorna false se ele não existir
+func (r *IncomingWebhookRepository) Delete(roomID, id string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM incoming_webhooks WHERE room_id = ? AND id = ?`, roomID, id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao deletar webhook de entrada: %v", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+// ErrLegalHold indica que a remoção foi bloqueada por uma retenção legal ativa
+var ErrLegalHold = errors.New("conteúdo sob retenção legal")
+
+// messageNotHeld é a condição SQL que exclui mensagens cobertas por retenção legal ativa,
+// seja pela sala ou pelo autor. Deve ser usada em consultas sobre a tabela messages.
+const messageNotHeld = `NOT EXISTS (
+	SELECT 1 FROM legal_holds h
+	WHERE h.released_at IS NULL
+	AND ((h.scope = 'room' AND h.target_id = messages.room_id) OR (h.scope = 'user' AND h.target_id = messages.user_id))
+)`
+
+// queryRower é satisfeito por *sql.DB e *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// isHeld verifica se existe retenção legal ativa para o alvo
+func isHeld(q queryRower, scope, targetID string) (bool, error) {
+	var held bool
+	err := q.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM legal_holds WHERE scope = ? AND target_id = ? AND released_at IS NULL)
+	`, scope, targetID).Scan(&held)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar retenção legal: %v", err)
+	}
+	return held, nil
+}
+
+type LegalHoldRepository struct {
+	db *sql.DB
+}
+
+func NewLegalHoldRepository(db *sql.DB) *LegalHoldRepository {
+	return &LegalHoldRepository{db: db}
+}
+
+const legalHoldSelect = `
+		SELECT id, scope, target_id, reason, placed_by, placed_at, released_by, released_at
+		FROM legal_holds
+	`
+
+func scanLegalHold(row rowScanner) (*models.LegalHold, error) {
+	hold := &models.LegalHold{}
+	var releasedAt sql.NullTime
+	err := row.Scan(
+		&hold.ID, &hold.Scope, &hold.TargetID, &hold.Reason, &hold.PlacedBy, &hold.PlacedAt, &hold.ReleasedBy, &releasedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if releasedAt.Valid {
+		hold.ReleasedAt = &releasedAt.Time
+	}
+	return hold, nil
+}
+
+func (r *LegalHoldRepository) Create(hold *models.LegalHold) error {
+	query := `
+		INSERT INTO legal_holds (id, scope, target_id, reason, placed_by, placed_at, released_by)
+		VALUES (?, ?, ?, ?, ?, ?, '')
+	`
+
+	_, err := r.db.Exec(query, hold.ID, hold.Scope, hold.TargetID, hold.Reason, hold.PlacedBy, hold.PlacedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar retenção legal: %v", err)
+	}
+
+	return nil
+}
+
+func (r *LegalHoldRepository) GetByID(id string) (*models.LegalHold, error) {
+	hold, err := scanLegalHold(r.db.QueryRow(legalHoldSelect+`WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar retenção legal: %v", err)
+	}
+
+	return hold, nil
+}
+
+// GetAll lista as retenções, mais recentes primeiro; activeOnly omite as já liberadas
+func (r *LegalHoldRepository) GetAll(activeOnly bool) ([]*models.LegalHold, error) {
+	query := legalHoldSelect
+	if activeOnly {
+		query += `WHERE released_at IS NULL `
+	}
+	query += `ORDER BY placed_at DESC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar retenções legais: %v", err)
+	}
+	defer rows.Close()
+
+	holds := []*models.LegalHold{}
+	for rows.Next() {
+		hold, err := scanLegalHold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear retenção legal: %v", err)
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, rows.Err()
+}
+
+// Release libera a retenção, registrando quem e quando; retenções já liberadas não mudam
+func (r *LegalHoldRepository) Release(id, releasedBy string) error {
+	_, err := r.db.Exec(`
+		UPDATE legal_holds SET released_by = ?, released_at = ? WHERE id = ? AND released_at IS NULL
+	`, releasedBy, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("erro ao liberar retenção legal: %v", err)
+	}
+
+	return nil
+}
+
+func (r *LegalHoldRepository) IsHeld(scope, targetID string) (bool, error) {
+	return isHeld(r.db, scope, targetID)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+// LinkPreviewRepository guarda o cache de prévias por URL, inclusive das buscas que falharam
+type LinkPreviewRepository struct {
+	db *sql.DB
+}
+
+func NewLinkPreviewRepository(db *sql.DB) *LinkPreviewRepository {
+	return &LinkPreviewRepository{db: db}
+}
+
+func (r *LinkPreviewRepository) Get(url string) (*models.LinkPreview, error) {
+	query := `
+		SELECT url, title, description, image, site_name, error, fetched_at
+		FROM link_previews WHERE url = ?
+	`
+
+	preview := &models.LinkPreview{}
+	err := r.db.QueryRow(query, url).Scan(
+		&preview.URL, &preview.Title, &preview.Description, &preview.Image, &preview.SiteName, &preview.Error, &preview.FetchedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar prévia de link: %v", err)
+	}
+
+	return preview, nil
+}
+
+// Save grava a prévia, substituindo a entrada anterior da mesma URL
+func (r *LinkPreviewRepository) Save(preview *models.LinkPreview) error {
+	query := `
+		INSERT INTO link_previews (url, title, description, image, site_name, error, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (url) DO UPDATE SET
+			title = excluded.title,
+			description = excluded.description,
+			image = excluded.image,
+			site_name = excluded.site_name,
+			error = excluded.error,
+			fetched_at = excluded.fetched_at
+	`
+
+	_, err := r.db.Exec(query, preview.URL, preview.Title, preview.Description, preview.Image, preview.SiteName, preview.Error, preview.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar prévia de link: %v", err)
+	}
+
+	return nil
+}
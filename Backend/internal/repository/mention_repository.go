@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+// mentionExists ignora menções cujas mensagens já foram removidas
+const mentionExists = `EXISTS (SELECT 1 FROM messages WHERE messages.id = mentions.message_id)`
+
+type MentionRepository struct {
+	db *sql.DB
+}
+
+func NewMentionRepository(db *sql.DB) *MentionRepository {
+	return &MentionRepository{db: db}
+}
+
+// CreateBatch grava as menções de uma mensagem; um usuário é mencionado no máximo uma vez por mensagem
+func (r *MentionRepository) CreateBatch(mentions []*models.Mention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO mentions (id, message_id, room_id, user_id, author_id, kind, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("erro ao preparar gravação de menções: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, m := range mentions {
+		if _, err := stmt.Exec(m.ID, m.MessageID, m.RoomID, m.UserID, m.AuthorID, m.Kind, m.CreatedAt); err != nil {
+			return fmt.Errorf("erro ao gravar menção: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar menções: %v", err)
+	}
+
+	return nil
+}
+
+// RoomParticipants retorna os usuários (ainda cadastrados) que já enviaram mensagens na sala
+func (r *MentionRepository) RoomParticipants(roomID string) ([]string, error) {
+	query := `
+		SELECT DISTINCT m.user_id FROM messages m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.room_id = ?
+	`
+
+	rows, err := r.db.Query(query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar participantes da sala: %v", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("erro ao escanear participante: %v", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// GetByUser lista as menções do usuário, da mais recente para a mais antiga, com a mensagem carregada
+func (r *MentionRepository) GetByUser(userID string, unreadOnly bool, limit, offset int) ([]*models.Mention, error) {
+	query := `
+		SELECT id, message_id, room_id, user_id, author_id, kind, created_at, read_at
+		FROM mentions WHERE user_id = ? AND ` + mentionExists
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar menções: %v", err)
+	}
+	defer rows.Close()
+
+	mentions := []*models.Mention{}
+	for rows.Next() {
+		mention := &models.Mention{}
+		var readAt sql.NullTime
+		err := rows.Scan(&mention.ID, &mention.MessageID, &mention.RoomID, &mention.UserID, &mention.AuthorID, &mention.Kind, &mention.CreatedAt, &readAt)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear menção: %v", err)
+		}
+		if readAt.Valid {
+			mention.ReadAt = &readAt.Time
+		}
+		mentions = append(mentions, mention)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(mentions) == 0 {
+		return mentions, nil
+	}
+
+	// Carrega as mensagens da página numa única consulta
+	placeholders := make([]string, len(mentions))
+	args := []interface{}{DeletedUserName}
+	for i, mention := range mentions {
+		placeholders[i] = "?"
+		args = append(args, mention.MessageID)
+	}
+
+	messageRows, err := r.db.Query(messageSelect+`WHERE m.id IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagens das menções: %v", err)
+	}
+	defer messageRows.Close()
+
+	messages, err := scanMessages(messageRows)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.Message, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+	for _, mention := range mentions {
+		mention.Message = byID[mention.MessageID]
+	}
+
+	return mentions, nil
+}
+
+// CountUnread conta as menções não lidas do usuário
+func (r *MentionRepository) CountUnread(userID string) (int, error) {
+	query := `SELECT COUNT(*) FROM mentions WHERE user_id = ? AND read_at IS NULL AND ` + mentionExists
+
+	var count int
+	if err := r.db.QueryRow(query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("erro ao contar menções não lidas: %v", err)
+	}
+
+	return count, nil
+}
+
+// MarkRead marca como lidas as menções informadas do usuário, ou todas quando ids é vazio
+func (r *MentionRepository) MarkRead(userID string, ids []string) (int64, error) {
+	query := `UPDATE mentions SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	args := []interface{}{time.Now().UTC(), userID}
+	query, args = appendIDFilter(query, args, ids)
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao marcar menções como lidas: %v", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -2,11 +2,77 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/rafael-bit/whatz/internal/models"
 )
 
+// DeletedUserName é exibido como autor das mensagens cujo usuário não existe mais
+const DeletedUserName = "Usuário removido"
+
+// messageSelect resolve nome e avatar do autor no momento da leitura. As colunas
+// username/avatar de messages ficam apenas como fallback para mensagens sem autor
+// (user_id vazio, ex.: mensagens de sistema).
+const messageSelect = `
+		SELECT m.id, m.content, m.user_id,
+			CASE WHEN u.id IS NOT NULL THEN u.username WHEN m.user_id = '' THEN m.username ELSE ? END,
+			CASE WHEN u.id IS NOT NULL THEN u.avatar WHEN m.user_id = '' THEN m.avatar ELSE '' END,
+			u.id IS NULL AND m.user_id <> '',
+			m.type, m.room_id, m.thread_id, m.entities, m.html, m.previews, m.created_at, m.updated_at
+		FROM messages m LEFT JOIN users u ON u.id = m.user_id
+	`
+
+func scanMessage(row rowScanner) (*models.Message, error) {
+	message := &models.Message{}
+	var entities, previews string
+	err := row.Scan(
+		&message.ID, &message.Content, &message.UserID, &message.Username, &message.Avatar, &message.AuthorDeleted, &message.Type, &message.RoomID, &message.ThreadID, &entities, &message.HTML, &previews, &message.CreatedAt, &message.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(previews), &message.Previews); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar prévias: %v", err)
+	}
+
+	// Mensagens gravadas antes da formatação não têm HTML salvo: renderiza na leitura
+	if message.HTML == "" && message.Content != "" {
+		message.SetContent(message.Content)
+		return message, nil
+	}
+	if err := json.Unmarshal([]byte(entities), &message.Entities); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar entidades: %v", err)
+	}
+	return message, nil
+}
+
+func scanMessages(rows *sql.Rows) ([]*models.Message, error) {
+	var messages []*models.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear mensagem: %v", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+func encodeEntities(message *models.Message) (string, error) {
+	if message.Entities == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(message.Entities)
+	if err != nil {
+		return "", fmt.Errorf("erro ao codificar entidades: %v", err)
+	}
+	return string(data), nil
+}
+
 type MessageRepository struct {
 	db *sql.DB
 }
@@ -17,11 +83,16 @@ func NewMessageRepository(db *sql.DB) *MessageRepository {
 
 func (r *MessageRepository) Create(message *models.Message) error {
 	query := `
-		INSERT INTO messages (id, content, user_id, username, avatar, type, room_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO messages (id, content, user_id, username, avatar, type, room_id, thread_id, entities, html, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query, message.ID, message.Content, message.UserID, message.Username, message.Avatar, message.Type, message.RoomID, message.CreatedAt, message.UpdatedAt)
+	entities, err := encodeEntities(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(query, message.ID, message.Content, message.UserID, message.Username, message.Avatar, message.Type, message.RoomID, message.ThreadID, entities, message.HTML, message.CreatedAt.UTC(), message.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("erro ao criar mensagem: %v", err)
 	}
@@ -30,16 +101,9 @@ func (r *MessageRepository) Create(message *models.Message) error {
 }
 
 func (r *MessageRepository) GetByID(id string) (*models.Message, error) {
-	query := `
-		SELECT id, content, user_id, username, avatar, type, room_id, created_at, updated_at
-		FROM messages WHERE id = ?
-	`
-
-	message := &models.Message{}
-	err := r.db.QueryRow(query, id).Scan(
-		&message.ID, &message.Content, &message.UserID, &message.Username, &message.Avatar, &message.Type, &message.RoomID, &message.CreatedAt, &message.UpdatedAt,
-	)
+	query := messageSelect + `WHERE m.id = ?`
 
+	message, err := scanMessage(r.db.QueryRow(query, DeletedUserName, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -51,94 +115,154 @@ func (r *MessageRepository) GetByID(id string) (*models.Message, error) {
 }
 
 func (r *MessageRepository) GetByRoom(roomID string, limit, offset int) ([]*models.Message, error) {
-	query := `
-		SELECT id, content, user_id, username, avatar, type, room_id, created_at, updated_at
-		FROM messages WHERE room_id = ? ORDER BY created_at ASC LIMIT ? OFFSET ?
-	`
+	query := messageSelect + `WHERE m.room_id = ? ORDER BY m.created_at ASC LIMIT ? OFFSET ?`
 
-	rows, err := r.db.Query(query, roomID, limit, offset)
+	rows, err := r.db.Query(query, DeletedUserName, roomID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar mensagens da sala: %v", err)
 	}
 	defer rows.Close()
 
-	var messages []*models.Message
-	for rows.Next() {
-		message := &models.Message{}
-		err := rows.Scan(
-			&message.ID, &message.Content, &message.UserID, &message.Username, &message.Avatar, &message.Type, &message.RoomID, &message.CreatedAt, &message.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear mensagem: %v", err)
-		}
-		messages = append(messages, message)
-	}
-
-	return messages, nil
+	return scanMessages(rows)
 }
 
 func (r *MessageRepository) GetRecentMessages(roomID string, limit int) ([]*models.Message, error) {
-	query := `
-		SELECT id, content, user_id, username, avatar, type, room_id, created_at, updated_at
-		FROM messages WHERE room_id = ? ORDER BY created_at ASC LIMIT ?
-	`
+	query := messageSelect + `WHERE m.room_id = ? ORDER BY m.created_at ASC LIMIT ?`
 
-	rows, err := r.db.Query(query, roomID, limit)
+	rows, err := r.db.Query(query, DeletedUserName, roomID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar mensagens recentes: %v", err)
 	}
 	defer rows.Close()
 
-	var messages []*models.Message
-	for rows.Next() {
-		message := &models.Message{}
-		err := rows.Scan(
-			&message.ID, &message.Content, &message.UserID, &message.Username, &message.Avatar, &message.Type, &message.RoomID, &message.CreatedAt, &message.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear mensagem: %v", err)
-		}
-		messages = append(messages, message)
+	return scanMessages(rows)
+}
+
+func (r *MessageRepository) GetByUser(userID string, limit, offset int) ([]*models.Message, error) {
+	query := messageSelect + `WHERE m.user_id = ? ORDER BY m.created_at DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, DeletedUserName, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagens do usuário: %v", err)
 	}
+	defer rows.Close()
 
-	return messages, nil
+	return scanMessages(rows)
 }
 
-func (r *MessageRepository) GetByUser(userID string, limit, offset int) ([]*models.Message, error) {
+// GetRoomPage retorna até limit mensagens da sala em ordem cronológica, depois do cursor
+// (created_at, id) da última mensagem lida (nil para começar do início) e dentro do período
+// [from, to] (limites opcionais). Cada página é uma consulta curta, sem cursor aberto entre
+// as páginas. created_at é gravado em UTC, então a comparação textual segue a cronológica.
+func (r *MessageRepository) GetRoomPage(roomID string, after *models.Message, from, to *time.Time, limit int) ([]*models.Message, error) {
+	query := messageSelect + `WHERE m.room_id = ?`
+	args := []interface{}{DeletedUserName, roomID}
+
+	if after != nil {
+		query += ` AND (m.created_at > ? OR (m.created_at = ? AND m.id > ?))`
+		args = append(args, after.CreatedAt.UTC(), after.CreatedAt.UTC(), after.ID)
+	}
+	if from != nil {
+		query += ` AND m.created_at >= ?`
+		args = append(args, from.UTC())
+	}
+	if to != nil {
+		query += ` AND m.created_at <= ?`
+		args = append(args, to.UTC())
+	}
+	query += ` ORDER BY m.created_at ASC, m.id ASC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagens da sala: %v", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func (r *MessageRepository) Update(message *models.Message) error {
 	query := `
-		SELECT id, content, user_id, username, avatar, type, room_id, created_at, updated_at
-		FROM messages WHERE user_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?
+		UPDATE messages SET content = ?, entities = ?, html = ?, updated_at = ? WHERE id = ?
 	`
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+	entities, err := encodeEntities(message)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar mensagens do usuário: %v", err)
+		return err
 	}
-	defer rows.Close()
 
-	var messages []*models.Message
-	for rows.Next() {
-		message := &models.Message{}
-		err := rows.Scan(
-			&message.ID, &message.Content, &message.UserID, &message.Username, &message.Avatar, &message.Type, &message.RoomID, &message.CreatedAt, &message.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear mensagem: %v", err)
-		}
-		messages = append(messages, message)
+	_, err = r.db.Exec(query, message.Content, entities, message.HTML, message.UpdatedAt, message.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar mensagem: %v", err)
 	}
 
-	return messages, nil
+	return nil
 }
 
+// SetPreviews substitui as prévias de links da mensagem
+func (r *MessageRepository) SetPreviews(id string, previews []models.LinkPreview) error {
+	if previews == nil {
+		previews = []models.LinkPreview{}
+	}
+	data, err := json.Marshal(previews)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar prévias: %v", err)
+	}
+
+	if _, err := r.db.Exec(`UPDATE messages SET previews = ? WHERE id = ?`, string(data), id); err != nil {
+		return fmt.Errorf("erro ao salvar prévias da mensagem: %v", err)
+	}
+
+	return nil
+}
+
+// Delete remove a mensagem, suas fixações, menções e cópias no log da sala numa única transação,
+// exceto se a sala ou o autor estiverem sob retenção legal (ErrLegalHold)
 func (r *MessageRepository) Delete(id string) error {
-	query := `DELETE FROM messages WHERE id = ?`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
 
-	_, err := r.db.Exec(query, id)
+	result, err := tx.Exec(`DELETE FROM messages WHERE id = ? AND `+messageNotHeld, id)
 	if err != nil {
 		return fmt.Errorf("erro ao deletar mensagem: %v", err)
 	}
 
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// Nada removido: a mensagem não existe ou está retida
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM messages WHERE id = ?)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("erro ao deletar mensagem: %v", err)
+		}
+		if exists {
+			return ErrLegalHold
+		}
+		return nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM pinned_messages WHERE message_id = ?`, id); err != nil {
+		return fmt.Errorf("erro ao desafixar mensagem removida: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM mentions WHERE message_id = ?`, id); err != nil {
+		return fmt.Errorf("erro ao remover menções da mensagem: %v", err)
+	}
+
+	// O conteúdo também sai do log da sala; o evento message_deleted publicado em seguida fica
+	if _, err := tx.Exec(`
+		DELETE FROM room_events
+		WHERE type IN ('new_message', 'message_edited', 'message_updated') AND json_extract(payload, '$.id') = ?
+	`, id); err != nil {
+		return fmt.Errorf("erro ao remover eventos da mensagem: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar remoção da mensagem: %v", err)
+	}
+
 	return nil
 }
 
@@ -153,3 +277,148 @@ func (r *MessageRepository) GetMessageCount(roomID string) (int, error) {
 
 	return count, nil
 }
+
+// BackfillAuthors sincroniza as colunas denormalizadas username/avatar com o cadastro
+// atual e marca com o placeholder as mensagens cujo autor foi removido
+func (r *MessageRepository) BackfillAuthors() (updated int64, orphaned int64, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE messages SET
+			username = (SELECT u.username FROM users u WHERE u.id = messages.user_id),
+			avatar = (SELECT u.avatar FROM users u WHERE u.id = messages.user_id)
+		WHERE EXISTS (
+			SELECT 1 FROM users u WHERE u.id = messages.user_id
+			AND (u.username <> messages.username OR u.avatar <> messages.avatar)
+		)
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao atualizar autores: %v", err)
+	}
+	updated, _ = result.RowsAffected()
+
+	result, err = tx.Exec(`
+		UPDATE messages SET username = ?, avatar = ''
+		WHERE user_id <> '' AND username <> ?
+		AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = messages.user_id)
+	`, DeletedUserName, DeletedUserName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao marcar autores removidos: %v", err)
+	}
+	orphaned, _ = result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("erro ao confirmar transação: %v", err)
+	}
+
+	return updated, orphaned, nil
+}
+
+// ExpiredBatch retorna, em ordem de rowid a partir de afterRowID, os IDs de até limit
+// mensagens da sala criadas antes de cutoff, e o rowid da última delas
+func (r *MessageRepository) ExpiredBatch(roomID string, afterRowID int64, cutoff time.Time, limit int) (ids []string, lastRowID int64, err error) {
+	rows, err := r.db.Query(`
+		SELECT rowid, id FROM messages
+		WHERE room_id = ? AND created_at < ? AND rowid > ? ORDER BY rowid LIMIT ?
+	`, roomID, cutoff.UTC(), afterRowID, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar mensagens expiradas: %v", err)
+	}
+	defer rows.Close()
+
+	lastRowID = afterRowID
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&lastRowID, &id); err != nil {
+			return nil, 0, fmt.Errorf("erro ao escanear mensagem: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, lastRowID, rows.Err()
+}
+
+// Purge remove as mensagens (copiando-as antes para messages_archive se archive for true),
+// seus pins e menções e as cópias no log de eventos da sala, em uma transação curta por
+// lote. Mensagens sob retenção legal são mantidas.
+func (r *MessageRepository) Purge(roomID string, ids []string, archive bool) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, 0, len(ids)+1)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Mensagens sob retenção legal (sala ou autor) ficam de fora do expurgo
+	rows, err := tx.Query(`SELECT id FROM messages WHERE id IN (`+placeholders+`) AND `+messageNotHeld, args...)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao verificar retenção legal: %v", err)
+	}
+	args = args[:0]
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("erro ao verificar retenção legal: %v", err)
+		}
+		args = append(args, id)
+	}
+	rows.Close()
+	if len(args) == 0 {
+		return 0, nil
+	}
+	placeholders = strings.TrimSuffix(strings.Repeat("?,", len(args)), ",")
+
+	if archive {
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO messages_archive
+				(id, content, user_id, username, avatar, type, room_id, thread_id, created_at, updated_at, archived_at)
+			SELECT id, content, user_id, username, avatar, type, room_id, thread_id, created_at, updated_at, ?
+			FROM messages WHERE id IN (`+placeholders+`)
+		`, append([]interface{}{time.Now().UTC()}, args...)...)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao arquivar mensagens: %v", err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM messages WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover mensagens: %v", err)
+	}
+	purged, _ := result.RowsAffected()
+
+	if _, err := tx.Exec(`DELETE FROM pinned_messages WHERE message_id IN (`+placeholders+`)`, args...); err != nil {
+		return 0, fmt.Errorf("erro ao desafixar mensagens removidas: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM mentions WHERE message_id IN (`+placeholders+`)`, args...); err != nil {
+		return 0, fmt.Errorf("erro ao remover menções das mensagens: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM room_events
+		WHERE room_id = ? AND type IN ('new_message', 'message_edited', 'message_updated') AND json_extract(payload, '$.id') IN (`+placeholders+`)
+	`, append([]interface{}{roomID}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover eventos das mensagens: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %v", err)
+	}
+
+	return purged, nil
+}
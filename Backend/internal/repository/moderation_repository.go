@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+const moderationFlagSelect = `
+		SELECT id, message_id, room_id, user_id, rule_id, reason, status, created_at, reviewed_by, reviewed_at
+		FROM moderation_flags
+	`
+
+func scanModerationFlag(row rowScanner) (*models.ModerationFlag, error) {
+	flag := &models.ModerationFlag{}
+	var reviewedAt sql.NullTime
+	err := row.Scan(
+		&flag.ID, &flag.MessageID, &flag.RoomID, &flag.UserID, &flag.RuleID, &flag.Reason, &flag.Status, &flag.CreatedAt, &flag.ReviewedBy, &reviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedAt.Valid {
+		flag.ReviewedAt = &reviewedAt.Time
+	}
+	return flag, nil
+}
+
+// ModerationRepository guarda as regras de moderação das salas e a fila de revisão
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+func NewModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+func (r *ModerationRepository) CreateRule(rule *models.ModerationRule) error {
+	query := `
+		INSERT INTO moderation_rules (id, room_id, kind, pattern, action, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, rule.ID, rule.RoomID, rule.Kind, rule.Pattern, rule.Action, rule.CreatedBy, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar regra de moderação: %v", err)
+	}
+
+	return nil
+}
+
+// GetRules retorna as regras da sala na ordem de criação
+func (r *ModerationRepository) GetRules(roomID string) ([]*models.ModerationRule, error) {
+	query := `
+		SELECT id, room_id, kind, pattern, action, created_by, created_at
+		FROM moderation_rules WHERE room_id = ? ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de moderação: %v", err)
+	}
+	defer rows.Close()
+
+	rules := []*models.ModerationRule{}
+	for rows.Next() {
+		rule := &models.ModerationRule{}
+		if err := rows.Scan(&rule.ID, &rule.RoomID, &rule.Kind, &rule.Pattern, &rule.Action, &rule.CreatedBy, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear regra de moderação: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteRule remove a regra da sala; retorna false se ela não existir
+func (r *ModerationRepository) DeleteRule(roomID, id string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM moderation_rules WHERE id = ? AND room_id = ?`, id, roomID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover regra de moderação: %v", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (r *ModerationRepository) CreateFlag(flag *models.ModerationFlag) error {
+	query := `
+		INSERT INTO moderation_flags (id, message_id, room_id, user_id, rule_id, reason, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, flag.ID, flag.MessageID, flag.RoomID, flag.UserID, flag.RuleID, flag.Reason, flag.Status, flag.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao sinalizar mensagem: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ModerationRepository) GetFlag(id string) (*models.ModerationFlag, error) {
+	flag, err := scanModerationFlag(r.db.QueryRow(moderationFlagSelect+`WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar sinalização: %v", err)
+	}
+
+	return flag, nil
+}
+
+// GetFlags lista as sinalizações, das mais recentes para as mais antigas; status vazio traz todas
+func (r *ModerationRepository) GetFlags(status string, limit, offset int) ([]*models.ModerationFlag, error) {
+	query := moderationFlagSelect + `WHERE ? = '' OR status = ? ORDER BY created_at DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, status, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar sinalizações: %v", err)
+	}
+	defer rows.Close()
+
+	flags := []*models.ModerationFlag{}
+	for rows.Next() {
+		flag, err := scanModerationFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear sinalização: %v", err)
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, rows.Err()
+}
+
+// ResolveFlag encerra a revisão; retorna false se a sinalização não existir ou já estiver resolvida
+func (r *ModerationRepository) ResolveFlag(id, reviewedBy string, at time.Time) (bool, error) {
+	query := `
+		UPDATE moderation_flags SET status = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE id = ? AND status = ?
+	`
+
+	result, err := r.db.Exec(query, models.ModerationFlagResolved, reviewedBy, at, id, models.ModerationFlagPending)
+	if err != nil {
+		return false, fmt.Errorf("erro ao resolver sinalização: %v", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+const notificationSelect = `
+		SELECT id, user_id, type, title, body, room_id, message_id, actor_id, created_at, read_at
+		FROM notifications
+	`
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	notification := &models.Notification{}
+	var readAt sql.NullTime
+	err := row.Scan(
+		&notification.ID, &notification.UserID, &notification.Type, &notification.Title, &notification.Body,
+		&notification.RoomID, &notification.MessageID, &notification.ActorID, &notification.CreatedAt, &readAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+	return notification, nil
+}
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) Create(n *models.Notification) error {
+	query := `
+		INSERT INTO notifications (id, user_id, type, title, body, room_id, message_id, actor_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, n.ID, n.UserID, n.Type, n.Title, n.Body, n.RoomID, n.MessageID, n.ActorID, n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar notificação: %v", err)
+	}
+
+	return nil
+}
+
+// GetByUser lista as notificações do usuário, da mais recente para a mais antiga
+func (r *NotificationRepository) GetByUser(userID string, unreadOnly bool, limit, offset int) ([]*models.Notification, error) {
+	query := notificationSelect + `WHERE user_id = ?`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar notificações: %v", err)
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear notificação: %v", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// CountUnread conta as notificações não lidas do usuário
+func (r *NotificationRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao contar notificações não lidas: %v", err)
+	}
+
+	return count, nil
+}
+
+// MarkRead marca como lidas as notificações informadas do usuário, ou todas quando ids é vazio
+func (r *NotificationRepository) MarkRead(userID string, ids []string) (int64, error) {
+	query := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	args := []interface{}{time.Now().UTC(), userID}
+	query, args = appendIDFilter(query, args, ids)
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao marcar notificações como lidas: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Clear remove notificações do usuário: as informadas em ids, ou todas (apenas as lidas, se readOnly)
+func (r *NotificationRepository) Clear(userID string, ids []string, readOnly bool) (int64, error) {
+	query := `DELETE FROM notifications WHERE user_id = ?`
+	args := []interface{}{userID}
+	if readOnly {
+		query += ` AND read_at IS NOT NULL`
+	}
+	query, args = appendIDFilter(query, args, ids)
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao limpar notificações: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// GetPreferences retorna as preferências gravadas do usuário (tipo -> habilitado)
+func (r *NotificationRepository) GetPreferences(userID string) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT type, enabled FROM notification_preferences WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar preferências de notificação: %v", err)
+	}
+	defer rows.Close()
+
+	preferences := make(map[string]bool)
+	for rows.Next() {
+		var notificationType string
+		var enabled bool
+		if err := rows.Scan(&notificationType, &enabled); err != nil {
+			return nil, fmt.Errorf("erro ao escanear preferência: %v", err)
+		}
+		preferences[notificationType] = enabled
+	}
+
+	return preferences, rows.Err()
+}
+
+// IsEnabled indica se o usuário recebe notificações do tipo; sem preferência gravada, recebe
+func (r *NotificationRepository) IsEnabled(userID, notificationType string) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRow(`
+		SELECT enabled FROM notification_preferences WHERE user_id = ? AND type = ?
+	`, userID, notificationType).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar preferência de notificação: %v", err)
+	}
+
+	return enabled, nil
+}
+
+// SetPreferences grava as preferências informadas, mantendo as demais
+func (r *NotificationRepository) SetPreferences(userID string, preferences map[string]bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	for notificationType, enabled := range preferences {
+		_, err := tx.Exec(`
+			INSERT INTO notification_preferences (user_id, type, enabled, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (user_id, type) DO UPDATE SET enabled = excluded.enabled, updated_at = excluded.updated_at
+		`, userID, notificationType, enabled, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("erro ao gravar preferência de notificação: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar preferências: %v", err)
+	}
+
+	return nil
+}
+
+// appendIDFilter restringe a consulta aos ids informados (nenhum filtro quando vazio)
+func appendIDFilter(query string, args []interface{}, ids []string) (string, []interface{}) {
+	if len(ids) == 0 {
+		return query, args
+	}
+
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	return query + ` AND id IN (` + strings.Join(placeholders, ", ") + `)`, args
+}
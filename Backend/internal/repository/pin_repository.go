@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+// pinExists ignora fixações cujas mensagens já foram removidas (retenção, remoção de conta etc.)
+const pinExists = `EXISTS (SELECT 1 FROM messages WHERE messages.id = pinned_messages.message_id)`
+
+type PinRepository struct {
+	db *sql.DB
+}
+
+func NewPinRepository(db *sql.DB) *PinRepository {
+	return &PinRepository{db: db}
+}
+
+// Create fixa a mensagem se a sala ainda tiver menos de limit fixações.
+// Retorna false quando o limite foi atingido.
+func (r *PinRepository) Create(pin *models.PinnedMessage, limit int) (bool, error) {
+	query := `
+		INSERT INTO pinned_messages (room_id, message_id, pinned_by, pinned_at)
+		SELECT ?, ?, ?, ?
+		WHERE (SELECT COUNT(*) FROM pinned_messages WHERE room_id = ? AND ` + pinExists + `) < ?
+	`
+
+	result, err := r.db.Exec(query, pin.RoomID, pin.MessageID, pin.PinnedBy, pin.PinnedAt, pin.RoomID, limit)
+	if err != nil {
+		return false, fmt.Errorf("erro ao fixar mensagem: %v", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// Get retorna a fixação da mensagem na sala, sem a mensagem, ou nil se não estiver fixada
+func (r *PinRepository) Get(roomID, messageID string) (*models.PinnedMessage, error) {
+	query := `
+		SELECT room_id, message_id, pinned_by, pinned_at FROM pinned_messages
+		WHERE room_id = ? AND message_id = ? AND ` + pinExists
+
+	pin := &models.PinnedMessage{}
+	err := r.db.QueryRow(query, roomID, messageID).Scan(&pin.RoomID, &pin.MessageID, &pin.PinnedBy, &pin.PinnedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar mensagem fixada: %v", err)
+	}
+
+	return pin, nil
+}
+
+// GetByRoom lista as mensagens fixadas da sala, da fixação mais recente para a mais antiga
+func (r *PinRepository) GetByRoom(roomID string) ([]*models.PinnedMessage, error) {
+	query := `
+		SELECT room_id, message_id, pinned_by, pinned_at FROM pinned_messages
+		WHERE room_id = ? AND ` + pinExists + `
+		ORDER BY pinned_at DESC
+	`
+
+	rows, err := r.db.Query(query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagens fixadas: %v", err)
+	}
+	defer rows.Close()
+
+	pins := []*models.PinnedMessage{}
+	for rows.Next() {
+		pin := &models.PinnedMessage{}
+		if err := rows.Scan(&pin.RoomID, &pin.MessageID, &pin.PinnedBy, &pin.PinnedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear mensagem fixada: %v", err)
+		}
+		pins = append(pins, pin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(pins) == 0 {
+		return pins, nil
+	}
+
+	// Carrega as mensagens numa única consulta, com o autor resolvido como nas demais leituras
+	messageQuery := messageSelect + `WHERE m.id IN (SELECT message_id FROM pinned_messages WHERE room_id = ?)`
+	messageRows, err := r.db.Query(messageQuery, DeletedUserName, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagens fixadas: %v", err)
+	}
+	defer messageRows.Close()
+
+	messages, err := scanMessages(messageRows)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.Message, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+	for _, pin := range pins {
+		pin.Message = byID[pin.MessageID]
+	}
+
+	return pins, nil
+}
+
+// Delete desafixa a mensagem; retorna false se ela não estava fixada
+func (r *PinRepository) Delete(roomID, messageID string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM pinned_messages WHERE room_id = ? AND message_id = ?`, roomID, messageID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao desafixar mensagem: %v", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
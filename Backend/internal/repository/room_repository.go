@@ -9,6 +9,43 @@ import (
 	"github.com/rafael-bit/whatz/internal/models"
 )
 
+const roomSelect = `
+		SELECT id, name, description, type, access_tags, created_by, retention_days, archived_at, created_at, updated_at
+		FROM rooms
+	`
+
+func scanRoom(row rowScanner) (*models.Room, error) {
+	room := &models.Room{}
+	var retentionDays sql.NullInt64
+	var archivedAt sql.NullTime
+	err := row.Scan(
+		&room.ID, &room.Name, &room.Description, &room.Type, &room.AccessTags, &room.CreatedBy, &retentionDays, &archivedAt, &room.CreatedAt, &room.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if retentionDays.Valid {
+		days := int(retentionDays.Int64)
+		room.RetentionDays = &days
+	}
+	if archivedAt.Valid {
+		room.ArchivedAt = &archivedAt.Time
+	}
+	return room, nil
+}
+
+func scanRooms(rows *sql.Rows) ([]*models.Room, error) {
+	var rooms []*models.Room
+	for rows.Next() {
+		room, err := scanRoom(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear sala: %v", err)
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
 type RoomRepository struct {
 	db *sql.DB
 }
@@ -32,16 +69,9 @@ func (r *RoomRepository) Create(room *models.Room) error {
 }
 
 func (r *RoomRepository) GetByID(id string) (*models.Room, error) {
-	query := `
-		SELECT id, name, description, type, access_tags, created_by, created_at, updated_at
-		FROM rooms WHERE id = ?
-	`
-
-	room := &models.Room{}
-	err := r.db.QueryRow(query, id).Scan(
-		&room.ID, &room.Name, &room.Description, &room.Type, &room.AccessTags, &room.CreatedBy, &room.CreatedAt, &room.UpdatedAt,
-	)
+	query := roomSelect + `WHERE id = ?`
 
+	room, err := scanRoom(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -53,10 +83,7 @@ func (r *RoomRepository) GetByID(id string) (*models.Room, error) {
 }
 
 func (r *RoomRepository) GetAll() ([]*models.Room, error) {
-	query := `
-		SELECT id, name, description, type, access_tags, created_by, created_at, updated_at
-		FROM rooms ORDER BY name
-	`
+	query := roomSelect + `ORDER BY name`
 
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -64,26 +91,11 @@ func (r *RoomRepository) GetAll() ([]*models.Room, error) {
 	}
 	defer rows.Close()
 
-	var rooms []*models.Room
-	for rows.Next() {
-		room := &models.Room{}
-		err := rows.Scan(
-			&room.ID, &room.Name, &room.Description, &room.Type, &room.AccessTags, &room.CreatedBy, &room.CreatedAt, &room.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear sala: %v", err)
-		}
-		rooms = append(rooms, room)
-	}
-
-	return rooms, nil
+	return scanRooms(rows)
 }
 
 func (r *RoomRepository) GetPublicRooms() ([]*models.Room, error) {
-	query := `
-		SELECT id, name, description, type, access_tags, created_by, created_at, updated_at
-		FROM rooms WHERE type = 'public' ORDER BY name
-	`
+	query := roomSelect + `WHERE type = 'public' ORDER BY name`
 
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -91,26 +103,11 @@ func (r *RoomRepository) GetPublicRooms() ([]*models.Room, error) {
 	}
 	defer rows.Close()
 
-	var rooms []*models.Room
-	for rows.Next() {
-		room := &models.Room{}
-		err := rows.Scan(
-			&room.ID, &room.Name, &room.Description, &room.Type, &room.AccessTags, &room.CreatedBy, &room.CreatedAt, &room.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear sala: %v", err)
-		}
-		rooms = append(rooms, room)
-	}
-
-	return rooms, nil
+	return scanRooms(rows)
 }
 
 func (r *RoomRepository) GetByCreator(createdBy string) ([]*models.Room, error) {
-	query := `
-		SELECT id, name, description, type, access_tags, created_by, created_at, updated_at
-		FROM rooms WHERE created_by = ? ORDER BY created_at DESC
-	`
+	query := roomSelect + `WHERE created_by = ? ORDER BY created_at DESC`
 
 	rows, err := r.db.Query(query, createdBy)
 	if err != nil {
@@ -118,19 +115,7 @@ func (r *RoomRepository) GetByCreator(createdBy string) ([]*models.Room, error)
 	}
 	defer rows.Close()
 
-	var rooms []*models.Room
-	for rows.Next() {
-		room := &models.Room{}
-		err := rows.Scan(
-			&room.ID, &room.Name, &room.Description, &room.Type, &room.AccessTags, &room.CreatedBy, &room.CreatedAt, &room.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao escanear sala: %v", err)
-		}
-		rooms = append(rooms, room)
-	}
-
-	return rooms, nil
+	return scanRooms(rows)
 }
 
 func (r *RoomRepository) Update(room *models.Room) error {
@@ -146,14 +131,59 @@ func (r *RoomRepository) Update(room *models.Room) error {
 	return nil
 }
 
+// SetArchived arquiva a sala em archivedAt ou, com nil, a reativa
+func (r *RoomRepository) SetArchived(id string, archivedAt *time.Time) error {
+	var value interface{}
+	if archivedAt != nil {
+		value = *archivedAt
+	}
+
+	_, err := r.db.Exec(`UPDATE rooms SET archived_at = ?, updated_at = ? WHERE id = ?`, value, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("erro ao arquivar sala: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateRetention define a retenção da sala em dias; nil volta a usar a política global
+func (r *RoomRepository) UpdateRetention(id string, retentionDays *int) error {
+	var value interface{}
+	if retentionDays != nil {
+		value = *retentionDays
+	}
+
+	_, err := r.db.Exec(`UPDATE rooms SET retention_days = ?, updated_at = ? WHERE id = ?`, value, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar retenção da sala: %v", err)
+	}
+
+	return nil
+}
+
+// Delete remove a sala, exceto se ela estiver sob retenção legal (ErrLegalHold)
 func (r *RoomRepository) Delete(id string) error {
-	query := `DELETE FROM rooms WHERE id = ?`
+	query := `
+		DELETE FROM rooms WHERE id = ? AND NOT EXISTS (
+			SELECT 1 FROM legal_holds WHERE scope = 'room' AND target_id = rooms.id AND released_at IS NULL
+		)
+	`
 
-	_, err := r.db.Exec(query, id)
+	result, err := r.db.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("erro ao deletar sala: %v", err)
 	}
 
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		held, err := isHeld(r.db, models.LegalHoldScopeRoom, id)
+		if err != nil {
+			return err
+		}
+		if held {
+			return ErrLegalHold
+		}
+	}
+
 	return nil
 }
 
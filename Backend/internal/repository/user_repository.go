@@ -2,7 +2,9 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rafael-bit/whatz/internal/models"
@@ -16,15 +18,46 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+var (
+	ErrUsernameTaken = errors.New("nome de usuário já está em uso")
+	ErrEmailTaken    = errors.New("email já está em uso")
+)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	var lastSeenAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.Avatar, &user.Status, &user.Role, &user.Tags, &user.Kind, &lastSeenAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastSeenAt.Valid {
+		user.LastSeenAt = &lastSeenAt.Time
+	}
+
+	return user, nil
+}
+
 func (r *UserRepository) Create(user *models.User) error {
 	query := `
-		INSERT INTO users (id, username, email, avatar, status, role, tags, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (id, username, email, avatar, status, role, tags, kind, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query, user.ID, user.Username, user.Email, user.Avatar, user.Status, user.Role, user.Tags, user.CreatedAt, user.UpdatedAt)
+	if user.Kind == "" {
+		user.Kind = models.UserKindHuman
+	}
+
+	_, err := r.db.Exec(query, user.ID, user.Username, user.Email, user.Avatar, user.Status, user.Role, user.Tags, user.Kind, user.CreatedAt, user.UpdatedAt)
 	if err != nil {
-		return fmt.Errorf("erro ao criar usuário: %v", err)
+		return uniqueViolation(err, "erro ao criar usuário")
 	}
 
 	return nil
@@ -32,15 +65,11 @@ func (r *UserRepository) Create(user *models.User) error {
 
 func (r *UserRepository) GetByID(id string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, avatar, status, role, tags, created_at, updated_at
+		SELECT id, username, email, avatar, status, role, tags, kind, last_seen_at, created_at, updated_at
 		FROM users WHERE id = ?
 	`
 
-	user := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Avatar, &user.Status, &user.Role, &user.Tags, &user.CreatedAt, &user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -53,15 +82,28 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
 
 func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, avatar, status, role, tags, created_at, updated_at
+		SELECT id, username, email, avatar, status, role, tags, kind, last_seen_at, created_at, updated_at
 		FROM users WHERE username = ?
 	`
 
-	user := &models.User{}
-	err := r.db.QueryRow(query, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Avatar, &user.Status, &user.Role, &user.Tags, &user.CreatedAt, &user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar usuário: %v", err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+	query := `
+		SELECT id, username, email, avatar, status, role, tags, kind, last_seen_at, created_at, updated_at
+		FROM users WHERE email = ?
+	`
 
+	user, err := scanUser(r.db.QueryRow(query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -74,7 +116,7 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 
 func (r *UserRepository) GetAll() ([]*models.User, error) {
 	query := `
-		SELECT id, username, email, avatar, status, role, tags, created_at, updated_at
+		SELECT id, username, email, avatar, status, role, tags, kind, last_seen_at, created_at, updated_at
 		FROM users ORDER BY username
 	`
 
@@ -86,10 +128,7 @@ func (r *UserRepository) GetAll() ([]*models.User, error) {
 
 	var users []*models.User
 	for rows.Next() {
-		user := &models.User{}
-		err := rows.Scan(
-			&user.ID, &user.Username, &user.Email, &user.Avatar, &user.Status, &user.Role, &user.Tags, &user.CreatedAt, &user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear usuário: %v", err)
 		}
@@ -112,17 +151,233 @@ func (r *UserRepository) UpdateStatus(id, status string) error {
 	return nil
 }
 
-func (r *UserRepository) Delete(id string) error {
-	query := `DELETE FROM users WHERE id = ?`
+// Update grava os dados de perfil do usuário
+func (r *UserRepository) Update(user *models.User) error {
+	query := `
+		UPDATE users SET username = ?, email = ?, avatar = ?, status = ?, role = ?, tags = ?, updated_at = ? WHERE id = ?
+	`
+
+	_, err := r.db.Exec(query, user.Username, user.Email, user.Avatar, user.Status, user.Role, user.Tags, user.UpdatedAt, user.ID)
+	if err != nil {
+		return uniqueViolation(err, "erro ao atualizar usuário")
+	}
+
+	return nil
+}
+
+// uniqueViolation traduz violações de UNIQUE em users para erros conhecidos
+func uniqueViolation(err error, context string) error {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "UNIQUE constraint failed: users.username"):
+		return ErrUsernameTaken
+	case strings.Contains(message, "UNIQUE constraint failed: users.email"):
+		return ErrEmailTaken
+	}
+	return fmt.Errorf("%s: %v", context, err)
+}
+
+// UpdatePresence grava o status de presença e o momento da última atividade
+func (r *UserRepository) UpdatePresence(id, status string, lastSeenAt time.Time) error {
+	query := `
+		UPDATE users SET status = ?, last_seen_at = ? WHERE id = ?
+	`
 
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.Exec(query, status, lastSeenAt, id)
 	if err != nil {
-		return fmt.Errorf("erro ao deletar usuário: %v", err)
+		return fmt.Errorf("erro ao atualizar presença do usuário: %v", err)
 	}
 
 	return nil
 }
 
+// Políticas aplicadas às mensagens de uma conta removida
+const (
+	MessagePolicyAnonymize = "anonymize"
+	MessagePolicyDelete    = "delete"
+)
+
+// DeletedUserID substitui o autor das mensagens anonimizadas; não existe usuário
+// com esse ID, então a leitura exibe DeletedUserName
+const DeletedUserID = "deleted-user"
+
+var ErrNoRoomHeir = errors.New("nenhum usuário disponível para receber as salas")
+
+// AccountDeletion define o que fazer com os dados ligados à conta removida
+type AccountDeletion struct {
+	MessagePolicy string // anonymize ou delete
+	ReassignTo    string // novo dono das salas; vazio usa o admin mais antigo
+}
+
+// AccountDeletionResult resume o que foi alterado na remoção da conta
+type AccountDeletionResult struct {
+	MessagePolicy     string `json:"message_policy"`
+	MessagesAffected  int64  `json:"messages_affected"`
+	RoomsReassigned   int64  `json:"rooms_reassigned"`
+	RoomsReassignedTo string `json:"rooms_reassigned_to,omitempty"`
+	// Arquivos das exportações do usuário, a apagar depois da remoção
+	ExportFiles []string `json:"-"`
+}
+
+// DeleteAccount remove o usuário em uma única transação: aplica a política às mensagens
+// (e às cópias no log de eventos das salas), transfere as salas criadas por ele, remove
+// as exportações e por fim apaga o cadastro. Qualquer falha desfaz tudo.
+// Retorna ErrLegalHold se o usuário ou suas salas estiverem sob retenção legal.
+func (r *UserRepository) DeleteAccount(id string, opts AccountDeletion) (*AccountDeletionResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Retenção legal sobre o usuário, sobre salas onde ele escreveu ou sobre salas dele bloqueia a remoção
+	var held bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM legal_holds h
+			WHERE h.released_at IS NULL AND (
+				(h.scope = 'user' AND h.target_id = ?)
+				OR (h.scope = 'room' AND h.target_id IN (
+					SELECT room_id FROM messages WHERE user_id = ?
+					UNION SELECT id FROM rooms WHERE created_by = ?
+				))
+			)
+		)
+	`, id, id, id).Scan(&held)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar retenção legal: %v", err)
+	}
+	if held {
+		return nil, ErrLegalHold
+	}
+
+	result := &AccountDeletionResult{MessagePolicy: opts.MessagePolicy}
+
+	switch opts.MessagePolicy {
+	case MessagePolicyDelete:
+		// Fixações e menções (de outros usuários) que apontam para as mensagens removidas
+		for _, table := range []string{"pinned_messages", "mentions"} {
+			_, err := tx.Exec(`DELETE FROM `+table+` WHERE message_id IN (SELECT id FROM messages WHERE user_id = ?)`, id)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao remover referências às mensagens do usuário: %v", err)
+			}
+		}
+
+		_, err = tx.Exec(`
+			DELETE FROM room_events
+			WHERE type IN ('new_message', 'message_edited', 'message_updated') AND (
+				json_extract(payload, '$.user_id') = ?
+				OR json_extract(payload, '$.id') IN (SELECT id FROM messages WHERE user_id = ?)
+			)
+		`, id, id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao remover eventos do usuário: %v", err)
+		}
+
+		res, err := tx.Exec(`DELETE FROM messages WHERE user_id = ?`, id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao remover mensagens do usuário: %v", err)
+		}
+		result.MessagesAffected, _ = res.RowsAffected()
+
+	case MessagePolicyAnonymize:
+		res, err := tx.Exec(`
+			UPDATE messages SET user_id = ?, username = ?, avatar = '' WHERE user_id = ?
+		`, DeletedUserID, DeletedUserName, id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao anonimizar mensagens do usuário: %v", err)
+		}
+		result.MessagesAffected, _ = res.RowsAffected()
+
+		_, err = tx.Exec(`
+			UPDATE room_events
+			SET payload = json_set(payload, '$.user_id', ?, '$.username', ?, '$.avatar', '')
+			WHERE type IN ('new_message', 'message_edited', 'message_updated') AND json_extract(payload, '$.user_id') = ?
+		`, DeletedUserID, DeletedUserName, id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao anonimizar eventos do usuário: %v", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("política de mensagens desconhecida: %s", opts.MessagePolicy)
+	}
+
+	var ownedRooms int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM rooms WHERE created_by = ?`, id).Scan(&ownedRooms); err != nil {
+		return nil, fmt.Errorf("erro ao contar salas do usuário: %v", err)
+	}
+
+	if ownedRooms > 0 {
+		heir := opts.ReassignTo
+		if heir == "" {
+			err := tx.QueryRow(`
+				SELECT id FROM users WHERE role = 'admin' AND id <> ? ORDER BY created_at ASC LIMIT 1
+			`, id).Scan(&heir)
+			if err == sql.ErrNoRows {
+				return nil, ErrNoRoomHeir
+			}
+			if err != nil {
+				return nil, fmt.Errorf("erro ao buscar administrador: %v", err)
+			}
+		}
+
+		res, err := tx.Exec(`UPDATE rooms SET created_by = ?, updated_at = ? WHERE created_by = ?`, heir, time.Now(), id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao transferir salas do usuário: %v", err)
+		}
+		result.RoomsReassigned, _ = res.RowsAffected()
+		result.RoomsReassignedTo = heir
+	}
+
+	// A caixa de menções e as notificações do usuário deixam de existir com a conta
+	if _, err := tx.Exec(`DELETE FROM mentions WHERE user_id = ?`, id); err != nil {
+		return nil, fmt.Errorf("erro ao remover menções do usuário: %v", err)
+	}
+
+	for _, table := range []string{"notifications", "notification_preferences"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE user_id = ?`, id); err != nil {
+			return nil, fmt.Errorf("erro ao remover notificações do usuário: %v", err)
+		}
+	}
+
+	// Exportações de dados pessoais: os arquivos são apagados depois da confirmação
+	rows, err := tx.Query(`SELECT file_path FROM data_exports WHERE user_id = ? AND file_path <> ''`, id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar exportações do usuário: %v", err)
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao escanear exportação: %v", err)
+		}
+		result.ExportFiles = append(result.ExportFiles, path)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(`DELETE FROM data_exports WHERE user_id = ?`, id); err != nil {
+		return nil, fmt.Errorf("erro ao remover exportações do usuário: %v", err)
+	}
+
+	// Tokens de API e comandos registrados não sobrevivem à conta
+	if _, err := tx.Exec(`DELETE FROM api_tokens WHERE user_id = ?`, id); err != nil {
+		return nil, fmt.Errorf("erro ao remover tokens do usuário: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM slash_commands WHERE bot_id = ?`, id); err != nil {
+		return nil, fmt.Errorf("erro ao remover comandos do usuário: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("erro ao deletar usuário: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %v", err)
+	}
+
+	return result, nil
+}
+
 func (r *UserRepository) UpdateTags(id, tags string) error {
 	query := `
 		UPDATE users SET tags = ?, updated_at = ? WHERE id = ?
@@ -151,7 +406,7 @@ func (r *UserRepository) UpdateRole(id, role string) error {
 
 func (r *UserRepository) GetByRole(role string) ([]*models.User, error) {
 	query := `
-		SELECT id, username, email, avatar, status, role, tags, created_at, updated_at
+		SELECT id, username, email, avatar, status, role, tags, kind, last_seen_at, created_at, updated_at
 		FROM users WHERE role = ? ORDER BY username
 	`
 
@@ -163,10 +418,7 @@ func (r *UserRepository) GetByRole(role string) ([]*models.User, error) {
 
 	var users []*models.User
 	for rows.Next() {
-		user := &models.User{}
-		err := rows.Scan(
-			&user.ID, &user.Username, &user.Email, &user.Avatar, &user.Status, &user.Role, &user.Tags, &user.CreatedAt, &user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear usuário: %v", err)
 		}
@@ -175,3 +427,27 @@ func (r *UserRepository) GetByRole(role string) ([]*models.User, error) {
 
 	return users, nil
 }
+
+func (r *UserRepository) GetByKind(kind string) ([]*models.User, error) {
+	query := `
+		SELECT id, username, email, avatar, status, role, tags, kind, last_seen_at, created_at, updated_at
+		FROM users WHERE kind = ? ORDER BY username
+	`
+
+	rows, err := r.db.Query(query, kind)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuários por tipo: %v", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear usuário: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
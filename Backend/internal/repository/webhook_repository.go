@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+const webhookSelect = `
+		SELECT id, url, secret, event_types, room_id, active, created_by, created_at, updated_at
+		FROM webhooks
+	`
+
+// next_attempt_at é gravado em segundos Unix (UTC) para que a fila possa ser ordenada e filtrada no SQL
+const webhookDeliverySelect = `
+		SELECT id, webhook_id, event_type, room_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+	`
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	err := row.Scan(
+		&webhook.ID, &webhook.URL, &webhook.Secret, &webhook.EventTypes, &webhook.RoomID, &webhook.Active, &webhook.CreatedBy, &webhook.CreatedAt, &webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	var nextAttempt sql.NullInt64
+	var deliveredAt sql.NullTime
+	err := row.Scan(
+		&delivery.ID, &delivery.WebhookID, &delivery.EventType, &delivery.RoomID, &delivery.Payload, &delivery.Status,
+		&delivery.Attempts, &nextAttempt, &delivery.LastError, &delivery.CreatedAt, &deliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if nextAttempt.Valid {
+		next := time.Unix(nextAttempt.Int64, 0).UTC()
+		delivery.NextAttempt = &next
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, url, secret, event_types, room_id, active, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, webhook.ID, webhook.URL, webhook.Secret, webhook.EventTypes, webhook.RoomID, webhook.Active, webhook.CreatedBy, webhook.CreatedAt, webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar webhook: %v", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(id string) (*models.Webhook, error) {
+	webhook, err := scanWebhook(r.db.QueryRow(webhookSelect+`WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar webhook: %v", err)
+	}
+
+	return webhook, nil
+}
+
+// GetAll lista os webhooks; com activeOnly, apenas os que recebem eventos
+func (r *WebhookRepository) GetAll(activeOnly bool) ([]*models.Webhook, error) {
+	query := webhookSelect
+	if activeOnly {
+		query += `WHERE active = 1 `
+	}
+	query += `ORDER BY created_at`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar webhooks: %v", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear webhook: %v", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) Update(webhook *models.Webhook) error {
+	query := `
+		UPDATE webhooks SET url = ?, event_types = ?, room_id = ?, active = ?, updated_at = ? WHERE id = ?
+	`
+
+	webhook.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(query, webhook.URL, webhook.EventTypes, webhook.RoomID, webhook.Active, webhook.UpdatedAt, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar webhook: %v", err)
+	}
+
+	return nil
+}
+
+// Delete remove o webhook junto com sua fila e histórico de tentativas
+func (r *WebhookRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = ?)`,
+		`DELETE FROM webhook_deliveries WHERE webhook_id = ?`,
+		`DELETE FROM webhooks WHERE id = ?`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, id); err != nil {
+			return fmt.Errorf("erro ao deletar webhook: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %v", err)
+	}
+
+	return nil
+}
+
+// OutboxEvents retorna até limit eventos do outbox, na ordem em que foram gravados, e o id do
+// último registro lido. Eventos já removidos do log da sala (ex.: expurgo) são ignorados.
+func (r *WebhookRepository) OutboxEvents(limit int) ([]*models.RoomEvent, int64, error) {
+	query := `
+		SELECT o.id, e.room_id, e.seq, e.type, e.payload, e.created_at
+		FROM room_event_outbox o
+		JOIN room_events e ON e.room_id = o.room_id AND e.seq = o.seq
+		ORDER BY o.id LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar eventos do outbox: %v", err)
+	}
+	defer rows.Close()
+
+	var events []*models.RoomEvent
+	var lastID int64
+	for rows.Next() {
+		event := &models.RoomEvent{}
+		if err := rows.Scan(&lastID, &event.RoomID, &event.Seq, &event.Type, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("erro ao escanear evento do outbox: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events, lastID, rows.Err()
+}
+
+// Enqueue grava as entregas e remove do outbox os eventos até throughID, na mesma transação
+func (r *WebhookRepository) Enqueue(deliveries []*models.WebhookDelivery, throughID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO webhook_deliveries (id, webhook_id, event_type, room_id, payload, status, attempts, next_attempt_at, last_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0, ?, '', ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("erro ao preparar fila de webhooks: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, d := range deliveries {
+		_, err := stmt.Exec(d.ID, d.WebhookID, d.EventType, d.RoomID, d.Payload, models.WebhookDeliveryPending, d.CreatedAt.Unix(), d.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("erro ao enfileirar entrega: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM room_event_outbox WHERE id <= ?`, throughID); err != nil {
+		return fmt.Errorf("erro ao limpar outbox: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar fila de webhooks: %v", err)
+	}
+
+	return nil
+}
+
+// DueWebhooks retorna os webhooks com entregas pendentes cuja próxima tentativa já venceu
+func (r *WebhookRepository) DueWebhooks(now time.Time) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT webhook_id FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ?
+	`, models.WebhookDeliveryPending, now.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar webhooks com entregas pendentes: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao escanear webhook: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Due retorna até limit entregas vencidas do webhook, das mais antigas para as mais novas
+func (r *WebhookRepository) Due(webhookID string, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	query := webhookDeliverySelect + `WHERE webhook_id = ? AND status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, rowid LIMIT ?`
+
+	rows, err := r.db.Query(query, webhookID, models.WebhookDeliveryPending, now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar entregas pendentes: %v", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(rows)
+}
+
+func (r *WebhookRepository) GetDeliveries(webhookID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	query := webhookDeliverySelect + `WHERE webhook_id = ? ORDER BY rowid DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar entregas: %v", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(rows)
+}
+
+// GetDelivery retorna a entrega com o histórico de tentativas
+func (r *WebhookRepository) GetDelivery(id string) (*models.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(r.db.QueryRow(webhookDeliverySelect+`WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar entrega: %v", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, delivery_id, status_code, error, duration_ms, created_at
+		FROM webhook_attempts WHERE delivery_id = ? ORDER BY rowid
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tentativas: %v", err)
+	}
+	defer rows.Close()
+
+	delivery.AttemptLog = []*models.WebhookAttempt{}
+	for rows.Next() {
+		attempt := &models.WebhookAttempt{}
+		if err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.StatusCode, &attempt.Error, &attempt.DurationMs, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear tentativa: %v", err)
+		}
+		delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+	}
+
+	return delivery, rows.Err()
+}
+
+// RecordAttempt grava a tentativa e o novo estado da entrega (status, tentativas, próxima tentativa e erro)
+func (r *WebhookRepository) RecordAttempt(delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	attempt.ID = uuid.New().String()
+	_, err = tx.Exec(`
+		INSERT INTO webhook_attempts (id, delivery_id, status_code, error, duration_ms, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, attempt.ID, delivery.ID, attempt.StatusCode, attempt.Error, attempt.DurationMs, attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar tentativa: %v", err)
+	}
+
+	var nextAttempt interface{}
+	if delivery.NextAttempt != nil {
+		nextAttempt = delivery.NextAttempt.Unix()
+	}
+	var deliveredAt interface{}
+	if delivery.DeliveredAt != nil {
+		deliveredAt = *delivery.DeliveredAt
+	}
+
+	_, err = tx.Exec(`
+		UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, delivered_at = ? WHERE id = ?
+	`, delivery.Status, delivery.Attempts, nextAttempt, delivery.LastError, deliveredAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar entrega: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar tentativa: %v", err)
+	}
+
+	return nil
+}
+
+// Requeue recoloca a entrega na fila para envio imediato, com novo ciclo de tentativas.
+// O histórico de tentativas é mantido.
+func (r *WebhookRepository) Requeue(id string) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ?, last_error = '', delivered_at = NULL WHERE id = ?
+	`, models.WebhookDeliveryPending, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("erro ao reenfileirar entrega: %v", err)
+	}
+
+	return nil
+}
+
+// Postpone adia para until as entregas pendentes e já vencidas do webhook, sem contar tentativa.
+// Usado quando o endpoint falha, para que o restante da fila dele não seja tentado em seguida.
+func (r *WebhookRepository) Postpone(webhookID string, until time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries SET next_attempt_at = ? WHERE webhook_id = ? AND status = ? AND next_attempt_at < ?
+	`, until.Unix(), webhookID, models.WebhookDeliveryPending, until.Unix())
+	if err != nil {
+		return fmt.Errorf("erro ao adiar entregas do webhook: %v", err)
+	}
+
+	return nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear entrega: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// Prefixo dos tokens de API, facilita reconhecê-los em logs e varreduras de segredos
+const apiTokenPrefix = "whatz_"
+
+// Intervalo mínimo entre duas gravações de last_used_at do mesmo token
+const apiTokenTouchInterval = time.Minute
+
+var (
+	ErrInvalidAPIToken       = errors.New("token de API inválido ou revogado")
+	ErrAPITokenNotFound      = errors.New("token de API não encontrado")
+	ErrAPITokenUserNotFound  = errors.New("usuário não encontrado")
+	ErrAPITokenNameRequired  = errors.New("nome do token é obrigatório")
+	ErrAPITokenScopeRequired = errors.New("informe ao menos um escopo")
+	ErrInvalidAPITokenScope  = errors.New("escopo inválido")
+)
+
+type APITokenService struct {
+	tokenRepo *repository.APITokenRepository
+	userRepo  *repository.UserRepository
+}
+
+func NewAPITokenService(tokenRepo *repository.APITokenRepository, userRepo *repository.UserRepository) *APITokenService {
+	return &APITokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Create emite um token para o usuário. O token é retornado apenas aqui; só o hash é gravado.
+func (s *APITokenService) Create(userID, name string, scopes []string, createdBy string) (*models.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrAPITokenNameRequired
+	}
+
+	encoded, err := encodeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrAPITokenUserNotFound
+	}
+
+	raw, err := generateAPIToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := models.NewAPIToken(user.ID, name, raw[:len(apiTokenPrefix)+6], hashAPIToken(raw), encoded, createdBy)
+	if err := s.tokenRepo.Create(token); err != nil {
+		return nil, "", err
+	}
+
+	log.Printf("🔑 Token de API %s emitido para %s (%s) com escopos %s", token.ID, user.Username, user.Kind, encoded)
+	return token, raw, nil
+}
+
+// GetAll lista os tokens (sem o segredo); com userID, apenas os do usuário
+func (s *APITokenService) GetAll(userID string) ([]*models.APIToken, error) {
+	return s.tokenRepo.GetAll(userID)
+}
+
+// Revoke invalida o token imediatamente
+func (s *APITokenService) Revoke(id string) error {
+	revoked, err := s.tokenRepo.Revoke(id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPITokenNotFound
+	}
+
+	log.Printf("🔑 Token de API %s revogado", id)
+	return nil
+}
+
+// Authenticate valida o token apresentado e retorna o registro correspondente
+func (s *APITokenService) Authenticate(raw string) (*models.APIToken, error) {
+	if !strings.HasPrefix(raw, apiTokenPrefix) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	token, err := s.tokenRepo.GetByHash(hashAPIToken(raw))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Revoked() {
+		return nil, ErrInvalidAPIToken
+	}
+
+	now := time.Now().UTC()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if err := s.tokenRepo.Touch(token.ID, now); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+
+	return token, nil
+}
+
+func encodeScopes(scopes []string) (string, error) {
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if !models.IsAPITokenScope(scope) {
+			return "", fmt.Errorf("%w: %s", ErrInvalidAPITokenScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	if len(unique) == 0 {
+		return "", ErrAPITokenScopeRequired
+	}
+
+	data, err := json.Marshal(unique)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func generateAPIToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar token de API: %v", err)
+	}
+	return apiTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
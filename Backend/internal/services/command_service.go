@@ -0,0 +1,406 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// Visibilidade da resposta de um comando de integração
+const (
+	CommandResponseEphemeral = "ephemeral"
+	CommandResponseInChannel = "in_channel"
+)
+
+// Limite do corpo lido da resposta de uma integração
+const maxCommandResponseSize = 64 * 1024
+
+var (
+	ErrUnknownCommand         = errors.New("comando desconhecido")
+	ErrCommandFailed          = errors.New("a integração não respondeu ao comando")
+	ErrInvalidCommandName     = errors.New("nome de comando inválido: use de 1 a 32 letras minúsculas, números, _ ou -")
+	ErrCommandTaken           = errors.New("nome de comando já está em uso")
+	ErrCommandNotFound        = errors.New("comando não encontrado")
+	ErrCommandBotRequired     = errors.New("apenas contas de serviço podem registrar comandos")
+	ErrCommandCallbackInvalid = errors.New("callback_url deve ser uma URL http ou https")
+)
+
+var (
+	commandNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+	// "/nome" no início da mensagem, seguido opcionalmente de espaço e argumentos
+	commandPattern = regexp.MustCompile(`(?i)^/([a-z0-9][a-z0-9_-]{0,31})(?:\s+([\s\S]*))?$`)
+)
+
+// ParseCommand identifica um comando de barra no conteúdo da mensagem.
+// Conteúdo iniciado por "//" não é comando (veja UnescapeCommand).
+func ParseCommand(content string) (name, args string, ok bool) {
+	match := commandPattern.FindStringSubmatch(strings.TrimSpace(content))
+	if match == nil {
+		return "", "", false
+	}
+	return strings.ToLower(match[1]), strings.TrimSpace(match[2]), true
+}
+
+// UnescapeCommand remove a barra de escape: "//texto" é enviado como "/texto"
+func UnescapeCommand(content string) string {
+	if strings.HasPrefix(content, "//") {
+		return content[1:]
+	}
+	return content
+}
+
+// CommandInvocation é a execução de um comando por um usuário numa sala
+type CommandInvocation struct {
+	Name string
+	Args string
+	User *models.User
+	Room *models.Room
+}
+
+// CommandResult descreve o efeito do comando: uma resposta efêmera, vista só por quem
+// o executou, e/ou uma mensagem a publicar na sala (ainda não gravada). Apply, quando
+// definido, aplica a alteração do comando e só é chamado se Post passar pela moderação.
+type CommandResult struct {
+	Reply string
+	Post  *models.Message
+	Apply func() error
+}
+
+// CommandConfig define as chamadas aos callbacks das integrações
+type CommandConfig struct {
+	Timeout time.Duration // prazo de cada chamada
+	// Libera callbacks em endereços privados e de loopback (apenas desenvolvimento e testes)
+	AllowPrivate bool
+}
+
+type builtinCommand struct {
+	command models.SlashCommand
+	run     func(inv *CommandInvocation) (*CommandResult, error)
+}
+
+// commandRequest é o corpo enviado ao callback_url de uma integração
+type commandRequest struct {
+	Command  string `json:"command"`
+	Text     string `json:"text"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	RoomID   string `json:"room_id"`
+	RoomName string `json:"room_name"`
+}
+
+// commandResponse é a resposta esperada de uma integração
+type commandResponse struct {
+	Text         string `json:"text"`
+	ResponseType string `json:"response_type"`
+}
+
+type CommandService struct {
+	commandRepo   *repository.CommandRepository
+	rooms         *RoomService
+	userRepo      *repository.UserRepository
+	notifications *NotificationService
+	builtins      map[string]*builtinCommand
+	client        *http.Client
+}
+
+func NewCommandService(commandRepo *repository.CommandRepository, rooms *RoomService, userRepo *repository.UserRepository, notifications *NotificationService, config CommandConfig) *CommandService {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	s := &CommandService{
+		commandRepo:   commandRepo,
+		rooms:         rooms,
+		userRepo:      userRepo,
+		notifications: notifications,
+		client:        newPublicHTTPClient(config.Timeout, config.AllowPrivate),
+	}
+
+	s.builtins = map[string]*builtinCommand{}
+	s.register("me", "Descreve uma ação sua", "/me <ação>", s.runMe)
+	s.register("shrug", "Envia ¯\\_(ツ)_/¯ ao fim da mensagem", "/shrug [mensagem]", s.runShrug)
+	s.register("topic", "Altera o tópico (descrição) da sala", "/topic <tópico>", s.runTopic)
+	s.register("invite", "Convida um usuário para a sala", "/invite @usuário", s.runInvite)
+	s.register("help", "Lista os comandos disponíveis", "/help", s.runHelp)
+
+	return s
+}
+
+func (s *CommandService) register(name, description, usage string, run func(inv *CommandInvocation) (*CommandResult, error)) {
+	s.builtins[name] = &builtinCommand{
+		command: models.SlashCommand{Name: name, Description: description, Usage: usage, Source: models.CommandSourceBuiltin},
+		run:     run,
+	}
+}
+
+// GetAll lista os comandos disponíveis, embutidos e de integrações, em ordem alfabética
+func (s *CommandService) GetAll() ([]*models.SlashCommand, error) {
+	integrations, err := s.commandRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	commands := make([]*models.SlashCommand, 0, len(s.builtins)+len(integrations))
+	for _, builtin := range s.builtins {
+		command := builtin.command
+		commands = append(commands, &command)
+	}
+	commands = append(commands, integrations...)
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands, nil
+}
+
+// Execute roda o comando. Comandos inexistentes retornam ErrUnknownCommand; falhas da
+// integração, ErrCommandFailed.
+func (s *CommandService) Execute(inv *CommandInvocation) (*CommandResult, error) {
+	if builtin, ok := s.builtins[inv.Name]; ok {
+		return builtin.run(inv)
+	}
+
+	command, err := s.commandRepo.GetByName(inv.Name)
+	if err != nil {
+		return nil, err
+	}
+	if command == nil {
+		return nil, ErrUnknownCommand
+	}
+
+	return s.callIntegration(command, inv)
+}
+
+// Register cadastra (ou atualiza) um comando da conta de serviço. O segredo usado para
+// assinar as chamadas ao callback é gerado aqui e retornado apenas nesta chamada.
+func (s *CommandService) Register(botID, name, description, usage, callbackURL string) (*models.SlashCommand, error) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "/"))
+	if !commandNamePattern.MatchString(name) {
+		return nil, ErrInvalidCommandName
+	}
+	if _, ok := s.builtins[name]; ok {
+		return nil, ErrCommandTaken
+	}
+	if err := validateWebhookURL(callbackURL); err != nil {
+		return nil, ErrCommandCallbackInvalid
+	}
+
+	bot, err := s.userRepo.GetByID(botID)
+	if err != nil {
+		return nil, err
+	}
+	if bot == nil || !bot.IsBot() {
+		return nil, ErrCommandBotRequired
+	}
+
+	existing, err := s.commandRepo.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.BotID != botID {
+		return nil, ErrCommandTaken
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if usage == "" {
+		usage = "/" + name
+	}
+
+	command := models.NewIntegrationCommand(name, description, usage, botID, callbackURL, secret)
+	if existing != nil {
+		command.CreatedAt = existing.CreatedAt
+	}
+	if err := s.commandRepo.Save(command); err != nil {
+		return nil, err
+	}
+
+	log.Printf("⌨️ Comando /%s registrado por %s", name, bot.Username)
+	return command, nil
+}
+
+// Unregister remove um comando da conta de serviço
+func (s *CommandService) Unregister(botID, name string) error {
+	removed, err := s.commandRepo.Delete(strings.TrimPrefix(name, "/"), botID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrCommandNotFound
+	}
+
+	log.Printf("⌨️ Comando /%s removido", name)
+	return nil
+}
+
+// callIntegration envia a invocação ao callback da integração, assinada com HMAC-SHA256
+func (s *CommandService) callIntegration(command *models.SlashCommand, inv *CommandInvocation) (*CommandResult, error) {
+	body, err := json.Marshal(commandRequest{
+		Command:  "/" + command.Name,
+		Text:     inv.Args,
+		UserID:   inv.User.ID,
+		Username: inv.User.Username,
+		RoomID:   inv.Room.ID,
+		RoomName: inv.Room.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, command.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Whatz-Webhook/1.0")
+	req.Header.Set("X-Whatz-Event", "slash_command")
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(command.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("❌ Erro ao chamar comando /%s: %v", command.Name, err)
+		return nil, ErrCommandFailed
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Printf("❌ Comando /%s respondeu HTTP %d", command.Name, resp.StatusCode)
+		return nil, ErrCommandFailed
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCommandResponseSize))
+	if err != nil {
+		return nil, ErrCommandFailed
+	}
+
+	// Corpo vazio: o comando foi aceito sem resposta
+	var response commandResponse
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &response); err != nil {
+			log.Printf("❌ Resposta inválida do comando /%s: %v", command.Name, err)
+			return nil, ErrCommandFailed
+		}
+	}
+
+	text := strings.TrimSpace(response.Text)
+	if response.ResponseType != CommandResponseInChannel || text == "" {
+		return &CommandResult{Reply: text}, nil
+	}
+
+	// Respostas públicas são publicadas pela conta de serviço dona do comando
+	bot, err := s.userRepo.GetByID(command.BotID)
+	if err != nil {
+		return nil, err
+	}
+	if bot == nil {
+		return nil, ErrCommandFailed
+	}
+
+	return &CommandResult{
+		Post: models.NewMessage(text, bot.ID, bot.Username, bot.Avatar, "text", inv.Room.ID),
+	}, nil
+}
+
+func (s *CommandService) runMe(inv *CommandInvocation) (*CommandResult, error) {
+	if inv.Args == "" {
+		return usageReply(s.builtins["me"]), nil
+	}
+	return &CommandResult{
+		Post: models.NewMessage(inv.Args, inv.User.ID, inv.User.Username, inv.User.Avatar, "action", inv.Room.ID),
+	}, nil
+}
+
+func (s *CommandService) runShrug(inv *CommandInvocation) (*CommandResult, error) {
+	// Escapado para o Markdown: sem os escapes a barra some e "_(ツ)_" vira itálico
+	content := strings.TrimSpace(inv.Args + ` ¯\\\_(ツ)\_/¯`)
+	return &CommandResult{
+		Post: models.NewMessage(content, inv.User.ID, inv.User.Username, inv.User.Avatar, "text", inv.Room.ID),
+	}, nil
+}
+
+// runTopic altera a descrição da sala; permitido ao moderador (criador) da sala e a administradores.
+// A alteração só é gravada se a mensagem de sistema passar pela moderação da sala, e o tópico
+// gravado é o da mensagem moderada (com as máscaras aplicadas).
+func (s *CommandService) runTopic(inv *CommandInvocation) (*CommandResult, error) {
+	if inv.Args == "" {
+		return usageReply(s.builtins["topic"]), nil
+	}
+	if inv.User.Role != "admin" && inv.Room.CreatedBy != inv.User.ID {
+		return &CommandResult{Reply: "Apenas o moderador da sala ou administradores podem alterar o tópico"}, nil
+	}
+
+	const prefix = "alterou o tópico para: "
+	room := inv.Room
+	post := models.NewMessage(prefix+inv.Args, inv.User.ID, inv.User.Username, inv.User.Avatar, "system", room.ID)
+	return &CommandResult{
+		Post: post,
+		Apply: func() error {
+			// A máscara troca caractere por caractere, então o tópico continua após o prefixo
+			room.Description = string([]rune(post.Content)[utf8.RuneCountInString(prefix):])
+			room.UpdatedAt = time.Now()
+			if err := s.rooms.Update(room); err != nil {
+				return err
+			}
+			log.Printf("⌨️ Tópico da sala %s alterado por %s", room.ID, inv.User.Username)
+			return nil
+		},
+	}, nil
+}
+
+func (s *CommandService) runInvite(inv *CommandInvocation) (*CommandResult, error) {
+	fields := strings.Fields(inv.Args)
+	if len(fields) == 0 || strings.TrimPrefix(fields[0], "@") == "" {
+		return usageReply(s.builtins["invite"]), nil
+	}
+	username := strings.TrimPrefix(fields[0], "@")
+
+	invitee, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if invitee == nil {
+		return &CommandResult{Reply: fmt.Sprintf("Usuário @%s não encontrado", username)}, nil
+	}
+
+	notification, err := s.notifications.NotifyInvite(inv.Room, invitee.ID, inv.User)
+	if err != nil {
+		return nil, err
+	}
+	if notification == nil {
+		return &CommandResult{Reply: fmt.Sprintf("@%s desabilitou o recebimento de convites", invitee.Username)}, nil
+	}
+
+	return &CommandResult{Reply: fmt.Sprintf("Convite enviado para @%s", invitee.Username)}, nil
+}
+
+func (s *CommandService) runHelp(inv *CommandInvocation) (*CommandResult, error) {
+	commands, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(commands))
+	for _, command := range commands {
+		lines = append(lines, fmt.Sprintf("%s — %s", command.Usage, command.Description))
+	}
+	return &CommandResult{Reply: strings.Join(lines, "\n")}, nil
+}
+
+func usageReply(builtin *builtinCommand) *CommandResult {
+	return &CommandResult{Reply: "Uso: " + builtin.command.Usage}
+}
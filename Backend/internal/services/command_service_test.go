@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// runTestCommand executa o comando como o handler WebSocket: moderação do Post e, se não
+// bloqueado, Apply
+func runTestCommand(t *testing.T, commands *CommandService, moderation *ModerationService, inv *CommandInvocation) *ModerationResult {
+	t.Helper()
+
+	result, err := commands.Execute(inv)
+	if err != nil {
+		t.Fatalf("erro ao executar /%s: %v", inv.Name, err)
+	}
+	verdict, err := moderation.Moderate(result.Post)
+	if err != nil {
+		t.Fatalf("erro ao moderar: %v", err)
+	}
+	if !verdict.Blocked && result.Apply != nil {
+		if err := result.Apply(); err != nil {
+			t.Fatalf("erro ao aplicar /%s: %v", inv.Name, err)
+		}
+	}
+	return verdict
+}
+
+func TestTopicUsesModeratedText(t *testing.T) {
+	db := newTestDatabase(t)
+	userRepo := repository.NewUserRepository(db.DB)
+	roomRepo := repository.NewRoomRepository(db.DB)
+	moderationRepo := repository.NewModerationRepository(db.DB)
+
+	user := models.NewUser("moderador", "moderador@exemplo.com", "")
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("erro ao criar usuário: %v", err)
+	}
+	room := models.NewRoom("geral", "tópico original", "public", user.ID)
+	if err := roomRepo.Create(room); err != nil {
+		t.Fatalf("erro ao criar sala: %v", err)
+	}
+	for _, rule := range []*models.ModerationRule{
+		models.NewModerationRule(room.ID, models.ModerationKindWords, "palavrão", models.ModerationActionMask, user.ID),
+		models.NewModerationRule(room.ID, models.ModerationKindWords, "proibido", models.ModerationActionBlock, user.ID),
+	} {
+		if err := moderationRepo.CreateRule(rule); err != nil {
+			t.Fatalf("erro ao criar regra: %v", err)
+		}
+	}
+
+	commands := NewCommandService(repository.NewCommandRepository(db.DB), NewRoomService(roomRepo), userRepo, nil, CommandConfig{})
+	moderation := NewModerationService(moderationRepo, repository.NewMessageRepository(db.DB), roomRepo, userRepo)
+
+	runTestCommand(t, commands, moderation, &CommandInvocation{Name: "topic", Args: "sem palavrão aqui", User: user, Room: room})
+
+	stored, err := roomRepo.GetByID(room.ID)
+	if err != nil {
+		t.Fatalf("erro ao buscar sala: %v", err)
+	}
+	if stored.Description != "sem •••••••• aqui" {
+		t.Fatalf("descrição = %q, esperado o tópico mascarado", stored.Description)
+	}
+
+	verdict := runTestCommand(t, commands, moderation, &CommandInvocation{Name: "topic", Args: "assunto proibido", User: user, Room: room})
+	if !verdict.Blocked {
+		t.Fatal("tópico com palavra bloqueada deveria ser recusado")
+	}
+
+	stored, err = roomRepo.GetByID(room.ID)
+	if err != nil {
+		t.Fatalf("erro ao buscar sala: %v", err)
+	}
+	if stored.Description != "sem •••••••• aqui" {
+		t.Fatalf("descrição = %q; o tópico bloqueado não deveria ser gravado", stored.Description)
+	}
+}
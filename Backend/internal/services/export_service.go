@@ -0,0 +1,335 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// Mensagens lidas por página ao montar o arquivo
+const exportMessagePageSize = 500
+
+// Intervalo em que o worker procura exportações pendentes mesmo sem ser acordado
+const exportPollInterval = time.Minute
+
+var ErrExportUserNotFound = errors.New("usuário não encontrado")
+
+// ExportService gera, em segundo plano, o arquivo ZIP com os dados pessoais de um usuário
+type ExportService struct {
+	exportRepo  *repository.ExportRepository
+	userRepo    *repository.UserRepository
+	roomRepo    *repository.RoomRepository
+	messageRepo *repository.MessageRepository
+	dir         string
+
+	wake chan struct{}
+}
+
+func NewExportService(exportRepo *repository.ExportRepository, userRepo *repository.UserRepository, roomRepo *repository.RoomRepository, messageRepo *repository.MessageRepository, dir string) *ExportService {
+	return &ExportService{
+		exportRepo:  exportRepo,
+		userRepo:    userRepo,
+		roomRepo:    roomRepo,
+		messageRepo: messageRepo,
+		dir:         dir,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Request registra um pedido de exportação e acorda o worker
+func (s *ExportService) Request(userID string) (*models.DataExport, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrExportUserNotFound
+	}
+
+	export := models.NewDataExport(userID)
+	if err := s.exportRepo.Create(export); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return export, nil
+}
+
+func (s *ExportService) GetByID(id string) (*models.DataExport, error) {
+	return s.exportRepo.GetByID(id)
+}
+
+// Run processa as exportações pendentes uma de cada vez. Pedidos interrompidos por um
+// reinício do servidor continuam pendentes no banco e são retomados aqui.
+func (s *ExportService) Run() {
+	log.Printf("📦 Serviço de exportação iniciado (diretório %s)", s.dir)
+
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processPending()
+
+		select {
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ExportService) processPending() {
+	exports, err := s.exportRepo.GetUnfinished()
+	if err != nil {
+		log.Printf("❌ Erro ao buscar exportações pendentes: %v", err)
+		return
+	}
+
+	for _, export := range exports {
+		s.process(export)
+	}
+}
+
+func (s *ExportService) process(export *models.DataExport) {
+	start := time.Now()
+
+	export.Status = models.ExportStatusRunning
+	if err := s.exportRepo.UpdateStatus(export); err != nil {
+		log.Printf("❌ Erro ao iniciar exportação %s: %v", export.ID, err)
+		return
+	}
+
+	path, err := s.writeArchiveFile(export)
+	if err != nil {
+		log.Printf("❌ Exportação %s falhou: %v", export.ID, err)
+		if err := s.exportRepo.MarkFinished(export, models.ExportStatusFailed, "", err.Error()); err != nil {
+			log.Printf("❌ Erro ao registrar falha da exportação %s: %v", export.ID, err)
+		}
+		return
+	}
+
+	if err := s.exportRepo.MarkFinished(export, models.ExportStatusCompleted, path, ""); err != nil {
+		log.Printf("❌ Erro ao concluir exportação %s: %v", export.ID, err)
+		return
+	}
+
+	// A conta pode ter sido removida durante a geração: o arquivo não deve sobrar
+	if current, err := s.exportRepo.GetByID(export.ID); err == nil && current == nil {
+		os.Remove(path)
+		log.Printf("🗑️ Exportação %s descartada: a conta foi removida", export.ID)
+		return
+	}
+
+	log.Printf("📦 Exportação %s do usuário %s concluída em %v", export.ID, export.UserID, time.Since(start))
+}
+
+// writeArchiveFile grava o ZIP em um arquivo temporário e o renomeia ao final,
+// para que um download nunca encontre um arquivo pela metade
+func (s *ExportService) writeArchiveFile(export *models.DataExport) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return "", fmt.Errorf("erro ao criar diretório de exportação: %v", err)
+	}
+
+	path := filepath.Join(s.dir, export.ID+".zip")
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar arquivo de exportação: %v", err)
+	}
+
+	if err := s.WriteUserArchive(export.UserID, file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("erro ao gravar arquivo de exportação: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("erro ao finalizar arquivo de exportação: %v", err)
+	}
+
+	return path, nil
+}
+
+// exportMemberships descreve as salas ligadas ao usuário. Não existe tabela de membros:
+// o vínculo é derivado das salas criadas, das liberadas pelas tags e das que têm mensagens dele.
+type exportMemberships struct {
+	CreatedRooms      []*models.Room `json:"created_rooms"`
+	AccessibleRooms   []*models.Room `json:"accessible_rooms"`
+	RoomsWithMessages []*models.Room `json:"rooms_with_messages"`
+}
+
+// WriteUserArchive escreve em w o ZIP com perfil, tags, salas e mensagens do usuário
+func (s *ExportService) WriteUserArchive(userID string, w io.Writer) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrExportUserNotFound
+	}
+
+	archive := zip.NewWriter(w)
+
+	if err := writeJSONEntry(archive, "profile.json", user); err != nil {
+		return err
+	}
+
+	var tags []string
+	if err := json.Unmarshal([]byte(user.Tags), &tags); err != nil {
+		tags = []string{}
+	}
+	if err := writeJSONEntry(archive, "tags.json", tags); err != nil {
+		return err
+	}
+
+	roomIDs, err := s.writeMessages(archive, userID)
+	if err != nil {
+		return err
+	}
+
+	memberships, err := s.memberships(userID, tags, roomIDs)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONEntry(archive, "memberships.json", memberships); err != nil {
+		return err
+	}
+
+	// Este servidor não armazena uploads: o avatar é apenas uma URL externa
+	note, err := archive.Create("files/LEIA-ME.txt")
+	if err != nil {
+		return fmt.Errorf("erro ao criar entrada do arquivo: %v", err)
+	}
+	fmt.Fprintf(note, "Nenhum arquivo enviado pelo usuário é armazenado pelo Whatz.\n")
+	if user.Avatar != "" {
+		fmt.Fprintf(note, "Avatar (URL externa): %s\n", user.Avatar)
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("erro ao finalizar arquivo: %v", err)
+	}
+
+	return nil
+}
+
+// writeMessages grava messages.json página a página e retorna as salas onde o usuário escreveu
+func (s *ExportService) writeMessages(archive *zip.Writer, userID string) ([]string, error) {
+	entry, err := archive.Create("messages.json")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar entrada do arquivo: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	var roomIDs []string
+	first := true
+
+	if _, err := io.WriteString(entry, "["); err != nil {
+		return nil, err
+	}
+
+	for offset := 0; ; offset += exportMessagePageSize {
+		messages, err := s.messageRepo.GetByUser(userID, exportMessagePageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, message := range messages {
+			data, err := json.Marshal(message)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao serializar mensagem: %v", err)
+			}
+			if !first {
+				data = append([]byte(","), data...)
+			}
+			first = false
+			if _, err := entry.Write(data); err != nil {
+				return nil, err
+			}
+
+			if !seen[message.RoomID] {
+				seen[message.RoomID] = true
+				roomIDs = append(roomIDs, message.RoomID)
+			}
+		}
+
+		if len(messages) < exportMessagePageSize {
+			break
+		}
+	}
+
+	if _, err := io.WriteString(entry, "]"); err != nil {
+		return nil, err
+	}
+
+	return roomIDs, nil
+}
+
+func (s *ExportService) memberships(userID string, tags []string, roomIDs []string) (*exportMemberships, error) {
+	created, err := s.roomRepo.GetByCreator(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessible, err := s.roomRepo.GetRoomsByAccessTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	withMessages := make([]*models.Room, 0, len(roomIDs))
+	for _, roomID := range roomIDs {
+		room, err := s.roomRepo.GetByID(roomID)
+		if err != nil {
+			return nil, err
+		}
+		if room != nil {
+			withMessages = append(withMessages, room)
+		}
+	}
+
+	if created == nil {
+		created = []*models.Room{}
+	}
+	if accessible == nil {
+		accessible = []*models.Room{}
+	}
+
+	return &exportMemberships{
+		CreatedRooms:      created,
+		AccessibleRooms:   accessible,
+		RoomsWithMessages: withMessages,
+	}, nil
+}
+
+func writeJSONEntry(archive *zip.Writer, name string, value interface{}) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("erro ao criar entrada do arquivo: %v", err)
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("erro ao serializar %s: %v", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// MaxIncomingMessageLength limita o tamanho (em caracteres) das mensagens recebidas por webhook
+const MaxIncomingMessageLength = models.MaxMessageLength
+
+var (
+	ErrIncomingWebhookNotFound      = errors.New("webhook de entrada não encontrado")
+	ErrIncomingWebhookRoomNotFound  = errors.New("sala não encontrada")
+	ErrIncomingWebhookRoomArchived  = errors.New("sala arquivada")
+	ErrIncomingWebhookUserNotFound  = errors.New("usuário não encontrado")
+	ErrIncomingWebhookForbidden     = errors.New("apenas o moderador da sala ou administradores podem gerenciar webhooks de entrada")
+	ErrIncomingWebhookInvalidName   = errors.New("nome do webhook é obrigatório")
+	ErrIncomingWebhookInvalidAvatar = errors.New("avatar deve ser uma URL http ou https")
+	ErrIncomingMessageEmpty         = errors.New("conteúdo da mensagem não pode ser vazio")
+	ErrIncomingMessageTooLong       = fmt.Errorf("conteúdo da mensagem excede %d caracteres", MaxIncomingMessageLength)
+	ErrIncomingMessageBlocked       = errors.New("mensagem bloqueada pela moderação da sala")
+)
+
+// IncomingMessage é o corpo aceito por POST /hooks/:token
+type IncomingMessage struct {
+	Content string `json:"content"`
+	// Sobrescrevem nome e avatar do bot apenas nesta mensagem
+	Username string `json:"username,omitempty"`
+	Avatar   string `json:"avatar,omitempty"`
+}
+
+// IncomingWebhookNotifier publica as mensagens dos bots para os clientes conectados
+// (o handler WebSocket, pelo mesmo caminho de "send_message")
+type IncomingWebhookNotifier interface {
+	PublishMessage(message *models.Message)
+}
+
+type IncomingWebhookService struct {
+	hookRepo    *repository.IncomingWebhookRepository
+	messageRepo *repository.MessageRepository
+	roomRepo    *repository.RoomRepository
+	userRepo    *repository.UserRepository
+	moderation  *ModerationService
+	notifier    IncomingWebhookNotifier
+}
+
+func NewIncomingWebhookService(hookRepo *repository.IncomingWebhookRepository, messageRepo *repository.MessageRepository, roomRepo *repository.RoomRepository, userRepo *repository.UserRepository, moderation *ModerationService) *IncomingWebhookService {
+	return &IncomingWebhookService{
+		hookRepo:    hookRepo,
+		messageRepo: messageRepo,
+		roomRepo:    roomRepo,
+		userRepo:    userRepo,
+		moderation:  moderation,
+	}
+}
+
+// SetNotifier define quem publica as mensagens recebidas (o handler WebSocket)
+func (s *IncomingWebhookService) SetNotifier(notifier IncomingWebhookNotifier) {
+	s.notifier = notifier
+}
+
+// Create gera um token para a sala. O token é retornado apenas aqui; só o hash é gravado.
+func (s *IncomingWebhookService) Create(roomID, name, avatar, userID string) (*models.IncomingWebhook, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrIncomingWebhookInvalidName
+	}
+	if err := validateIncomingAvatar(avatar); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.authorize(roomID, userID); err != nil {
+		return nil, "", err
+	}
+
+	token, err := generateIncomingWebhookToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	hook := models.NewIncomingWebhook(roomID, name, avatar, hashIncomingWebhookToken(token), userID)
+	if err := s.hookRepo.Create(hook); err != nil {
+		return nil, "", err
+	}
+
+	log.Printf("🪝 Webhook de entrada %s criado na sala %s por %s", hook.ID, roomID, userID)
+	return hook, token, nil
+}
+
+func (s *IncomingWebhookService) GetByRoom(roomID string) ([]*models.IncomingWebhook, error) {
+	return s.hookRepo.GetByRoom(roomID)
+}
+
+// Delete revoga o token; mensagens já publicadas permanecem
+func (s *IncomingWebhookService) Delete(roomID, id, userID string) error {
+	room, err := s.roomRepo.GetByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room == nil {
+		return ErrIncomingWebhookRoomNotFound
+	}
+	if err := s.checkModerator(room, userID); err != nil {
+		return err
+	}
+
+	removed, err := s.hookRepo.Delete(roomID, id)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrIncomingWebhookNotFound
+	}
+
+	log.Printf("🪝 Webhook de entrada %s revogado na sala %s por %s", id, roomID, userID)
+	return nil
+}
+
+// Post valida o corpo e publica a mensagem do bot na sala do token. O conteúdo passa pela
+// moderação da sala como as mensagens enviadas pelo WebSocket.
+func (s *IncomingWebhookService) Post(token string, incoming *IncomingMessage) (*models.Message, error) {
+	hook, err := s.hookRepo.GetByTokenHash(hashIncomingWebhookToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil {
+		return nil, ErrIncomingWebhookNotFound
+	}
+
+	// Mesma validação de "send_message": o conteúdo é gravado como enviado
+	content := incoming.Content
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrIncomingMessageEmpty
+	}
+	if utf8.RuneCountInString(content) > MaxIncomingMessageLength {
+		return nil, ErrIncomingMessageTooLong
+	}
+	if err := validateIncomingAvatar(incoming.Avatar); err != nil {
+		return nil, err
+	}
+
+	room, err := s.roomRepo.GetByID(hook.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil {
+		return nil, ErrIncomingWebhookRoomNotFound
+	}
+	if room.Archived() {
+		return nil, ErrIncomingWebhookRoomArchived
+	}
+
+	username := hook.Name
+	if name := strings.TrimSpace(incoming.Username); name != "" {
+		username = name
+	}
+	avatar := hook.Avatar
+	if incoming.Avatar != "" {
+		avatar = incoming.Avatar
+	}
+
+	// Bots não têm cadastro: user_id vazio faz a leitura usar o nome e avatar gravados na mensagem
+	message := models.NewMessage(content, "", username, avatar, "bot", hook.RoomID)
+
+	moderation, err := s.moderation.Moderate(message)
+	if err != nil {
+		return nil, err
+	}
+	if moderation.Blocked {
+		return nil, fmt.Errorf("%w: %s", ErrIncomingMessageBlocked, moderation.Reason)
+	}
+
+	if err := s.messageRepo.Create(message); err != nil {
+		return nil, err
+	}
+	s.moderation.Flag(message, moderation)
+
+	if err := s.hookRepo.Touch(hook.ID, message.CreatedAt); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+
+	if s.notifier != nil {
+		s.notifier.PublishMessage(message)
+	}
+
+	log.Printf("🤖 Mensagem %s publicada na sala %s pelo webhook %s", message.ID, hook.RoomID, hook.ID)
+	return message, nil
+}
+
+// authorize garante que a sala existe, está ativa e que o usuário é o moderador (criador) da sala ou administrador
+func (s *IncomingWebhookService) authorize(roomID, userID string) error {
+	room, err := s.roomRepo.GetByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room == nil {
+		return ErrIncomingWebhookRoomNotFound
+	}
+	if room.Archived() {
+		return ErrIncomingWebhookRoomArchived
+	}
+
+	return s.checkModerator(room, userID)
+}
+
+func (s *IncomingWebhookService) checkModerator(room *models.Room, userID string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrIncomingWebhookUserNotFound
+	}
+
+	if user.Role != "admin" && room.CreatedBy != user.ID {
+		return ErrIncomingWebhookForbidden
+	}
+
+	return nil
+}
+
+// validateIncomingAvatar aceita apenas URLs http(s); vazio mantém o avatar padrão
+func validateIncomingAvatar(avatar string) error {
+	if avatar == "" {
+		return nil
+	}
+	parsed, err := url.Parse(avatar)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrIncomingWebhookInvalidAvatar
+	}
+	return nil
+}
+
+func generateIncomingWebhookToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar token do webhook: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashIncomingWebhookToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
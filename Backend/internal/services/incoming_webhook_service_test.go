@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+func TestIncomingWebhookPostIsModerated(t *testing.T) {
+	db := newTestDatabase(t)
+	userRepo := repository.NewUserRepository(db.DB)
+	roomRepo := repository.NewRoomRepository(db.DB)
+	messageRepo := repository.NewMessageRepository(db.DB)
+	moderationRepo := repository.NewModerationRepository(db.DB)
+
+	user := models.NewUser("moderador", "moderador@exemplo.com", "")
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("erro ao criar usuário: %v", err)
+	}
+	room := models.NewRoom("alertas", "", "public", user.ID)
+	if err := roomRepo.Create(room); err != nil {
+		t.Fatalf("erro ao criar sala: %v", err)
+	}
+	for _, rule := range []*models.ModerationRule{
+		models.NewModerationRule(room.ID, models.ModerationKindWords, "palavrão", models.ModerationActionMask, user.ID),
+		models.NewModerationRule(room.ID, models.ModerationKindWords, "proibido", models.ModerationActionBlock, user.ID),
+		models.NewModerationRule(room.ID, models.ModerationKindWords, "suspeito", models.ModerationActionFlag, user.ID),
+	} {
+		if err := moderationRepo.CreateRule(rule); err != nil {
+			t.Fatalf("erro ao criar regra: %v", err)
+		}
+	}
+
+	moderation := NewModerationService(moderationRepo, messageRepo, roomRepo, userRepo)
+	s := NewIncomingWebhookService(repository.NewIncomingWebhookRepository(db.DB), messageRepo, roomRepo, userRepo, moderation)
+	_, token, err := s.Create(room.ID, "Monitoramento", "", user.ID)
+	if err != nil {
+		t.Fatalf("erro ao criar webhook: %v", err)
+	}
+
+	if _, err := s.Post(token, &IncomingMessage{Content: "alerta proibido"}); !errors.Is(err, ErrIncomingMessageBlocked) {
+		t.Fatalf("erro = %v, esperado bloqueio", err)
+	}
+	if n := countRows(t, db.DB, "messages", "room_id = ?", room.ID); n != 0 {
+		t.Fatalf("%d mensagens gravadas, esperado nenhuma", n)
+	}
+
+	message, err := s.Post(token, &IncomingMessage{Content: "um palavrão **suspeito**"})
+	if err != nil {
+		t.Fatalf("erro ao publicar: %v", err)
+	}
+	stored, err := messageRepo.GetByID(message.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("erro ao buscar mensagem: %v", err)
+	}
+	if stored.Content != "um •••••••• **suspeito**" {
+		t.Fatalf("conteúdo = %q, esperado mascarado", stored.Content)
+	}
+	if n := countRows(t, db.DB, "moderation_flags", "message_id = ?", message.ID); n != 1 {
+		t.Fatalf("%d sinalizações, esperado 1", n)
+	}
+}
+
+func TestIncomingWebhookAvatarMustBeHTTP(t *testing.T) {
+	db := newTestDatabase(t)
+	userRepo := repository.NewUserRepository(db.DB)
+	roomRepo := repository.NewRoomRepository(db.DB)
+	messageRepo := repository.NewMessageRepository(db.DB)
+
+	user := models.NewUser("moderador", "moderador@exemplo.com", "")
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("erro ao criar usuário: %v", err)
+	}
+	room := models.NewRoom("alertas", "", "public", user.ID)
+	if err := roomRepo.Create(room); err != nil {
+		t.Fatalf("erro ao criar sala: %v", err)
+	}
+
+	moderation := NewModerationService(repository.NewModerationRepository(db.DB), messageRepo, roomRepo, userRepo)
+	s := NewIncomingWebhookService(repository.NewIncomingWebhookRepository(db.DB), messageRepo, roomRepo, userRepo, moderation)
+
+	if _, _, err := s.Create(room.ID, "Monitoramento", "javascript:alert(1)", user.ID); !errors.Is(err, ErrIncomingWebhookInvalidAvatar) {
+		t.Fatalf("erro = %v, esperado avatar inválido", err)
+	}
+	_, token, err := s.Create(room.ID, "Monitoramento", "https://exemplo.com/bot.png", user.ID)
+	if err != nil {
+		t.Fatalf("erro ao criar webhook: %v", err)
+	}
+
+	for _, avatar := range []string{"javascript:alert(1)", "data:image/png;base64,AAAA", "//exemplo.com/a.png"} {
+		if _, err := s.Post(token, &IncomingMessage{Content: "oi", Avatar: avatar}); !errors.Is(err, ErrIncomingWebhookInvalidAvatar) {
+			t.Errorf("avatar %q: erro = %v, esperado avatar inválido", avatar, err)
+		}
+	}
+	message, err := s.Post(token, &IncomingMessage{Content: "oi", Avatar: "http://exemplo.com/grafana.png"})
+	if err != nil {
+		t.Fatalf("erro ao publicar: %v", err)
+	}
+	if message.Avatar != "http://exemplo.com/grafana.png" {
+		t.Fatalf("avatar = %q", message.Avatar)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+var (
+	ErrInvalidHoldScope   = errors.New("escopo de retenção inválido")
+	ErrHoldTargetNotFound = errors.New("alvo da retenção não encontrado")
+	ErrHoldNotFound       = errors.New("retenção legal não encontrada")
+)
+
+type LegalHoldService struct {
+	holdRepo *repository.LegalHoldRepository
+	userRepo *repository.UserRepository
+	roomRepo *repository.RoomRepository
+}
+
+func NewLegalHoldService(holdRepo *repository.LegalHoldRepository, userRepo *repository.UserRepository, roomRepo *repository.RoomRepository) *LegalHoldService {
+	return &LegalHoldService{
+		holdRepo: holdRepo,
+		userRepo: userRepo,
+		roomRepo: roomRepo,
+	}
+}
+
+// Place coloca uma sala ou um usuário sob retenção legal
+func (s *LegalHoldService) Place(scope, targetID, reason, placedBy string) (*models.LegalHold, error) {
+	switch scope {
+	case models.LegalHoldScopeRoom:
+		room, err := s.roomRepo.GetByID(targetID)
+		if err != nil {
+			return nil, err
+		}
+		if room == nil {
+			return nil, ErrHoldTargetNotFound
+		}
+	case models.LegalHoldScopeUser:
+		user, err := s.userRepo.GetByID(targetID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrHoldTargetNotFound
+		}
+	default:
+		return nil, ErrInvalidHoldScope
+	}
+
+	hold := models.NewLegalHold(scope, targetID, reason, placedBy)
+	if err := s.holdRepo.Create(hold); err != nil {
+		return nil, err
+	}
+
+	log.Printf("⚖️ Retenção legal %s aplicada em %s %s por %q", hold.ID, scope, targetID, placedBy)
+	return hold, nil
+}
+
+// Release libera a retenção; liberar uma retenção já liberada não altera o registro
+func (s *LegalHoldService) Release(id, releasedBy string) (*models.LegalHold, error) {
+	hold, err := s.holdRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, ErrHoldNotFound
+	}
+	if !hold.Active() {
+		return hold, nil
+	}
+
+	if err := s.holdRepo.Release(id, releasedBy); err != nil {
+		return nil, err
+	}
+
+	log.Printf("⚖️ Retenção legal %s (%s %s) liberada por %q", hold.ID, hold.Scope, hold.TargetID, releasedBy)
+	return s.holdRepo.GetByID(id)
+}
+
+func (s *LegalHoldService) GetByID(id string) (*models.LegalHold, error) {
+	return s.holdRepo.GetByID(id)
+}
+
+func (s *LegalHoldService) GetAll(activeOnly bool) ([]*models.LegalHold, error) {
+	return s.holdRepo.GetAll(activeOnly)
+}
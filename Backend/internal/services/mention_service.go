@@ -0,0 +1,139 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	"github.com/rafael-bit/whatz/internal/markdown"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// ParsedMentions são as menções encontradas no texto de uma mensagem
+type ParsedMentions struct {
+	Usernames []string
+	Room      bool
+	Here      bool
+}
+
+// ParseMentions extrai @username, @room e @here das entidades de menção do Markdown, sem
+// repetir nomes. Menções escapadas ou em código não geram entidade e não são contadas.
+func ParseMentions(entities []markdown.Entity) ParsedMentions {
+	var parsed ParsedMentions
+	seen := make(map[string]bool)
+
+	for _, entity := range entities {
+		if entity.Type != markdown.EntityMention {
+			continue
+		}
+		switch name := entity.Username; strings.ToLower(name) {
+		case "room":
+			parsed.Room = true
+		case "here":
+			parsed.Here = true
+		default:
+			if !seen[name] {
+				seen[name] = true
+				parsed.Usernames = append(parsed.Usernames, name)
+			}
+		}
+	}
+
+	return parsed
+}
+
+type MentionService struct {
+	mentionRepo   *repository.MentionRepository
+	userRepo      *repository.UserRepository
+	notifications *NotificationService
+}
+
+func NewMentionService(mentionRepo *repository.MentionRepository, userRepo *repository.UserRepository, notifications *NotificationService) *MentionService {
+	return &MentionService{
+		mentionRepo:   mentionRepo,
+		userRepo:      userRepo,
+		notifications: notifications,
+	}
+}
+
+// Record resolve e grava as menções da mensagem. onlineUserIDs são os usuários conectados na sala,
+// usados por @here. O autor nunca é mencionado e cada usuário recebe uma única menção por mensagem,
+// priorizando a menção direta. Cada menção também gera uma notificação.
+func (s *MentionService) Record(message *models.Message, onlineUserIDs []string) ([]*models.Mention, error) {
+	parsed := ParseMentions(message.Entities)
+	if len(parsed.Usernames) == 0 && !parsed.Room && !parsed.Here {
+		return nil, nil
+	}
+
+	var mentions []*models.Mention
+	mentioned := make(map[string]bool)
+	add := func(userID, kind string) {
+		if userID == "" || userID == message.UserID || mentioned[userID] {
+			return
+		}
+		mentioned[userID] = true
+		mentions = append(mentions, models.NewMention(message, userID, kind))
+	}
+
+	for _, username := range parsed.Usernames {
+		user, err := s.userRepo.GetByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			add(user.ID, models.MentionKindUser)
+		}
+	}
+
+	if parsed.Here {
+		for _, userID := range onlineUserIDs {
+			add(userID, models.MentionKindHere)
+		}
+	}
+
+	if parsed.Room {
+		participants, err := s.mentionRepo.RoomParticipants(message.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		for _, userID := range participants {
+			add(userID, models.MentionKindRoom)
+		}
+		// Quem está conectado também faz parte da sala, mesmo sem ter escrito nela
+		for _, userID := range onlineUserIDs {
+			add(userID, models.MentionKindRoom)
+		}
+	}
+
+	if err := s.mentionRepo.CreateBatch(mentions); err != nil {
+		return nil, err
+	}
+
+	if len(mentions) > 0 {
+		log.Printf("🔔 %d menções registradas na mensagem %s", len(mentions), message.ID)
+	}
+	for _, mention := range mentions {
+		s.notifications.NotifyMention(mention)
+	}
+	return mentions, nil
+}
+
+// GetForUser lista as menções do usuário e o total de não lidas
+func (s *MentionService) GetForUser(userID string, unreadOnly bool, limit, offset int) ([]*models.Mention, int, error) {
+	mentions, err := s.mentionRepo.GetByUser(userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	unread, err := s.mentionRepo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return mentions, unread, nil
+}
+
+// MarkRead marca menções como lidas (todas, se ids for vazio)
+func (s *MentionService) MarkRead(userID string, ids []string) (int64, error) {
+	return s.mentionRepo.MarkRead(userID, ids)
+}
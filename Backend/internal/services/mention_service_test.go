@@ -0,0 +1,30 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+func TestParseMentionsFollowsMarkdown(t *testing.T) {
+	cases := []struct {
+		content string
+		want    ParsedMentions
+	}{
+		{"oi @joao e @maria, tudo bem @joao?", ParsedMentions{Usernames: []string{"joao", "maria"}}},
+		{"atenção @room e @HERE", ParsedMentions{Room: true, Here: true}},
+		{"fala com @joao.", ParsedMentions{Usernames: []string{"joao"}}},
+		{"e-mail joao@exemplo.com", ParsedMentions{}},
+		{`sem aviso: \@room`, ParsedMentions{}},
+		{"em código: `@room`", ParsedMentions{}},
+		{"```\n@here\n```", ParsedMentions{}},
+	}
+
+	for _, tc := range cases {
+		message := models.NewMessage(tc.content, "autor", "autor", "", "text", "sala")
+		if got := ParseMentions(message.Entities); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseMentions(%q) = %+v, esperado %+v", tc.content, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/rafael-bit/whatz/internal/markdown"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+var (
+	ErrModerationRoomNotFound   = errors.New("sala não encontrada")
+	ErrModerationUserNotFound   = errors.New("usuário não encontrado")
+	ErrModerationForbidden      = errors.New("apenas o moderador da sala ou administradores podem gerenciar a moderação")
+	ErrModerationRuleNotFound   = errors.New("regra de moderação não encontrada")
+	ErrModerationFlagNotFound   = errors.New("sinalização não encontrada ou já resolvida")
+	ErrInvalidModerationKind    = errors.New("tipo de regra inválido (use words ou regex)")
+	ErrInvalidModerationAction  = errors.New("ação inválida (use block, mask ou flag)")
+	ErrInvalidModerationPattern = errors.New("padrão da regra inválido")
+)
+
+// MaxModerationPattern limita o tamanho do padrão de cada regra
+const MaxModerationPattern = 2000
+
+// moderationMaskRune substitui os caracteres mascarados; não é marcador de Markdown,
+// então o trecho mascarado não altera a formatação da mensagem
+const moderationMaskRune = '•'
+
+// FilterVerdict é o que um filtro encontrou no conteúdo e a ação pedida
+type FilterVerdict struct {
+	Action string // block, mask ou flag
+	// Conteúdo com os trechos mascarados (apenas na ação mask)
+	Content string
+	RuleID  string
+	Reason  string
+}
+
+// MessageFilter analisa uma mensagem antes da gravação. Recebe as regras da sala, que pode
+// ignorar, e retorna um veredito por regra acionada; nenhum quando o conteúdo está liberado.
+type MessageFilter interface {
+	Filter(message *models.Message, rules []*models.ModerationRule) ([]FilterVerdict, error)
+}
+
+// ModerationResult é o resultado da moderação de uma mensagem
+type ModerationResult struct {
+	Blocked bool
+	Reason  string
+	// Sinalizações a gravar depois que a mensagem for salva
+	Flags []FilterVerdict
+}
+
+// ModerationService executa os filtros de conteúdo das salas e mantém a fila de revisão.
+// Os filtros de lista de palavras e de expressão regular vêm registrados; outros podem
+// ser acrescentados com AddFilter.
+type ModerationService struct {
+	moderationRepo *repository.ModerationRepository
+	messageRepo    *repository.MessageRepository
+	roomRepo       *repository.RoomRepository
+	userRepo       *repository.UserRepository
+	filters        []MessageFilter
+}
+
+func NewModerationService(moderationRepo *repository.ModerationRepository, messageRepo *repository.MessageRepository, roomRepo *repository.RoomRepository, userRepo *repository.UserRepository) *ModerationService {
+	return &ModerationService{
+		moderationRepo: moderationRepo,
+		messageRepo:    messageRepo,
+		roomRepo:       roomRepo,
+		userRepo:       userRepo,
+		filters:        []MessageFilter{&WordListFilter{}, &RegexFilter{}},
+	}
+}
+
+// AddFilter acrescenta um filtro ao fim da cadeia
+func (s *ModerationService) AddFilter(filter MessageFilter) {
+	s.filters = append(s.filters, filter)
+}
+
+// Moderate passa a mensagem pelos filtros, na ordem. Um bloqueio interrompe a cadeia; máscaras
+// alteram o conteúdo da mensagem (e o que os filtros seguintes recebem).
+func (s *ModerationService) Moderate(message *models.Message) (*ModerationResult, error) {
+	rules, err := s.moderationRepo.GetRules(message.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ModerationResult{}
+	for _, filter := range s.filters {
+		verdicts, err := filter.Filter(message, rules)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, verdict := range verdicts {
+			switch verdict.Action {
+			case models.ModerationActionBlock:
+				log.Printf("🛡️ Mensagem de %s bloqueada na sala %s: %s", message.UserID, message.RoomID, verdict.Reason)
+				return &ModerationResult{Blocked: true, Reason: verdict.Reason}, nil
+			case models.ModerationActionMask:
+				message.SetContent(verdict.Content)
+			case models.ModerationActionFlag:
+				result.Flags = append(result.Flags, verdict)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Flag grava as sinalizações da mensagem já salva
+func (s *ModerationService) Flag(message *models.Message, result *ModerationResult) {
+	for _, verdict := range result.Flags {
+		flag := models.NewModerationFlag(message, verdict.RuleID, verdict.Reason)
+		if err := s.moderationRepo.CreateFlag(flag); err != nil {
+			log.Printf("❌ Erro ao sinalizar mensagem %s: %v", message.ID, err)
+			continue
+		}
+		log.Printf("🛡️ Mensagem %s sinalizada para revisão: %s", message.ID, verdict.Reason)
+	}
+}
+
+// CreateRule cadastra um filtro na sala; permitido ao moderador (criador) da sala e a administradores
+func (s *ModerationService) CreateRule(roomID, kind, pattern, action, userID string) (*models.ModerationRule, error) {
+	if kind != models.ModerationKindWords && kind != models.ModerationKindRegex {
+		return nil, ErrInvalidModerationKind
+	}
+	if action != models.ModerationActionBlock && action != models.ModerationActionMask && action != models.ModerationActionFlag {
+		return nil, ErrInvalidModerationAction
+	}
+
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || len(pattern) > MaxModerationPattern {
+		return nil, ErrInvalidModerationPattern
+	}
+	switch kind {
+	case models.ModerationKindWords:
+		if len(parseWordList(pattern)) == 0 {
+			return nil, ErrInvalidModerationPattern
+		}
+	case models.ModerationKindRegex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidModerationPattern, err)
+		}
+	}
+
+	if err := s.authorize(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	rule := models.NewModerationRule(roomID, kind, pattern, action, userID)
+	if err := s.moderationRepo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🛡️ Regra de moderação %s (%s, %s) criada na sala %s por %s", rule.ID, kind, action, roomID, userID)
+	return rule, nil
+}
+
+func (s *ModerationService) GetRules(roomID string) ([]*models.ModerationRule, error) {
+	return s.moderationRepo.GetRules(roomID)
+}
+
+func (s *ModerationService) DeleteRule(roomID, id, userID string) error {
+	if err := s.authorize(roomID, userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.moderationRepo.DeleteRule(roomID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrModerationRuleNotFound
+	}
+
+	log.Printf("🛡️ Regra de moderação %s removida da sala %s por %s", id, roomID, userID)
+	return nil
+}
+
+// GetFlags lista a fila de revisão com as mensagens sinalizadas
+func (s *ModerationService) GetFlags(status string, limit, offset int) ([]*models.ModerationFlag, error) {
+	flags, err := s.moderationRepo.GetFlags(status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, flag := range flags {
+		message, err := s.messageRepo.GetByID(flag.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		flag.Message = message
+	}
+	return flags, nil
+}
+
+// ResolveFlag tira a mensagem da fila de revisão, registrando quem a revisou
+func (s *ModerationService) ResolveFlag(id, reviewedBy string) (*models.ModerationFlag, error) {
+	resolved, err := s.moderationRepo.ResolveFlag(id, reviewedBy, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return nil, ErrModerationFlagNotFound
+	}
+
+	log.Printf("🛡️ Sinalização %s resolvida por %s", id, reviewedBy)
+	return s.moderationRepo.GetFlag(id)
+}
+
+func (s *ModerationService) authorize(roomID, userID string) error {
+	room, err := s.roomRepo.GetByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room == nil {
+		return ErrModerationRoomNotFound
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrModerationUserNotFound
+	}
+
+	if user.Role != "admin" && room.CreatedBy != user.ID {
+		return ErrModerationForbidden
+	}
+	return nil
+}
+
+// WordListFilter aplica as regras "words": palavras ou expressões inteiras, sem diferenciar maiúsculas.
+// A busca é feita no texto exibido da mensagem (sem a formatação Markdown e com os escapes resolvidos),
+// então "b**a**d" não escapa da regra "bad" e "mal\_dito" cai na regra "mal_dito".
+type WordListFilter struct {
+	compiled sync.Map // padrão -> *regexp.Regexp
+}
+
+func (f *WordListFilter) Filter(message *models.Message, rules []*models.ModerationRule) ([]FilterVerdict, error) {
+	var verdicts []FilterVerdict
+	current := message.Content
+	plain, positions := markdown.PlainText(current)
+	for _, rule := range rules {
+		if rule.Kind != models.ModerationKindWords {
+			continue
+		}
+
+		re := f.regex(rule.Pattern)
+		if re == nil {
+			continue
+		}
+
+		content := []rune(current)
+		spans, matched := wordSpans(re, plain, positions, content)
+		if len(spans) == 0 {
+			continue
+		}
+
+		verdict := ruleVerdict(rule, content, spans, matched)
+		if verdict.Action == models.ModerationActionMask {
+			current = verdict.Content
+			plain, positions = markdown.PlainText(current)
+		}
+		verdicts = append(verdicts, verdict)
+	}
+	return verdicts, nil
+}
+
+// regex compila a lista de palavras em uma única expressão; nil quando a lista está vazia.
+// Como \b do RE2 só considera ASCII, os limites de palavra são os próprios caracteres vizinhos.
+func (f *WordListFilter) regex(pattern string) *regexp.Regexp {
+	if cached, ok := f.compiled.Load(pattern); ok {
+		return cached.(*regexp.Regexp)
+	}
+
+	words := parseWordList(pattern)
+	if len(words) == 0 {
+		return nil
+	}
+	// As mais longas primeiro, para que "mau caráter" ganhe de "mau"
+	sort.SliceStable(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+
+	re := regexp.MustCompile(`(?i)[^\p{L}\p{Nd}_](` + strings.Join(words, "|") + `)[^\p{L}\p{Nd}_]`)
+	f.compiled.Store(pattern, re)
+	return re
+}
+
+// wordSpans procura as palavras da regra no texto exibido e devolve os trechos correspondentes
+// no conteúdo, além do texto da primeira ocorrência
+func wordSpans(re *regexp.Regexp, plain string, positions []int, content []rune) ([][2]int, string) {
+	text := " " + plain + " "
+
+	// Posição em runas de cada byte do texto
+	runeAt := make([]int, len(text)+1)
+	n := 0
+	for i := range text {
+		runeAt[i] = n
+		n++
+	}
+	runeAt[len(text)] = n
+
+	var spans [][2]int
+	var matched string
+	for offset := 0; offset < len(text); {
+		match := re.FindStringSubmatchIndex(text[offset:])
+		if match == nil {
+			break
+		}
+		start, end := offset+match[2], offset+match[3]
+		if matched == "" {
+			matched = text[start:end]
+		}
+		// O primeiro caractere do texto é o espaço acrescentado
+		spans = append(spans, contentSpans(positions[runeAt[start]-1:runeAt[end]-1], content)...)
+		// O caractere que encerrou a ocorrência pode iniciar a próxima
+		offset = end
+	}
+	return spans, matched
+}
+
+// contentSpans agrupa as posições no conteúdo em trechos contínuos. Um escape ("\_")
+// entra junto com o caractere escapado, para que a barra não sobre sozinha ao mascarar.
+func contentSpans(positions []int, content []rune) [][2]int {
+	var spans [][2]int
+	for i, pos := range positions {
+		if pos < 0 {
+			continue
+		}
+		start := pos
+		if pos > 0 && content[pos-1] == '\\' && (i == 0 || positions[i-1] != pos-1) {
+			start = pos - 1
+		}
+		if last := len(spans) - 1; last >= 0 && spans[last][1] == start {
+			spans[last][1] = pos + 1
+			continue
+		}
+		spans = append(spans, [2]int{start, pos + 1})
+	}
+	return spans
+}
+
+// RegexFilter aplica as regras "regex" (sintaxe RE2, tempo linear; use (?i) para ignorar maiúsculas)
+type RegexFilter struct {
+	compiled sync.Map // padrão -> *regexp.Regexp
+}
+
+func (f *RegexFilter) Filter(message *models.Message, rules []*models.ModerationRule) ([]FilterVerdict, error) {
+	var verdicts []FilterVerdict
+	current := message.Content
+	for _, rule := range rules {
+		if rule.Kind != models.ModerationKindRegex {
+			continue
+		}
+
+		re, err := f.regex(rule.Pattern)
+		if err != nil {
+			log.Printf("⚠️ Regra de moderação %s com expressão inválida: %v", rule.ID, err)
+			continue
+		}
+
+		matches := re.FindAllStringIndex(current, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		// Converter posições em bytes para posições em runas
+		var spans [][2]int
+		for _, match := range matches {
+			start := len([]rune(current[:match[0]]))
+			spans = append(spans, [2]int{start, start + len([]rune(current[match[0]:match[1]]))})
+		}
+
+		verdict := ruleVerdict(rule, []rune(current), spans, current[matches[0][0]:matches[0][1]])
+		if verdict.Action == models.ModerationActionMask {
+			current = verdict.Content
+		}
+		verdicts = append(verdicts, verdict)
+	}
+	return verdicts, nil
+}
+
+func (f *RegexFilter) regex(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := f.compiled.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	f.compiled.Store(pattern, re)
+	return re, nil
+}
+
+// ruleVerdict monta o veredito da regra para os trechos [início, fim) encontrados no conteúdo;
+// matched é o texto da primeira ocorrência, usado no motivo
+func ruleVerdict(rule *models.ModerationRule, content []rune, spans [][2]int, matched string) FilterVerdict {
+	verdict := FilterVerdict{
+		Action: rule.Action,
+		RuleID: rule.ID,
+		Reason: fmt.Sprintf("contém %q", matched),
+	}
+
+	if rule.Action == models.ModerationActionMask {
+		masked := append([]rune(nil), content...)
+		for _, span := range spans {
+			for i := span[0]; i < span[1]; i++ {
+				if !unicode.IsSpace(masked[i]) {
+					masked[i] = moderationMaskRune
+				}
+			}
+		}
+		verdict.Content = string(masked)
+	}
+	return verdict
+}
+
+// parseWordList separa a lista de palavras (vírgulas ou quebras de linha), em minúsculas
+func parseWordList(pattern string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(pattern, func(c rune) bool { return c == ',' || c == '\n' }) {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+func TestWordListFilterMatchesDisplayedText(t *testing.T) {
+	cases := []struct {
+		pattern string
+		content string
+		want    string // conteúdo mascarado; vazio quando a regra não é acionada
+	}{
+		{"palavrão", "sem Palavrão aqui", "sem •••••••• aqui"},
+		{"palavrão", "sem p**alav**rão aqui", "sem •**••••**••• aqui"},
+		{"mal_dito", `é mal\_dito`, "é •••••••••"},
+		{"ruim", "[ru](https://exemplo.com)im", "[••](https://exemplo.com)••"},
+		{"ruim", "`ruim` e ~~ruim~~", "`••••` e ~~••••~~"},
+		{"mau, mau caráter", "um mau caráter", "um ••• •••••••"},
+		{"a+b", "conta a+b.", "conta •••."},
+		{"ruim", "ruimzinho e ruim_sim", ""},
+		{"ruim", "ruim ruim", "•••• ••••"},
+	}
+
+	f := &WordListFilter{}
+	for _, tc := range cases {
+		rule := models.NewModerationRule("sala", models.ModerationKindWords, tc.pattern, models.ModerationActionMask, "moderador")
+		message := models.NewMessage(tc.content, "autor", "autor", "", "text", "sala")
+
+		verdicts, err := f.Filter(message, []*models.ModerationRule{rule})
+		if err != nil {
+			t.Fatalf("erro ao filtrar %q: %v", tc.content, err)
+		}
+		if tc.want == "" {
+			if len(verdicts) != 0 {
+				t.Errorf("%q acionou a regra %q: %+v", tc.content, tc.pattern, verdicts)
+			}
+			continue
+		}
+		if len(verdicts) != 1 || verdicts[0].Content != tc.want {
+			t.Errorf("%q com a regra %q = %+v, esperado %q", tc.content, tc.pattern, verdicts, tc.want)
+		}
+	}
+}
+
+func TestWordListFilterLargeMessage(t *testing.T) {
+	words := make([]string, 200)
+	for i := range words {
+		words[i] = strings.Repeat("x", 20) + string(rune('a'+i%26)) + strings.Repeat("y", i%7)
+	}
+	rule := models.NewModerationRule("sala", models.ModerationKindWords, strings.Join(words, ","), models.ModerationActionFlag, "moderador")
+	message := models.NewMessage(strings.Repeat("xxxxxxxxxx ", 900)+"proibido", "autor", "autor", "", "text", "sala")
+
+	started := time.Now()
+	verdicts, err := (&WordListFilter{}).Filter(message, []*models.ModerationRule{rule})
+	if err != nil {
+		t.Fatalf("erro ao filtrar: %v", err)
+	}
+	if len(verdicts) != 0 {
+		t.Fatalf("vereditos inesperados: %+v", verdicts)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("filtro levou %v", elapsed)
+	}
+}
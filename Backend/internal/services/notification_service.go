@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+var ErrInvalidNotificationType = errors.New("tipo de notificação inválido")
+
+// notificationBodyLimit limita o trecho da mensagem copiado para a notificação
+const notificationBodyLimit = 140
+
+// NotificationNotifier entrega notificações às sessões conectadas do usuário
+type NotificationNotifier interface {
+	DeliverNotification(notification *models.Notification)
+}
+
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	notifier         NotificationNotifier
+}
+
+func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// SetNotifier define quem entrega as notificações em tempo real (o hub WebSocket)
+func (s *NotificationService) SetNotifier(notifier NotificationNotifier) {
+	s.notifier = notifier
+}
+
+// Notify grava a notificação e a entrega às sessões do usuário. Retorna nil, sem erro,
+// quando o usuário desabilitou o tipo nas preferências.
+func (s *NotificationService) Notify(notification *models.Notification) (*models.Notification, error) {
+	enabled, err := s.notificationRepo.IsEnabled(notification.UserID, notification.Type)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	if err := s.notificationRepo.Create(notification); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔔 Notificação %s (%s) para %s", notification.ID, notification.Type, notification.UserID)
+	if s.notifier != nil {
+		s.notifier.DeliverNotification(notification)
+	}
+	return notification, nil
+}
+
+// NotifyMention avisa o usuário mencionado
+func (s *NotificationService) NotifyMention(mention *models.Mention) {
+	message := mention.Message
+	title := fmt.Sprintf("%s mencionou você", message.Username)
+	if mention.Kind != models.MentionKindUser {
+		title = fmt.Sprintf("%s mencionou @%s", message.Username, mention.Kind)
+	}
+
+	s.notifyAboutMessage(mention.UserID, models.NotificationTypeMention, title, message)
+}
+
+// NotifyReply avisa o autor da mensagem respondida
+func (s *NotificationService) NotifyReply(reply, parent *models.Message) {
+	if parent.UserID == "" || parent.UserID == reply.UserID || parent.AuthorDeleted {
+		return
+	}
+
+	title := fmt.Sprintf("%s respondeu sua mensagem", reply.Username)
+	s.notifyAboutMessage(parent.UserID, models.NotificationTypeReply, title, reply)
+}
+
+// NotifyInvite convida o usuário para a sala
+func (s *NotificationService) NotifyInvite(room *models.Room, userID string, invitedBy *models.User) (*models.Notification, error) {
+	notification := models.NewNotification(userID, models.NotificationTypeInvite,
+		fmt.Sprintf("%s convidou você para %s", invitedBy.Username, room.Name), room.Description)
+	notification.RoomID = room.ID
+	notification.ActorID = invitedBy.ID
+
+	return s.Notify(notification)
+}
+
+// NotifyRoleChange avisa o usuário que sua role mudou
+func (s *NotificationService) NotifyRoleChange(userID, role string) {
+	notification := models.NewNotification(userID, models.NotificationTypeRoleChange,
+		"Sua role foi alterada", fmt.Sprintf("Nova role: %s", role))
+
+	if _, err := s.Notify(notification); err != nil {
+		log.Printf("❌ Erro ao notificar mudança de role: %v", err)
+	}
+}
+
+func (s *NotificationService) notifyAboutMessage(userID, notificationType, title string, message *models.Message) {
+	notification := models.NewNotification(userID, notificationType, title, excerpt(message.Content))
+	notification.RoomID = message.RoomID
+	notification.MessageID = message.ID
+	notification.ActorID = message.UserID
+
+	if _, err := s.Notify(notification); err != nil {
+		log.Printf("❌ Erro ao criar notificação %s: %v", notificationType, err)
+	}
+}
+
+// GetForUser lista as notificações do usuário e o total de não lidas
+func (s *NotificationService) GetForUser(userID string, unreadOnly bool, limit, offset int) ([]*models.Notification, int, error) {
+	notifications, err := s.notificationRepo.GetByUser(userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, unread, nil
+}
+
+// MarkRead marca notificações como lidas (todas, se ids for vazio)
+func (s *NotificationService) MarkRead(userID string, ids []string) (int64, error) {
+	return s.notificationRepo.MarkRead(userID, ids)
+}
+
+// Clear remove notificações do usuário (as informadas, ou todas; apenas lidas se readOnly)
+func (s *NotificationService) Clear(userID string, ids []string, readOnly bool) (int64, error) {
+	return s.notificationRepo.Clear(userID, ids, readOnly)
+}
+
+// Preferences retorna a preferência de cada tipo de notificação; tipos sem preferência gravada ficam habilitados
+func (s *NotificationService) Preferences(userID string) (map[string]bool, error) {
+	stored, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]bool, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		enabled, ok := stored[notificationType]
+		preferences[notificationType] = !ok || enabled
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences altera as preferências informadas e retorna o conjunto completo
+func (s *NotificationService) UpdatePreferences(userID string, preferences map[string]bool) (map[string]bool, error) {
+	for notificationType := range preferences {
+		if !models.IsNotificationType(notificationType) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidNotificationType, notificationType)
+		}
+	}
+
+	if err := s.notificationRepo.SetPreferences(userID, preferences); err != nil {
+		return nil, err
+	}
+
+	return s.Preferences(userID)
+}
+
+// excerpt encurta o texto para o corpo da notificação, sem cortar caracteres multibyte
+func excerpt(content string) string {
+	content = strings.TrimSpace(content)
+	runes := []rune(content)
+	if len(runes) <= notificationBodyLimit {
+		return content
+	}
+	return string(runes[:notificationBodyLimit-1]) + "…"
+}
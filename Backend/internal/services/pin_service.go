@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+var (
+	ErrPinRoomNotFound    = errors.New("sala não encontrada")
+	ErrPinMessageNotFound = errors.New("mensagem não encontrada na sala")
+	ErrPinUserNotFound    = errors.New("usuário não encontrado")
+	ErrPinForbidden       = errors.New("apenas o moderador da sala ou administradores podem fixar mensagens")
+	ErrPinLimitReached    = errors.New("limite de mensagens fixadas atingido")
+	ErrPinNotFound        = errors.New("mensagem não está fixada")
+	ErrPinRoomArchived    = errors.New("sala arquivada")
+)
+
+// PinNotifier avisa os clientes conectados quando mensagens são fixadas ou desafixadas
+type PinNotifier interface {
+	BroadcastMessagePinned(pin *models.PinnedMessage)
+	BroadcastMessageUnpinned(roomID, messageID, unpinnedBy string)
+}
+
+type PinService struct {
+	pinRepo     *repository.PinRepository
+	messageRepo *repository.MessageRepository
+	roomRepo    *repository.RoomRepository
+	userRepo    *repository.UserRepository
+	limit       int
+	notifier    PinNotifier
+}
+
+func NewPinService(pinRepo *repository.PinRepository, messageRepo *repository.MessageRepository, roomRepo *repository.RoomRepository, userRepo *repository.UserRepository, limit int) *PinService {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	return &PinService{
+		pinRepo:     pinRepo,
+		messageRepo: messageRepo,
+		roomRepo:    roomRepo,
+		userRepo:    userRepo,
+		limit:       limit,
+	}
+}
+
+// SetNotifier define quem avisa os clientes sobre fixações (o hub WebSocket)
+func (s *PinService) SetNotifier(notifier PinNotifier) {
+	s.notifier = notifier
+}
+
+// Limit retorna o máximo de mensagens fixadas por sala
+func (s *PinService) Limit() int {
+	return s.limit
+}
+
+// Pin fixa a mensagem na sala. Fixar uma mensagem já fixada retorna a fixação existente.
+func (s *PinService) Pin(roomID, messageID, userID string) (*models.PinnedMessage, error) {
+	if _, err := s.authorize(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	message, err := s.messageRepo.GetByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil || message.RoomID != roomID {
+		return nil, ErrPinMessageNotFound
+	}
+
+	existing, err := s.pinRepo.Get(roomID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		existing.Message = message
+		return existing, nil
+	}
+
+	pin := models.NewPinnedMessage(message, userID)
+	created, err := s.pinRepo.Create(pin, s.limit)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrPinLimitReached
+	}
+
+	log.Printf("📌 Mensagem %s fixada na sala %s por %s", messageID, roomID, userID)
+	if s.notifier != nil {
+		s.notifier.BroadcastMessagePinned(pin)
+	}
+	return pin, nil
+}
+
+// Unpin desafixa a mensagem da sala
+func (s *PinService) Unpin(roomID, messageID, userID string) error {
+	if _, err := s.authorize(roomID, userID); err != nil {
+		return err
+	}
+
+	removed, err := s.pinRepo.Delete(roomID, messageID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrPinNotFound
+	}
+
+	log.Printf("📌 Mensagem %s desafixada da sala %s por %s", messageID, roomID, userID)
+	if s.notifier != nil {
+		s.notifier.BroadcastMessageUnpinned(roomID, messageID, userID)
+	}
+	return nil
+}
+
+func (s *PinService) GetByRoom(roomID string) ([]*models.PinnedMessage, error) {
+	return s.pinRepo.GetByRoom(roomID)
+}
+
+// authorize garante que a sala existe, está ativa e que o usuário é o moderador (criador) da sala ou administrador
+func (s *PinService) authorize(roomID, userID string) (*models.Room, error) {
+	room, err := s.roomRepo.GetByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil {
+		return nil, ErrPinRoomNotFound
+	}
+	if room.Archived() {
+		return nil, ErrPinRoomArchived
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrPinUserNotFound
+	}
+
+	if user.Role != "admin" && room.CreatedBy != user.ID {
+		return nil, ErrPinForbidden
+	}
+
+	return room, nil
+}
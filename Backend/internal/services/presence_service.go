@@ -0,0 +1,287 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// Status de presença
+const (
+	StatusOnline  = "online"
+	StatusAway    = "away"
+	StatusOffline = "offline"
+)
+
+// PresenceNotifier entrega eventos de presença aos clientes conectados
+type PresenceNotifier interface {
+	BroadcastPresence(roomIDs []string, userID, status string, lastSeenAt time.Time)
+}
+
+type presenceSession struct {
+	roomID       string
+	lastActivity time.Time
+}
+
+type userPresence struct {
+	status   string
+	sessions map[string]*presenceSession
+	// Incrementada a cada sessão aberta ou encerrada
+	version int
+}
+
+// userLock serializa as transições de um usuário: a mudança de status, a gravação e o aviso
+// às salas acontecem na mesma ordem para todas
+type userLock struct {
+	sync.Mutex
+	refs int
+}
+
+// PresenceService acompanha a atividade de cada sessão WebSocket e deriva o status do usuário:
+// online enquanto alguma sessão tiver atividade recente, away quando todas estiverem ociosas
+// por mais de awayAfter e offline quando a última sessão for encerrada.
+type PresenceService struct {
+	userRepo  *repository.UserRepository
+	notifier  PresenceNotifier
+	awayAfter time.Duration
+
+	mutex sync.Mutex
+	users map[string]*userPresence
+	locks map[string]*userLock
+}
+
+func NewPresenceService(userRepo *repository.UserRepository, awayAfter time.Duration) *PresenceService {
+	return &PresenceService{
+		userRepo:  userRepo,
+		awayAfter: awayAfter,
+		users:     make(map[string]*userPresence),
+		locks:     make(map[string]*userLock),
+	}
+}
+
+// SetNotifier define quem entrega os eventos presence_changed (o hub WebSocket)
+func (s *PresenceService) SetNotifier(notifier PresenceNotifier) {
+	s.notifier = notifier
+}
+
+// Run verifica periodicamente as sessões ociosas e marca os usuários como away
+func (s *PresenceService) Run() {
+	log.Printf("👀 Serviço de presença iniciado (away após %v)", s.awayAfter)
+
+	interval := s.awayAfter / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.markIdleUsers(now)
+	}
+}
+
+// Connect registra uma nova sessão do usuário
+func (s *PresenceService) Connect(userID, sessionID, roomID string) {
+	unlock := s.lockUser(userID)
+	defer unlock()
+
+	now := time.Now().UTC()
+
+	s.mutex.Lock()
+	presence := s.users[userID]
+	if presence == nil {
+		presence = &userPresence{status: StatusOffline, sessions: make(map[string]*presenceSession)}
+		s.users[userID] = presence
+	}
+	presence.sessions[sessionID] = &presenceSession{roomID: roomID, lastActivity: now}
+	presence.version++
+	changed := presence.status != StatusOnline
+	presence.status = StatusOnline
+	roomIDs := presence.roomIDs()
+	s.mutex.Unlock()
+
+	if changed {
+		s.publish(roomIDs, userID, StatusOnline, now)
+	}
+}
+
+// Disconnect remove a sessão; sem sessões restantes o usuário fica offline
+func (s *PresenceService) Disconnect(userID, sessionID string) {
+	unlock := s.lockUser(userID)
+	defer unlock()
+
+	now := time.Now().UTC()
+
+	s.mutex.Lock()
+	presence := s.users[userID]
+	if presence == nil {
+		s.mutex.Unlock()
+		return
+	}
+
+	session := presence.sessions[sessionID]
+	delete(presence.sessions, sessionID)
+	presence.version++
+
+	if len(presence.sessions) > 0 {
+		s.mutex.Unlock()
+		return
+	}
+
+	delete(s.users, userID)
+	s.mutex.Unlock()
+
+	// Avisar a sala que a última sessão deixou
+	var roomIDs []string
+	if session != nil {
+		roomIDs = []string{session.roomID}
+	}
+	s.publish(roomIDs, userID, StatusOffline, now)
+}
+
+// Touch registra atividade na sessão, trazendo o usuário de volta de away
+func (s *PresenceService) Touch(userID, sessionID string) {
+	unlock := s.lockUser(userID)
+	defer unlock()
+
+	now := time.Now().UTC()
+
+	s.mutex.Lock()
+	presence := s.users[userID]
+	if presence == nil {
+		s.mutex.Unlock()
+		return
+	}
+
+	if session := presence.sessions[sessionID]; session != nil {
+		session.lastActivity = now
+	}
+
+	changed := presence.status == StatusAway
+	presence.status = StatusOnline
+	roomIDs := presence.roomIDs()
+	s.mutex.Unlock()
+
+	if changed {
+		s.publish(roomIDs, userID, StatusOnline, now)
+	}
+}
+
+// GetStatus retorna o status atual do usuário segundo as sessões ativas
+func (s *PresenceService) GetStatus(userID string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if presence := s.users[userID]; presence != nil {
+		return presence.status
+	}
+	return StatusOffline
+}
+
+// markIdleUsers marca como away os usuários cujas sessões estão ociosas. A varredura só anota
+// os candidatos; cada transição é refeita com o lock do usuário e descartada se as sessões
+// mudaram desde a leitura (ex.: a última sessão foi encerrada e o usuário já está offline).
+func (s *PresenceService) markIdleUsers(now time.Time) {
+	type candidate struct {
+		userID   string
+		presence *userPresence
+		version  int
+	}
+	var candidates []candidate
+
+	s.mutex.Lock()
+	for userID, presence := range s.users {
+		if presence.status == StatusOnline && now.Sub(presence.lastActivity()) >= s.awayAfter {
+			candidates = append(candidates, candidate{userID: userID, presence: presence, version: presence.version})
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, c := range candidates {
+		s.markIdle(c.userID, c.presence, c.version, now)
+	}
+}
+
+func (s *PresenceService) markIdle(userID string, seen *userPresence, version int, now time.Time) {
+	unlock := s.lockUser(userID)
+	defer unlock()
+
+	s.mutex.Lock()
+	presence := s.users[userID]
+	if presence != seen || presence.version != version || presence.status != StatusOnline {
+		s.mutex.Unlock()
+		return
+	}
+
+	lastActivity := presence.lastActivity()
+	if now.Sub(lastActivity) < s.awayAfter {
+		s.mutex.Unlock()
+		return
+	}
+	presence.status = StatusAway
+	roomIDs := presence.roomIDs()
+	s.mutex.Unlock()
+
+	s.publish(roomIDs, userID, StatusAway, lastActivity)
+}
+
+// lockUser obtém o lock de transições do usuário; a função retornada o libera
+func (s *PresenceService) lockUser(userID string) func() {
+	s.mutex.Lock()
+	lock := s.locks[userID]
+	if lock == nil {
+		lock = &userLock{}
+		s.locks[userID] = lock
+	}
+	lock.refs++
+	s.mutex.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		s.mutex.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(s.locks, userID)
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// publish persiste o novo status e avisa as salas onde o usuário está presente
+func (s *PresenceService) publish(roomIDs []string, userID, status string, lastSeenAt time.Time) {
+	if err := s.userRepo.UpdatePresence(userID, status, lastSeenAt); err != nil {
+		log.Printf("❌ Erro ao atualizar presença de %s: %v", userID, err)
+	}
+
+	log.Printf("👀 Usuário %s agora está %s", userID, status)
+
+	if s.notifier != nil {
+		s.notifier.BroadcastPresence(roomIDs, userID, status, lastSeenAt)
+	}
+}
+
+func (p *userPresence) lastActivity() time.Time {
+	var last time.Time
+	for _, session := range p.sessions {
+		if session.lastActivity.After(last) {
+			last = session.lastActivity
+		}
+	}
+	return last
+}
+
+func (p *userPresence) roomIDs() []string {
+	seen := make(map[string]bool, len(p.sessions))
+	roomIDs := make([]string, 0, len(p.sessions))
+	for _, session := range p.sessions {
+		if !seen[session.roomID] {
+			seen[session.roomID] = true
+			roomIDs = append(roomIDs, session.roomID)
+		}
+	}
+	return roomIDs
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// recordingPresenceNotifier guarda os status publicados, em ordem
+type recordingPresenceNotifier struct {
+	mutex    sync.Mutex
+	statuses []string
+}
+
+func (n *recordingPresenceNotifier) BroadcastPresence(roomIDs []string, userID, status string, lastSeenAt time.Time) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.statuses = append(n.statuses, status)
+}
+
+func (n *recordingPresenceNotifier) last() string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if len(n.statuses) == 0 {
+		return ""
+	}
+	return n.statuses[len(n.statuses)-1]
+}
+
+func newTestPresenceService(t *testing.T) (*PresenceService, *repository.UserRepository, *recordingPresenceNotifier, *models.User) {
+	t.Helper()
+
+	db := newTestDatabase(t)
+	userRepo := repository.NewUserRepository(db.DB)
+	user := models.NewUser("presente", "presente@exemplo.com", "")
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("erro ao criar usuário: %v", err)
+	}
+
+	notifier := &recordingPresenceNotifier{}
+	s := NewPresenceService(userRepo, time.Minute)
+	s.SetNotifier(notifier)
+	return s, userRepo, notifier, user
+}
+
+func storedStatus(t *testing.T, userRepo *repository.UserRepository, userID string) string {
+	t.Helper()
+
+	user, err := userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		t.Fatalf("erro ao buscar usuário: %v", err)
+	}
+	return user.Status
+}
+
+func TestPresenceIdleSweepDropsStaleTransitions(t *testing.T) {
+	s, userRepo, notifier, user := newTestPresenceService(t)
+	later := time.Now().Add(time.Hour)
+
+	// A varredura leu o usuário online e a última sessão foi encerrada antes da transição
+	s.Connect(user.ID, "sessao-1", "sala")
+	seen := s.users[user.ID]
+	s.Disconnect(user.ID, "sessao-1")
+	s.markIdle(user.ID, seen, seen.version, later)
+
+	if got := notifier.last(); got != StatusOffline {
+		t.Fatalf("último status publicado = %q, esperado offline", got)
+	}
+	if got := storedStatus(t, userRepo, user.ID); got != StatusOffline {
+		t.Fatalf("status gravado = %q, esperado offline", got)
+	}
+
+	// Reconectado depois da leitura: a presença é outra e a transição também é descartada
+	s.Connect(user.ID, "sessao-2", "sala")
+	s.markIdle(user.ID, seen, seen.version, later)
+	if got := s.GetStatus(user.ID); got != StatusOnline {
+		t.Fatalf("status = %q, esperado online", got)
+	}
+
+	// Nova sessão aberta depois da leitura
+	seen = s.users[user.ID]
+	version := seen.version
+	s.Connect(user.ID, "sessao-3", "sala")
+	s.markIdle(user.ID, seen, version, later)
+	if got := s.GetStatus(user.ID); got != StatusOnline {
+		t.Fatalf("status = %q, esperado online", got)
+	}
+
+	// Sem mudanças nas sessões a transição vale
+	s.markIdle(user.ID, seen, seen.version, later)
+	if got := s.GetStatus(user.ID); got != StatusAway {
+		t.Fatalf("status = %q, esperado away", got)
+	}
+	if got := storedStatus(t, userRepo, user.ID); got != StatusAway {
+		t.Fatalf("status gravado = %q, esperado away", got)
+	}
+}
+
+func TestPresenceDisconnectDuringSweepEndsOffline(t *testing.T) {
+	s, userRepo, notifier, user := newTestPresenceService(t)
+
+	for i := 0; i < 20; i++ {
+		s.Connect(user.ID, "sessao", "sala")
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.markIdleUsers(time.Now().Add(time.Hour))
+		}()
+		go func() {
+			defer wg.Done()
+			s.Disconnect(user.ID, "sessao")
+		}()
+		wg.Wait()
+
+		if got := notifier.last(); got != StatusOffline {
+			t.Fatalf("rodada %d: último status publicado = %q, esperado offline", i, got)
+		}
+		if got := storedStatus(t, userRepo, user.ID); got != StatusOffline {
+			t.Fatalf("rodada %d: status gravado = %q, esperado offline", i, got)
+		}
+	}
+}
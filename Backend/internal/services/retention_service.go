@@ -0,0 +1,231 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// Modos de retenção: apagar de vez ou mover para messages_archive
+const (
+	RetentionModeDelete  = "delete"
+	RetentionModeArchive = "archive"
+)
+
+// RetentionConfig define a política global e o ritmo do worker
+type RetentionConfig struct {
+	DefaultDays int           // 0 mantém as mensagens para sempre
+	Mode        string        // delete ou archive
+	Interval    time.Duration // intervalo entre execuções
+	BatchSize   int           // mensagens examinadas por lote
+	BatchPause  time.Duration // pausa entre lotes para não bloquear quem escreve
+}
+
+// RoomPurge resume o expurgo de uma sala
+type RoomPurge struct {
+	RoomID        string `json:"room_id"`
+	RoomName      string `json:"room_name"`
+	RetentionDays int    `json:"retention_days"`
+	Purged        int64  `json:"purged"`
+	Error         string `json:"error,omitempty"`
+}
+
+// RetentionReport resume uma execução do worker
+type RetentionReport struct {
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt time.Time   `json:"finished_at"`
+	Mode       string      `json:"mode"`
+	Purged     int64       `json:"purged"`
+	Rooms      []RoomPurge `json:"rooms"`
+}
+
+// RetentionStats é o estado exposto na rota administrativa
+type RetentionStats struct {
+	DefaultDays int              `json:"default_days"`
+	Mode        string           `json:"mode"`
+	Interval    string           `json:"interval"`
+	Running     bool             `json:"running"`
+	TotalPurged int64            `json:"total_purged"`
+	LastRun     *RetentionReport `json:"last_run"`
+}
+
+// RetentionService apaga ou arquiva periodicamente as mensagens mais antigas que a
+// retenção da sala (ou a global, quando a sala não define a sua)
+type RetentionService struct {
+	roomRepo    *repository.RoomRepository
+	messageRepo *repository.MessageRepository
+	config      RetentionConfig
+
+	trigger chan struct{}
+
+	mutex       sync.Mutex
+	running     bool
+	totalPurged int64
+	lastRun     *RetentionReport
+}
+
+func NewRetentionService(roomRepo *repository.RoomRepository, messageRepo *repository.MessageRepository, config RetentionConfig) *RetentionService {
+	if config.Mode != RetentionModeArchive {
+		config.Mode = RetentionModeDelete
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+
+	return &RetentionService{
+		roomRepo:    roomRepo,
+		messageRepo: messageRepo,
+		config:      config,
+		trigger:     make(chan struct{}, 1),
+	}
+}
+
+// Run executa o expurgo no intervalo configurado ou quando disparado pela rota administrativa
+func (s *RetentionService) Run() {
+	log.Printf("🧹 Retenção iniciada (global: %d dias, modo: %s, a cada %v)", s.config.DefaultDays, s.config.Mode, s.config.Interval)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.trigger:
+		}
+		s.Purge()
+	}
+}
+
+// Trigger agenda uma execução imediata; retorna false se uma já estiver em andamento
+func (s *RetentionService) Trigger() bool {
+	s.mutex.Lock()
+	running := s.running
+	s.mutex.Unlock()
+	if running {
+		return false
+	}
+
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (s *RetentionService) Stats() RetentionStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return RetentionStats{
+		DefaultDays: s.config.DefaultDays,
+		Mode:        s.config.Mode,
+		Interval:    s.config.Interval.String(),
+		Running:     s.running,
+		TotalPurged: s.totalPurged,
+		LastRun:     s.lastRun,
+	}
+}
+
+// SetRoomRetention define a retenção de uma sala; nil volta a usar a política global
+func (s *RetentionService) SetRoomRetention(roomID string, days *int) error {
+	return s.roomRepo.UpdateRetention(roomID, days)
+}
+
+// EffectiveDays retorna a retenção aplicada à sala (0 = para sempre)
+func (s *RetentionService) EffectiveDays(room *models.Room) int {
+	if room.RetentionDays != nil {
+		return *room.RetentionDays
+	}
+	return s.config.DefaultDays
+}
+
+// Purge executa uma passada completa por todas as salas
+func (s *RetentionService) Purge() *RetentionReport {
+	s.mutex.Lock()
+	if s.running {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.running = true
+	s.mutex.Unlock()
+
+	report := &RetentionReport{StartedAt: time.Now().UTC(), Mode: s.config.Mode, Rooms: []RoomPurge{}}
+
+	rooms, err := s.roomRepo.GetAll()
+	if err != nil {
+		log.Printf("❌ Retenção: erro ao listar salas: %v", err)
+	}
+
+	for _, room := range rooms {
+		days := s.EffectiveDays(room)
+		if days <= 0 {
+			continue
+		}
+
+		purged, err := s.purgeRoom(room, days)
+		roomReport := RoomPurge{RoomID: room.ID, RoomName: room.Name, RetentionDays: days, Purged: purged}
+		if err != nil {
+			log.Printf("❌ Retenção: erro na sala %s: %v", room.Name, err)
+			roomReport.Error = err.Error()
+		}
+		if purged > 0 || err != nil {
+			report.Rooms = append(report.Rooms, roomReport)
+		}
+		report.Purged += purged
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	log.Printf("🧹 Retenção: %d mensagens removidas (%s) em %v", report.Purged, report.Mode, report.FinishedAt.Sub(report.StartedAt))
+
+	s.mutex.Lock()
+	s.running = false
+	s.totalPurged += report.Purged
+	s.lastRun = report
+	s.mutex.Unlock()
+
+	return report
+}
+
+// purgeRoom percorre a sala em lotes; cada lote é uma transação curta seguida de uma pausa,
+// para que o envio de mensagens não fique bloqueado durante o expurgo
+func (s *RetentionService) purgeRoom(room *models.Room, days int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	archive := s.config.Mode == RetentionModeArchive
+
+	var purged int64
+	var afterRowID int64
+
+	for {
+		ids, lastRowID, err := s.messageRepo.ExpiredBatch(room.ID, afterRowID, cutoff, s.config.BatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		count, err := s.messageRepo.Purge(room.ID, ids, archive)
+		if err != nil {
+			return purged, err
+		}
+		purged += count
+
+		if len(ids) < s.config.BatchSize {
+			break
+		}
+		afterRowID = lastRowID
+
+		if s.config.BatchPause > 0 {
+			time.Sleep(s.config.BatchPause)
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("🧹 Retenção: %d mensagens de %s (mais de %d dias)", purged, room.Name, days)
+	}
+
+	return purged, nil
+}
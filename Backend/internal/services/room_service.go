@@ -1,12 +1,22 @@
 package services
 
 import (
+	"log"
+	"time"
+
 	"github.com/rafael-bit/whatz/internal/models"
 	"github.com/rafael-bit/whatz/internal/repository"
 )
 
+// RoomNotifier avisa os clientes conectados sobre mudanças de estado da sala
+type RoomNotifier interface {
+	BroadcastRoomArchived(roomID string, archivedAt *time.Time)
+	BroadcastRoomUpdated(room *models.Room)
+}
+
 type RoomService struct {
 	roomRepo *repository.RoomRepository
+	notifier RoomNotifier
 }
 
 func NewRoomService(roomRepo *repository.RoomRepository) *RoomService {
@@ -15,6 +25,59 @@ func NewRoomService(roomRepo *repository.RoomRepository) *RoomService {
 	}
 }
 
+// SetNotifier define quem avisa os clientes sobre alterações e arquivamento (o hub WebSocket)
+func (s *RoomService) SetNotifier(notifier RoomNotifier) {
+	s.notifier = notifier
+}
+
+// Archive torna a sala somente leitura e avisa quem está conectado nela
+func (s *RoomService) Archive(room *models.Room) error {
+	if room.Archived() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.roomRepo.SetArchived(room.ID, &now); err != nil {
+		return err
+	}
+	room.ArchivedAt = &now
+
+	log.Printf("🗄️ Sala %s arquivada", room.Name)
+	if s.notifier != nil {
+		s.notifier.BroadcastRoomArchived(room.ID, room.ArchivedAt)
+	}
+	return nil
+}
+
+// Unarchive reativa a sala arquivada
+func (s *RoomService) Unarchive(room *models.Room) error {
+	if !room.Archived() {
+		return nil
+	}
+
+	if err := s.roomRepo.SetArchived(room.ID, nil); err != nil {
+		return err
+	}
+	room.ArchivedAt = nil
+
+	log.Printf("🗄️ Sala %s reativada", room.Name)
+	if s.notifier != nil {
+		s.notifier.BroadcastRoomArchived(room.ID, nil)
+	}
+	return nil
+}
+
+// VisibleRooms remove as salas arquivadas da listagem, a menos que includeArchived seja true
+func VisibleRooms(rooms []*models.Room, includeArchived bool) []*models.Room {
+	visible := make([]*models.Room, 0, len(rooms))
+	for _, room := range rooms {
+		if includeArchived || !room.Archived() {
+			visible = append(visible, room)
+		}
+	}
+	return visible
+}
+
 func (s *RoomService) Create(room *models.Room) error {
 	return s.roomRepo.Create(room)
 }
@@ -35,8 +98,16 @@ func (s *RoomService) GetByCreator(createdBy string) ([]*models.Room, error) {
 	return s.roomRepo.GetByCreator(createdBy)
 }
 
+// Update grava a sala e avisa quem está conectado nela
 func (s *RoomService) Update(room *models.Room) error {
-	return s.roomRepo.Update(room)
+	if err := s.roomRepo.Update(room); err != nil {
+		return err
+	}
+
+	if s.notifier != nil {
+		s.notifier.BroadcastRoomUpdated(room)
+	}
+	return nil
 }
 
 func (s *RoomService) Delete(id string) error {
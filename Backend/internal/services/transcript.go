@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+// Formatos aceitos na exportação de transcrições
+var TranscriptFormats = map[string]TranscriptFormat{
+	"json": {Extension: "json", ContentType: "application/json; charset=utf-8"},
+	"csv":  {Extension: "csv", ContentType: "text/csv; charset=utf-8"},
+	"md":   {Extension: "md", ContentType: "text/markdown; charset=utf-8"},
+	"html": {Extension: "html", ContentType: "text/html; charset=utf-8"},
+}
+
+// TranscriptFormat descreve o arquivo gerado para um formato
+type TranscriptFormat struct {
+	Extension   string
+	ContentType string
+}
+
+// TranscriptRange limita a transcrição a um intervalo de datas (limites opcionais e inclusivos)
+type TranscriptRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// Mensagens lidas por consulta ao gerar a transcrição
+const transcriptPageSize = 500
+
+// transcriptWriter escreve uma transcrição em um formato específico
+type transcriptWriter interface {
+	begin(room *models.Room) error
+	message(message *models.Message) error
+	end() error
+}
+
+// WriteTranscript escreve em w a transcrição da sala no formato pedido, lendo as mensagens
+// do banco em páginas curtas para não manter uma leitura aberta durante o download
+func (s *MessageService) WriteTranscript(w io.Writer, room *models.Room, format string, period TranscriptRange) error {
+	var writer transcriptWriter
+	switch format {
+	case "json":
+		writer = &jsonTranscript{w: w}
+	case "csv":
+		writer = &csvTranscript{w: csv.NewWriter(w)}
+	case "md":
+		writer = &markdownTranscript{w: w}
+	case "html":
+		writer = &htmlTranscript{w: w}
+	default:
+		return fmt.Errorf("formato de transcrição desconhecido: %s", format)
+	}
+
+	if err := writer.begin(room); err != nil {
+		return err
+	}
+
+	var last *models.Message
+	for {
+		messages, err := s.messageRepo.GetRoomPage(room.ID, last, period.From, period.To, transcriptPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if err := writer.message(message); err != nil {
+				return err
+			}
+		}
+
+		if len(messages) < transcriptPageSize {
+			break
+		}
+		last = messages[len(messages)-1]
+	}
+
+	return writer.end()
+}
+
+type jsonTranscript struct {
+	w     io.Writer
+	count int
+}
+
+func (t *jsonTranscript) begin(room *models.Room) error {
+	data, err := json.Marshal(room)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(t.w, `{"room":%s,"messages":[`, data)
+	return err
+}
+
+func (t *jsonTranscript) message(message *models.Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if t.count > 0 {
+		data = append([]byte(","), data...)
+	}
+	t.count++
+	_, err = t.w.Write(data)
+	return err
+}
+
+func (t *jsonTranscript) end() error {
+	_, err := fmt.Fprintf(t.w, `],"count":%d}`, t.count)
+	return err
+}
+
+type csvTranscript struct {
+	w *csv.Writer
+}
+
+func (t *csvTranscript) begin(room *models.Room) error {
+	return t.w.Write([]string{"id", "created_at", "user_id", "username", "type", "content"})
+}
+
+func (t *csvTranscript) message(message *models.Message) error {
+	return t.w.Write([]string{
+		message.ID,
+		message.CreatedAt.UTC().Format(time.RFC3339),
+		message.UserID,
+		message.Username,
+		message.Type,
+		message.Content,
+	})
+}
+
+func (t *csvTranscript) end() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+type markdownTranscript struct {
+	w io.Writer
+}
+
+func (t *markdownTranscript) begin(room *models.Room) error {
+	_, err := fmt.Fprintf(t.w, "# %s\n\n", room.Name)
+	if err == nil && room.Description != "" {
+		_, err = fmt.Fprintf(t.w, "%s\n\n", room.Description)
+	}
+	return err
+}
+
+func (t *markdownTranscript) message(message *models.Message) error {
+	_, err := fmt.Fprintf(t.w, "**%s** — %s\n\n%s\n\n",
+		message.Username,
+		message.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"),
+		strings.TrimSpace(message.Content),
+	)
+	return err
+}
+
+func (t *markdownTranscript) end() error {
+	return nil
+}
+
+type htmlTranscript struct {
+	w io.Writer
+}
+
+func (t *htmlTranscript) begin(room *models.Room) error {
+	name := html.EscapeString(room.Name)
+	_, err := fmt.Fprintf(t.w, `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; }
+.message { margin-bottom: 1rem; }
+.meta { color: #666; font-size: 0.85rem; }
+.content { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p>%s</p>
+`, name, name, html.EscapeString(room.Description))
+	return err
+}
+
+func (t *htmlTranscript) message(message *models.Message) error {
+	_, err := fmt.Fprintf(t.w, `<div class="message"><div class="meta"><strong>%s</strong> · <time datetime="%s">%s</time></div><div class="content">%s</div></div>
+`,
+		html.EscapeString(message.Username),
+		message.CreatedAt.UTC().Format(time.RFC3339),
+		message.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"),
+		html.EscapeString(message.Content),
+	)
+	return err
+}
+
+func (t *htmlTranscript) end() error {
+	_, err := io.WriteString(t.w, "</body>\n</html>\n")
+	return err
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/markdown"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+	"golang.org/x/net/html"
+)
+
+var (
+	ErrUnfurlBlockedAddress = errors.New("endereço de rede não permitido")
+	ErrUnfurlNotHTML        = errors.New("conteúdo não é uma página HTML")
+	ErrUnfurlNoMetadata     = errors.New("página sem metadados de prévia")
+)
+
+const (
+	// Buscas que falharam ficam em cache por menos tempo que as prévias válidas
+	unfurlFailureTTL = 10 * time.Minute
+	unfurlQueueSize  = 256
+	unfurlRedirects  = 3
+
+	maxPreviewTitle       = 300
+	maxPreviewDescription = 500
+)
+
+// Faixas especiais que net/netip não classifica como privadas
+var unfurlBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// UnfurlConfig define os limites da busca de prévias
+type UnfurlConfig struct {
+	Timeout  time.Duration // prazo total de cada busca (conexão, redirecionamentos e leitura)
+	MaxBytes int64         // bytes lidos da página, no máximo
+	MaxLinks int           // links com prévia por mensagem
+	CacheTTL time.Duration // validade de uma prévia em cache
+	Workers  int           // buscas simultâneas
+	// Libera endereços privados e de loopback (apenas desenvolvimento e testes com servidor local)
+	AllowPrivate bool
+}
+
+// UnfurlNotifier recebe a mensagem com as prévias atualizadas
+type UnfurlNotifier interface {
+	BroadcastMessageUpdated(message *models.Message)
+}
+
+// UnfurlService busca em segundo plano os metadados OpenGraph/Twitter Card dos links das mensagens.
+// As conexões só são abertas para endereços públicos (verificados após a resolução de DNS) e os
+// resultados, inclusive falhas, ficam em cache por URL.
+type UnfurlService struct {
+	previewRepo *repository.LinkPreviewRepository
+	messageRepo *repository.MessageRepository
+	notifier    UnfurlNotifier
+	config      UnfurlConfig
+	client      *http.Client
+	queue       chan *models.Message
+}
+
+func NewUnfurlService(previewRepo *repository.LinkPreviewRepository, messageRepo *repository.MessageRepository, config UnfurlConfig) *UnfurlService {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 512 * 1024
+	}
+	if config.MaxLinks <= 0 {
+		config.MaxLinks = 3
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = 24 * time.Hour
+	}
+	if config.Workers <= 0 {
+		config.Workers = 2
+	}
+
+	s := &UnfurlService{
+		previewRepo: previewRepo,
+		messageRepo: messageRepo,
+		config:      config,
+		queue:       make(chan *models.Message, unfurlQueueSize),
+	}
+
+	s.client = newPublicHTTPClient(config.Timeout, config.AllowPrivate)
+	return s
+}
+
+// newPublicHTTPClient cria o cliente das requisições a URLs informadas pelos usuários
+// (prévias de links e callbacks de comandos). Sem allowPrivate, as conexões só são abertas
+// para endereços públicos, verificados após a resolução de DNS.
+func newPublicHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = checkUnfurlAddress
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= unfurlRedirects {
+				return fmt.Errorf("redirecionamentos demais")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirecionamento para esquema não permitido: %s", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+func (s *UnfurlService) SetNotifier(notifier UnfurlNotifier) {
+	s.notifier = notifier
+}
+
+// Enqueue agenda a busca das prévias da mensagem. Com a fila cheia a mensagem fica sem prévia.
+func (s *UnfurlService) Enqueue(message *models.Message) {
+	if len(unfurlLinks(message, s.config.MaxLinks)) == 0 && len(message.Previews) == 0 {
+		return
+	}
+
+	select {
+	case s.queue <- message:
+	default:
+		log.Printf("⚠️ Fila de prévias cheia, mensagem %s ficará sem prévia", message.ID)
+	}
+}
+
+// Run processa a fila de prévias com o número configurado de workers
+func (s *UnfurlService) Run() {
+	log.Printf("🔗 Prévias de links iniciadas (%d workers, timeout %v, até %d bytes)", s.config.Workers, s.config.Timeout, s.config.MaxBytes)
+
+	for i := 1; i < s.config.Workers; i++ {
+		go s.work()
+	}
+	s.work()
+}
+
+func (s *UnfurlService) work() {
+	for message := range s.queue {
+		s.unfurl(message)
+	}
+}
+
+// unfurl monta as prévias dos links da mensagem e, se mudaram, grava e notifica a sala
+func (s *UnfurlService) unfurl(message *models.Message) {
+	previews := []models.LinkPreview{}
+	for _, link := range unfurlLinks(message, s.config.MaxLinks) {
+		if preview := s.preview(link); preview != nil {
+			previews = append(previews, *preview)
+		}
+	}
+
+	current, err := s.messageRepo.GetByID(message.ID)
+	if err != nil {
+		log.Printf("❌ Erro ao buscar mensagem %s para prévia: %v", message.ID, err)
+		return
+	}
+	// Mensagem removida ou editada durante a busca: a edição agenda uma nova busca
+	if current == nil || current.Content != message.Content {
+		return
+	}
+	if samePreviews(current.Previews, previews) {
+		return
+	}
+
+	if err := s.messageRepo.SetPreviews(current.ID, previews); err != nil {
+		log.Printf("❌ Erro ao salvar prévias da mensagem %s: %v", current.ID, err)
+		return
+	}
+	current.Previews = previews
+
+	if s.notifier != nil {
+		s.notifier.BroadcastMessageUpdated(current)
+	}
+}
+
+// preview retorna a prévia do link, do cache quando válido; nil se a página não tem prévia
+func (s *UnfurlService) preview(link string) *models.LinkPreview {
+	cached, err := s.previewRepo.Get(link)
+	if err != nil {
+		log.Printf("❌ Erro ao consultar cache de prévias: %v", err)
+	}
+	if cached != nil {
+		ttl := s.config.CacheTTL
+		if cached.Error != "" {
+			ttl = min(ttl, unfurlFailureTTL)
+		}
+		if time.Since(cached.FetchedAt) < ttl {
+			if cached.Error != "" {
+				return nil
+			}
+			return cached
+		}
+	}
+
+	preview, err := s.fetch(link)
+	if err != nil {
+		log.Printf("⚠️ Prévia de %s indisponível: %v", link, err)
+		preview = &models.LinkPreview{URL: link, Error: err.Error()}
+	}
+	preview.FetchedAt = time.Now().UTC()
+
+	if err := s.previewRepo.Save(preview); err != nil {
+		log.Printf("❌ Erro ao salvar prévia no cache: %v", err)
+	}
+
+	if preview.Error != "" {
+		return nil
+	}
+	return preview
+}
+
+// fetch baixa a página (até MaxBytes) e extrai os metadados de prévia
+func (s *UnfurlService) fetch(link string) (*models.LinkPreview, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Whatz-LinkPreview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("resposta HTTP %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrUnfurlNotHTML
+	}
+
+	meta := parsePreviewMeta(io.LimitReader(resp.Body, s.config.MaxBytes))
+
+	preview := &models.LinkPreview{
+		URL:         link,
+		Title:       truncateRunes(firstNonEmpty(meta["og:title"], meta["twitter:title"], meta["title"]), maxPreviewTitle),
+		Description: truncateRunes(firstNonEmpty(meta["og:description"], meta["twitter:description"], meta["description"]), maxPreviewDescription),
+		SiteName:    truncateRunes(meta["og:site_name"], maxPreviewTitle),
+		Image:       resolvePreviewImage(resp.Request.URL, firstNonEmpty(meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"])),
+	}
+	if preview.Title == "" {
+		return nil, ErrUnfurlNoMetadata
+	}
+	return preview, nil
+}
+
+// checkUnfurlAddress é chamado a cada conexão, já com o IP resolvido, e recusa destinos
+// não públicos. Verificar aqui (e não no nome do host) cobre redirecionamentos e DNS rebinding.
+func checkUnfurlAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return ErrUnfurlBlockedAddress
+	}
+
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return ErrUnfurlBlockedAddress
+	}
+	for _, prefix := range unfurlBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return ErrUnfurlBlockedAddress
+		}
+	}
+	return nil
+}
+
+// parsePreviewMeta lê o <head> da página e retorna as metatags og:*, twitter:*, description e o <title>.
+// Vale a primeira ocorrência de cada chave.
+func parsePreviewMeta(body io.Reader) map[string]string {
+	meta := map[string]string{}
+	tokenizer := html.NewTokenizer(body)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return meta
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				return meta
+			case "title":
+				inTitle = true
+			case "meta":
+				var key, content string
+				for _, attr := range token.Attr {
+					switch attr.Key {
+					case "property", "name":
+						if key == "" {
+							key = strings.ToLower(strings.TrimSpace(attr.Val))
+						}
+					case "content":
+						content = collapseSpaces(attr.Val)
+					}
+				}
+				if key != "" && content != "" && meta[key] == "" && key != "title" {
+					meta[key] = content
+				}
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "head":
+				return meta
+			case "title":
+				inTitle = false
+			}
+
+		case html.TextToken:
+			if inTitle && meta["title"] == "" {
+				meta["title"] = collapseSpaces(string(tokenizer.Text()))
+			}
+		}
+	}
+}
+
+// unfurlLinks retorna os links http(s) da mensagem, sem repetição, até o limite
+func unfurlLinks(message *models.Message, limit int) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, entity := range message.Entities {
+		if entity.Type != markdown.EntityLink || seen[entity.URL] {
+			continue
+		}
+		scheme := strings.ToLower(strings.SplitN(entity.URL, ":", 2)[0])
+		if scheme != "http" && scheme != "https" {
+			continue
+		}
+
+		seen[entity.URL] = true
+		links = append(links, entity.URL)
+		if len(links) == limit {
+			break
+		}
+	}
+	return links
+}
+
+// resolvePreviewImage torna a URL da imagem absoluta e descarta esquemas que não sejam http(s)
+func resolvePreviewImage(base *url.URL, raw string) string {
+	if raw == "" {
+		return ""
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return resolved.String()
+}
+
+func samePreviews(a, b []models.LinkPreview) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].URL != b[i].URL || a[i].Title != b[i].Title || a[i].Description != b[i].Description ||
+			a[i].Image != b[i].Image || a[i].SiteName != b[i].SiteName {
+			return false
+		}
+	}
+	return true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func collapseSpaces(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
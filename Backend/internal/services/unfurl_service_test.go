@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+func newTestUnfurlService(t *testing.T, config UnfurlConfig) *UnfurlService {
+	t.Helper()
+
+	db := newTestDatabase(t)
+	return NewUnfurlService(repository.NewLinkPreviewRepository(db.DB), repository.NewMessageRepository(db.DB), config)
+}
+
+func serveHTML(page string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+}
+
+func TestCheckUnfurlAddress(t *testing.T) {
+	blocked := []string{
+		"127.0.0.1:80",
+		"10.1.2.3:80",
+		"172.16.0.1:80",
+		"192.168.0.10:443",
+		"169.254.169.254:80",
+		"100.64.0.1:80",
+		"0.0.0.0:80",
+		"[::1]:80",
+		"[::ffff:127.0.0.1]:80",
+		"[fd00::1]:80",
+		"[fe80::1]:80",
+		"localhost:80",
+	}
+	for _, address := range blocked {
+		if err := checkUnfurlAddress("tcp", address, nil); !errors.Is(err, ErrUnfurlBlockedAddress) {
+			t.Errorf("checkUnfurlAddress(%s) = %v, esperado bloqueio", address, err)
+		}
+	}
+
+	allowed := []string{"93.184.216.34:443", "8.8.8.8:80", "[2606:4700:4700::1111]:443"}
+	for _, address := range allowed {
+		if err := checkUnfurlAddress("tcp", address, nil); err != nil {
+			t.Errorf("checkUnfurlAddress(%s) = %v, esperado permitido", address, err)
+		}
+	}
+}
+
+func TestUnfurlRejectsLoopback(t *testing.T) {
+	var hit atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit.Store(true)
+	}))
+	defer server.Close()
+
+	s := newTestUnfurlService(t, UnfurlConfig{})
+	if _, err := s.fetch(server.URL); !errors.Is(err, ErrUnfurlBlockedAddress) {
+		t.Fatalf("fetch(%s) = %v, esperado bloqueio do endereço de loopback", server.URL, err)
+	}
+	if hit.Load() {
+		t.Fatal("a requisição chegou ao servidor de loopback")
+	}
+
+	// A falha também fica em cache e a mensagem fica sem prévia
+	if preview := s.preview(server.URL); preview != nil {
+		t.Fatalf("preview = %+v, esperado nil", preview)
+	}
+	cached, err := s.previewRepo.Get(server.URL)
+	if err != nil || cached == nil || cached.Error == "" {
+		t.Fatalf("cache = %+v (%v), esperada a falha registrada", cached, err)
+	}
+}
+
+func TestUnfurlParsesOpenGraph(t *testing.T) {
+	server := serveHTML(`<!DOCTYPE html>
+<html><head>
+	<title>Título da página</title>
+	<meta property="og:title" content="  Título
+		OpenGraph ">
+	<meta name="twitter:description" content="Descrição do Twitter">
+	<meta property="og:site_name" content="Exemplo">
+	<meta property="og:image" content="/img/capa.png">
+</head><body>
+	<meta property="og:description" content="fora do head">
+</body></html>`)
+	defer server.Close()
+
+	s := newTestUnfurlService(t, UnfurlConfig{AllowPrivate: true})
+	preview, err := s.fetch(server.URL + "/artigo")
+	if err != nil {
+		t.Fatalf("erro ao buscar prévia: %v", err)
+	}
+
+	if preview.Title != "Título OpenGraph" {
+		t.Errorf("title = %q", preview.Title)
+	}
+	if preview.Description != "Descrição do Twitter" {
+		t.Errorf("description = %q", preview.Description)
+	}
+	if preview.SiteName != "Exemplo" {
+		t.Errorf("site_name = %q", preview.SiteName)
+	}
+	if want := server.URL + "/img/capa.png"; preview.Image != want {
+		t.Errorf("image = %q, esperado %q", preview.Image, want)
+	}
+}
+
+func TestUnfurlRejectsNonHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"title":"não"}`))
+	}))
+	defer server.Close()
+
+	s := newTestUnfurlService(t, UnfurlConfig{AllowPrivate: true})
+	if _, err := s.fetch(server.URL); err != ErrUnfurlNotHTML {
+		t.Fatalf("fetch = %v, esperado ErrUnfurlNotHTML", err)
+	}
+}
+
+func TestUnfurlReadsAtMostMaxBytes(t *testing.T) {
+	padding := `<meta name="description" content="` + strings.Repeat("a", 4096) + `">`
+	server := serveHTML(`<html><head>` + padding + `<meta property="og:title" content="Depois do limite"></head></html>`)
+	defer server.Close()
+
+	s := newTestUnfurlService(t, UnfurlConfig{AllowPrivate: true, MaxBytes: 1024})
+	if _, err := s.fetch(server.URL); err != ErrUnfurlNoMetadata {
+		t.Fatalf("fetch = %v, esperado ErrUnfurlNoMetadata (título além de MaxBytes)", err)
+	}
+
+	s = newTestUnfurlService(t, UnfurlConfig{AllowPrivate: true, MaxBytes: 8192})
+	if preview, err := s.fetch(server.URL); err != nil || preview.Title != "Depois do limite" {
+		t.Fatalf("fetch = %+v, %v; com limite maior o título deveria ser lido", preview, err)
+	}
+}
+
+func TestUnfurlTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	s := newTestUnfurlService(t, UnfurlConfig{AllowPrivate: true, Timeout: 200 * time.Millisecond})
+
+	start := time.Now()
+	_, err := s.fetch(server.URL)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("fetch de servidor lento deveria falhar")
+	}
+	if elapsed > time.Second {
+		t.Fatalf("fetch levou %v; esperado o limite de 200ms", elapsed)
+	}
+}
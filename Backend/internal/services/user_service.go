@@ -1,19 +1,42 @@
 package services
 
 import (
+	"errors"
+	"log"
+	"os"
 	"time"
 
 	"github.com/rafael-bit/whatz/internal/models"
 	"github.com/rafael-bit/whatz/internal/repository"
 )
 
+// ProfileNotifier propaga alterações de perfil para os clientes conectados
+type ProfileNotifier interface {
+	BroadcastProfileUpdate(user *models.User)
+	CloseAccountSessions(userID string) int
+}
+
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo      *repository.UserRepository
+	notifier      ProfileNotifier
+	notifications *NotificationService
+
+	// Política padrão para as mensagens de contas removidas (anonymize ou delete)
+	messagePolicy string
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
+var (
+	ErrInvalidMessagePolicy = errors.New("política de mensagens inválida")
+	ErrInvalidRoomHeir      = errors.New("usuário para receber as salas não encontrado")
+)
+
+func NewUserService(userRepo *repository.UserRepository, messagePolicy string) *UserService {
+	if messagePolicy == "" {
+		messagePolicy = repository.MessagePolicyAnonymize
+	}
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:      userRepo,
+		messagePolicy: messagePolicy,
 	}
 }
 
@@ -33,27 +56,130 @@ func (s *UserService) GetAll() ([]*models.User, error) {
 	return s.userRepo.GetAll()
 }
 
+// SetNotifier define quem propaga as alterações de perfil (o hub WebSocket)
+func (s *UserService) SetNotifier(notifier ProfileNotifier) {
+	s.notifier = notifier
+}
+
+// SetNotifications define a central de notificações usada para avisar mudanças de role
+func (s *UserService) SetNotifications(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
+// Update grava o perfil completo, recusando username ou email usados por outro usuário
 func (s *UserService) Update(user *models.User) error {
+	existing, err := s.userRepo.GetByUsername(user.Username)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != user.ID {
+		return repository.ErrUsernameTaken
+	}
+
+	existing, err = s.userRepo.GetByEmail(user.Email)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != user.ID {
+		return repository.ErrEmailTaken
+	}
+
 	user.UpdatedAt = time.Now()
-	return s.userRepo.UpdateStatus(user.ID, user.Status)
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	if s.notifier != nil {
+		s.notifier.BroadcastProfileUpdate(user)
+	}
+
+	return nil
 }
 
-func (s *UserService) Delete(id string) error {
-	return s.userRepo.Delete(id)
+// Delete remove a conta aplicando a política de mensagens (a padrão quando opts.MessagePolicy
+// é vazio) e transferindo as salas; depois encerra as sessões WebSocket ainda abertas
+func (s *UserService) Delete(id string, opts repository.AccountDeletion) (*repository.AccountDeletionResult, error) {
+	if opts.MessagePolicy == "" {
+		opts.MessagePolicy = s.messagePolicy
+	}
+	if opts.MessagePolicy != repository.MessagePolicyAnonymize && opts.MessagePolicy != repository.MessagePolicyDelete {
+		return nil, ErrInvalidMessagePolicy
+	}
+
+	if opts.ReassignTo != "" {
+		heir, err := s.userRepo.GetByID(opts.ReassignTo)
+		if err != nil {
+			return nil, err
+		}
+		if heir == nil || heir.ID == id {
+			return nil, ErrInvalidRoomHeir
+		}
+	}
+
+	result, err := s.userRepo.DeleteAccount(id, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range result.ExportFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("❌ Erro ao apagar exportação %s: %v", path, err)
+		}
+	}
+
+	if s.notifier != nil {
+		if closed := s.notifier.CloseAccountSessions(id); closed > 0 {
+			log.Printf("🔌 %d sessão(ões) de %s encerradas após remoção da conta", closed, id)
+		}
+	}
+
+	log.Printf("🗑️ Conta %s removida (mensagens: %s, %d afetadas; %d salas transferidas)",
+		id, result.MessagePolicy, result.MessagesAffected, result.RoomsReassigned)
+
+	return result, nil
 }
 
 func (s *UserService) UpdateTags(id, tags string) error {
 	return s.userRepo.UpdateTags(id, tags)
 }
 
+// UpdateRole altera a role e notifica o usuário quando ela de fato muda
 func (s *UserService) UpdateRole(id, role string) error {
-	return s.userRepo.UpdateRole(id, role)
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateRole(id, role); err != nil {
+		return err
+	}
+
+	if user != nil && user.Role != role && s.notifications != nil {
+		s.notifications.NotifyRoleChange(id, role)
+	}
+	return nil
 }
 
 func (s *UserService) GetByRole(role string) ([]*models.User, error) {
 	return s.userRepo.GetByRole(role)
 }
 
+// CreateBot cria uma conta de serviço para integrações
+func (s *UserService) CreateBot(username, avatar string) (*models.User, error) {
+	bot := models.NewBotUser(username, avatar)
+	if err := s.userRepo.Create(bot); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🤖 Conta de serviço %s criada", bot.Username)
+	return bot, nil
+}
+
+// GetBots lista as contas de serviço
+func (s *UserService) GetBots() ([]*models.User, error) {
+	return s.userRepo.GetByKind(models.UserKindBot)
+}
+
 func (s *UserService) UpdateStatus(id, status string) error {
 	return s.userRepo.UpdateStatus(id, status)
 }
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// countRows conta as linhas da tabela que satisfazem a condição
+func countRows(t *testing.T, db *sql.DB, table, where string, args ...interface{}) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE `+where, args...).Scan(&count); err != nil {
+		t.Fatalf("erro ao contar %s: %v", table, err)
+	}
+	return count
+}
+
+// messageWithReferences grava uma mensagem do autor, fixada e mencionando other, com o evento new_message
+func messageWithReferences(t *testing.T, db *sql.DB, author, other *models.User, room *models.Room) *models.Message {
+	t.Helper()
+
+	message := models.NewMessage("oi @"+other.Username, author.ID, author.Username, "", "text", room.ID)
+	if err := repository.NewMessageRepository(db).Create(message); err != nil {
+		t.Fatalf("erro ao criar mensagem: %v", err)
+	}
+	if _, err := repository.NewPinRepository(db).Create(models.NewPinnedMessage(message, other.ID), 10); err != nil {
+		t.Fatalf("erro ao fixar mensagem: %v", err)
+	}
+	if err := repository.NewMentionRepository(db).CreateBatch([]*models.Mention{models.NewMention(message, other.ID, models.MentionKindUser)}); err != nil {
+		t.Fatalf("erro ao criar menção: %v", err)
+	}
+	if _, err := repository.NewEventRepository(db).Append(room.ID, "new_message", message); err != nil {
+		t.Fatalf("erro ao gravar evento: %v", err)
+	}
+	return message
+}
+
+func newTestUsersAndRoom(t *testing.T, userRepo *repository.UserRepository, roomRepo *repository.RoomRepository) (*models.User, *models.User, *models.Room) {
+	t.Helper()
+
+	author := models.NewUser("autor", "autor@exemplo.com", "")
+	other := models.NewUser("outro", "outro@exemplo.com", "")
+	for _, user := range []*models.User{author, other} {
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("erro ao criar usuário: %v", err)
+		}
+	}
+	room := models.NewRoom("geral", "", "public", other.ID)
+	if err := roomRepo.Create(room); err != nil {
+		t.Fatalf("erro ao criar sala: %v", err)
+	}
+	return author, other, room
+}
+
+func TestDeleteAccountRemovesMessageReferencesAndExports(t *testing.T) {
+	db := newTestDatabase(t)
+	userRepo := repository.NewUserRepository(db.DB)
+	exportRepo := repository.NewExportRepository(db.DB)
+	author, other, room := newTestUsersAndRoom(t, userRepo, repository.NewRoomRepository(db.DB))
+
+	message := messageWithReferences(t, db.DB, author, other, room)
+
+	export := models.NewDataExport(author.ID)
+	if err := exportRepo.Create(export); err != nil {
+		t.Fatalf("erro ao criar exportação: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), export.ID+".zip")
+	if err := os.WriteFile(path, []byte("zip"), 0o600); err != nil {
+		t.Fatalf("erro ao criar arquivo: %v", err)
+	}
+	if err := exportRepo.MarkFinished(export, models.ExportStatusCompleted, path, ""); err != nil {
+		t.Fatalf("erro ao concluir exportação: %v", err)
+	}
+
+	s := NewUserService(userRepo, repository.MessagePolicyDelete)
+	if _, err := s.Delete(author.ID, repository.AccountDeletion{}); err != nil {
+		t.Fatalf("erro ao remover conta: %v", err)
+	}
+
+	if n := countRows(t, db.DB, "pinned_messages", "message_id = ?", message.ID); n != 0 {
+		t.Errorf("%d fixações da mensagem removida sobraram", n)
+	}
+	if n := countRows(t, db.DB, "mentions", "message_id = ?", message.ID); n != 0 {
+		t.Errorf("%d menções da mensagem removida sobraram", n)
+	}
+	if n := countRows(t, db.DB, "room_events", "json_extract(payload, '$.id') = ?", message.ID); n != 0 {
+		t.Errorf("%d eventos com o conteúdo da mensagem removida sobraram", n)
+	}
+	if n := countRows(t, db.DB, "data_exports", "user_id = ?", author.ID); n != 0 {
+		t.Errorf("%d exportações do usuário sobraram", n)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("arquivo da exportação não foi apagado: %v", err)
+	}
+}
+
+func TestDeleteMessageRemovesReferences(t *testing.T) {
+	db := newTestDatabase(t)
+	author, other, room := newTestUsersAndRoom(t, repository.NewUserRepository(db.DB), repository.NewRoomRepository(db.DB))
+
+	message := messageWithReferences(t, db.DB, author, other, room)
+	kept := messageWithReferences(t, db.DB, author, other, room)
+
+	if err := repository.NewMessageRepository(db.DB).Delete(message.ID); err != nil {
+		t.Fatalf("erro ao remover mensagem: %v", err)
+	}
+
+	for _, table := range []string{"pinned_messages", "mentions"} {
+		if n := countRows(t, db.DB, table, "message_id = ?", message.ID); n != 0 {
+			t.Errorf("%d linhas de %s da mensagem removida sobraram", n, table)
+		}
+		if n := countRows(t, db.DB, table, "message_id = ?", kept.ID); n != 1 {
+			t.Errorf("%s da outra mensagem: %d linhas, esperado 1", table, n)
+		}
+	}
+	if n := countRows(t, db.DB, "room_events", "json_extract(payload, '$.id') = ?", message.ID); n != 0 {
+		t.Errorf("%d eventos com o conteúdo da mensagem removida sobraram", n)
+	}
+	if n := countRows(t, db.DB, "room_events", "json_extract(payload, '$.id') = ?", kept.ID); n != 1 {
+		t.Errorf("eventos da outra mensagem: %d, esperado 1", n)
+	}
+}
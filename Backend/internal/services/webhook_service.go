@@ -0,0 +1,571 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+var (
+	ErrInvalidWebhookURL      = errors.New("URL do webhook inválida")
+	ErrWebhookNotFound        = errors.New("webhook não encontrado")
+	ErrWebhookDeliveryMissing = errors.New("entrega não encontrada")
+	ErrWebhookRoomNotFound    = errors.New("sala do webhook não encontrada")
+)
+
+// WebhookSignatureHeader carrega "sha256=<hex>", o HMAC-SHA256 do corpo com o segredo do webhook
+const WebhookSignatureHeader = "X-Whatz-Signature"
+
+// WebhookConfig define o ritmo da fila de entrega
+type WebhookConfig struct {
+	MaxAttempts  int           // tentativas por entrega antes de marcá-la como failed
+	RetryBase    time.Duration // espera antes da 2ª tentativa; dobra a cada falha
+	RetryMax     time.Duration // teto da espera entre tentativas
+	Timeout      time.Duration // prazo de cada requisição
+	PollInterval time.Duration // intervalo entre varreduras da fila
+	BatchSize    int           // entregas buscadas por varredura
+}
+
+// WebhookEnvelope é o corpo JSON enviado aos endpoints
+type WebhookEnvelope struct {
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	RoomID    string          `json:"room_id"`
+	Seq       int64           `json:"seq"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookService entrega os eventos das salas aos webhooks cadastrados. Os eventos chegam pelo
+// outbox gravado junto com o log da sala, viram entregas numa fila persistente e são enviados
+// por um worker por webhook, com novas tentativas em backoff exponencial.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	roomRepo    *repository.RoomRepository
+	config      WebhookConfig
+	client      *http.Client
+	wake        chan struct{}
+	outbox      chan struct{}
+
+	// Webhooks com worker em andamento: no máximo um por webhook, para manter a ordem das entregas
+	busyMutex sync.Mutex
+	busy      map[string]bool
+	workers   sync.WaitGroup
+}
+
+func NewWebhookService(webhookRepo *repository.WebhookRepository, roomRepo *repository.RoomRepository, config WebhookConfig) *WebhookService {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.RetryBase <= 0 {
+		config.RetryBase = 30 * time.Second
+	}
+	if config.RetryMax <= 0 {
+		config.RetryMax = time.Hour
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		roomRepo:    roomRepo,
+		config:      config,
+		client:      &http.Client{Timeout: config.Timeout},
+		wake:        make(chan struct{}, 1),
+		outbox:      make(chan struct{}, 1),
+		busy:        make(map[string]bool),
+	}
+}
+
+// Register cadastra um webhook. Sem segredo informado, um é gerado.
+func (s *WebhookService) Register(rawURL, secret string, eventTypes []string, roomID, createdBy string) (*models.Webhook, error) {
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRoom(roomID); err != nil {
+		return nil, err
+	}
+
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	types, err := encodeEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := models.NewWebhook(rawURL, secret, types, roomID, createdBy)
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🪝 Webhook %s cadastrado para %s por %q", webhook.ID, webhook.URL, createdBy)
+	return webhook, nil
+}
+
+// Update altera destino, filtros e estado do webhook; campos nil não mudam
+func (s *WebhookService) Update(id string, rawURL *string, eventTypes []string, roomID *string, active *bool) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	if rawURL != nil {
+		if err := validateWebhookURL(*rawURL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *rawURL
+	}
+	if eventTypes != nil {
+		types, err := encodeEventTypes(eventTypes)
+		if err != nil {
+			return nil, err
+		}
+		webhook.EventTypes = types
+	}
+	if roomID != nil {
+		if err := s.checkRoom(*roomID); err != nil {
+			return nil, err
+		}
+		webhook.RoomID = *roomID
+	}
+	if active != nil {
+		webhook.Active = *active
+	}
+
+	if err := s.webhookRepo.Update(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) GetAll() ([]*models.Webhook, error) {
+	return s.webhookRepo.GetAll(false)
+}
+
+func (s *WebhookService) GetByID(id string) (*models.Webhook, error) {
+	return s.webhookRepo.GetByID(id)
+}
+
+func (s *WebhookService) Delete(id string) error {
+	return s.webhookRepo.Delete(id)
+}
+
+func (s *WebhookService) GetDeliveries(webhookID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	return s.webhookRepo.GetDeliveries(webhookID, limit, offset)
+}
+
+// GetDelivery retorna a entrega do webhook com o histórico de tentativas
+func (s *WebhookService) GetDelivery(webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.WebhookID != webhookID {
+		return nil, nil
+	}
+	return delivery, nil
+}
+
+// Redeliver recoloca a entrega na fila para envio imediato, mesmo que já tenha sido entregue ou falhado
+func (s *WebhookService) Redeliver(webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrWebhookDeliveryMissing
+	}
+
+	if err := s.webhookRepo.Requeue(delivery.ID); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🪝 Entrega %s reenfileirada", delivery.ID)
+	s.signal()
+	return s.GetDelivery(webhookID, deliveryID)
+}
+
+// RoomEventPublished avisa que há eventos novos no outbox (chamado pelo hub). A distribuição
+// aos webhooks acontece em Run, fora do lock de publicação do hub.
+func (s *WebhookService) RoomEventPublished(event *models.RoomEvent) {
+	select {
+	case s.outbox <- struct{}{}:
+	default:
+	}
+}
+
+// Run distribui os eventos do outbox e processa a fila de entregas. Eventos e entregas
+// pendentes de antes de um reinício são retomados aqui.
+func (s *WebhookService) Run() {
+	log.Printf("🪝 Fila de webhooks iniciada (até %d tentativas, backoff a partir de %v)", s.config.MaxAttempts, s.config.RetryBase)
+
+	go s.runOutbox()
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processDue()
+
+		select {
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WebhookService) runOutbox() {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatchOutbox()
+
+		select {
+		case <-s.outbox:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WebhookService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchOutbox transforma os eventos do outbox em entregas para os webhooks ativos interessados
+func (s *WebhookService) dispatchOutbox() {
+	for {
+		events, throughID, err := s.webhookRepo.OutboxEvents(s.config.BatchSize)
+		if err != nil {
+			log.Printf("❌ Erro ao ler outbox de webhooks: %v", err)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+
+		webhooks, err := s.webhookRepo.GetAll(true)
+		if err != nil {
+			log.Printf("❌ Erro ao buscar webhooks: %v", err)
+			return
+		}
+
+		var deliveries []*models.WebhookDelivery
+		for _, event := range events {
+			for _, webhook := range webhooks {
+				if !webhookMatches(webhook, event) {
+					continue
+				}
+
+				delivery, err := newWebhookDelivery(webhook, event)
+				if err != nil {
+					log.Printf("❌ Erro ao serializar evento para webhook: %v", err)
+					continue
+				}
+				deliveries = append(deliveries, delivery)
+			}
+		}
+
+		if err := s.webhookRepo.Enqueue(deliveries, throughID); err != nil {
+			log.Printf("❌ Erro ao enfileirar entregas de webhook: %v", err)
+			return
+		}
+		if len(deliveries) > 0 {
+			s.signal()
+		}
+
+		if len(events) < s.config.BatchSize {
+			return
+		}
+	}
+}
+
+func newWebhookDelivery(webhook *models.Webhook, event *models.RoomEvent) (*models.WebhookDelivery, error) {
+	id := uuid.New().String()
+	body, err := json.Marshal(WebhookEnvelope{
+		ID:        id,
+		Event:     event.Type,
+		RoomID:    event.RoomID,
+		Seq:       event.Seq,
+		CreatedAt: event.CreatedAt,
+		Data:      json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookDelivery{
+		ID:        id,
+		WebhookID: webhook.ID,
+		EventType: event.Type,
+		RoomID:    event.RoomID,
+		Payload:   string(body),
+		Status:    models.WebhookDeliveryPending,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// processDue inicia um worker para cada webhook com entregas vencidas que ainda não tenha um.
+// Os workers não esperam uns pelos outros: um endpoint lento ou fora do ar atrasa apenas a sua fila.
+func (s *WebhookService) processDue() {
+	webhookIDs, err := s.webhookRepo.DueWebhooks(time.Now())
+	if err != nil {
+		log.Printf("❌ Erro ao buscar entregas de webhook: %v", err)
+		return
+	}
+
+	for _, webhookID := range webhookIDs {
+		s.busyMutex.Lock()
+		if s.busy[webhookID] {
+			s.busyMutex.Unlock()
+			continue
+		}
+		s.busy[webhookID] = true
+		s.busyMutex.Unlock()
+
+		s.workers.Add(1)
+		go s.work(webhookID)
+	}
+}
+
+// work envia, em ordem, as entregas vencidas do webhook até esvaziar a fila ou uma tentativa falhar
+func (s *WebhookService) work(webhookID string) {
+	defer s.workers.Done()
+
+	drained := s.drain(webhookID)
+
+	s.busyMutex.Lock()
+	delete(s.busy, webhookID)
+	s.busyMutex.Unlock()
+
+	// Entregas enfileiradas enquanto o worker terminava ficariam para a próxima varredura
+	if drained {
+		s.signal()
+	}
+}
+
+func (s *WebhookService) drain(webhookID string) bool {
+	for {
+		deliveries, err := s.webhookRepo.Due(webhookID, time.Now(), s.config.BatchSize)
+		if err != nil {
+			log.Printf("❌ Erro ao buscar entregas de webhook: %v", err)
+			return false
+		}
+
+		for _, delivery := range deliveries {
+			if !s.attempt(delivery) {
+				return false
+			}
+		}
+
+		if len(deliveries) < s.config.BatchSize {
+			return true
+		}
+	}
+}
+
+// attempt envia a entrega uma vez e agenda a próxima tentativa em caso de falha. Retorna false
+// quando a entrega não foi feita; se ela voltou para a fila, as demais entregas vencidas do
+// mesmo webhook são adiadas junto.
+func (s *WebhookService) attempt(delivery *models.WebhookDelivery) bool {
+	webhook, err := s.webhookRepo.GetByID(delivery.WebhookID)
+	if err != nil {
+		log.Printf("❌ Erro ao buscar webhook da entrega %s: %v", delivery.ID, err)
+		return false
+	}
+
+	attempt := &models.WebhookAttempt{DeliveryID: delivery.ID, CreatedAt: time.Now().UTC()}
+	delivery.Attempts++
+
+	switch {
+	case webhook == nil:
+		attempt.Error = "webhook removido"
+	case !webhook.Active:
+		attempt.Error = "webhook desativado"
+	default:
+		start := time.Now()
+		attempt.StatusCode, err = s.send(webhook, delivery)
+		attempt.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	}
+
+	if attempt.Error == "" {
+		now := time.Now().UTC()
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttempt = nil
+		delivery.LastError = ""
+	} else if webhook == nil || !webhook.Active || delivery.Attempts >= s.config.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttempt = nil
+		delivery.LastError = attempt.Error
+	} else {
+		next := time.Now().Add(s.backoff(delivery.Attempts)).UTC()
+		delivery.NextAttempt = &next
+		delivery.LastError = attempt.Error
+	}
+
+	if err := s.webhookRepo.RecordAttempt(delivery, attempt); err != nil {
+		log.Printf("❌ Erro ao registrar tentativa da entrega %s: %v", delivery.ID, err)
+		return false
+	}
+
+	if attempt.Error == "" {
+		return true
+	}
+
+	log.Printf("⚠️ Entrega %s (%s) falhou na tentativa %d: %s", delivery.ID, delivery.EventType, delivery.Attempts, attempt.Error)
+	if delivery.NextAttempt != nil {
+		if err := s.webhookRepo.Postpone(delivery.WebhookID, *delivery.NextAttempt); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}
+	return false
+}
+
+// send faz o POST assinado e considera entregue qualquer resposta 2xx
+func (s *WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Whatz-Webhook/1.0")
+	req.Header.Set("X-Whatz-Event", delivery.EventType)
+	req.Header.Set("X-Whatz-Delivery", delivery.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("resposta HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff retorna a espera antes da próxima tentativa: RetryBase dobrando a cada falha, até RetryMax
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	wait := s.config.RetryBase
+	for i := 1; i < attempts && wait < s.config.RetryMax; i++ {
+		wait *= 2
+	}
+	if wait > s.config.RetryMax {
+		wait = s.config.RetryMax
+	}
+	return wait
+}
+
+func (s *WebhookService) checkRoom(roomID string) error {
+	if roomID == "" {
+		return nil
+	}
+
+	room, err := s.roomRepo.GetByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room == nil {
+		return ErrWebhookRoomNotFound
+	}
+	return nil
+}
+
+// SignWebhookPayload calcula o valor do cabeçalho de assinatura ("sha256=<hex>") para o corpo
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookMatches(webhook *models.Webhook, event *models.RoomEvent) bool {
+	if webhook.RoomID != "" && webhook.RoomID != event.RoomID {
+		return false
+	}
+
+	var types []string
+	if err := json.Unmarshal([]byte(webhook.EventTypes), &types); err != nil || len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+func encodeEventTypes(eventTypes []string) (string, error) {
+	types := []string{}
+	for _, t := range eventTypes {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	data, err := json.Marshal(types)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar segredo do webhook: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/database"
+	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
+)
+
+// newTestDatabase abre um banco SQLite temporário com todas as migrações aplicadas
+func newTestDatabase(t *testing.T) *database.Database {
+	t.Helper()
+
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de teste: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// webhookTestEnv reúne o serviço e o log de eventos que alimenta o seu outbox
+type webhookTestEnv struct {
+	*WebhookService
+	events *repository.EventRepository
+}
+
+func newTestWebhookService(t *testing.T, config WebhookConfig) *webhookTestEnv {
+	t.Helper()
+
+	db := newTestDatabase(t)
+	return &webhookTestEnv{
+		WebhookService: NewWebhookService(repository.NewWebhookRepository(db.DB), repository.NewRoomRepository(db.DB), config),
+		events:         repository.NewEventRepository(db.DB),
+	}
+}
+
+// publish grava um evento no log da sala, como o hub, e o distribui aos webhooks
+func (env *webhookTestEnv) publish(t *testing.T) {
+	t.Helper()
+
+	event, err := env.events.Append("room-1", "new_message", map[string]string{"id": "message-1", "content": "oi"})
+	if err != nil {
+		t.Fatalf("erro ao gravar evento: %v", err)
+	}
+	env.RoomEventPublished(event)
+	env.dispatchOutbox()
+}
+
+// deliver inicia os workers das entregas vencidas e espera que terminem
+func (env *webhookTestEnv) deliver() {
+	env.processDue()
+	env.workers.Wait()
+}
+
+func deliveriesOf(t *testing.T, s *webhookTestEnv, webhookID string) []*models.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := s.GetDeliveries(webhookID, 100, 0)
+	if err != nil {
+		t.Fatalf("erro ao listar entregas: %v", err)
+	}
+	return deliveries
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"event":"new_message"}`)
+
+	mac := hmac.New(sha256.New, []byte("segredo"))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := SignWebhookPayload("segredo", body); got != want {
+		t.Fatalf("assinatura = %q, esperado %q", got, want)
+	}
+	if SignWebhookPayload("outro", body) == want {
+		t.Fatal("segredos diferentes geraram a mesma assinatura")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	s := &WebhookService{config: WebhookConfig{RetryBase: time.Second, RetryMax: 10 * time.Second}}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, expected := range want {
+		if got := s.backoff(i + 1); got != expected {
+			t.Errorf("backoff(%d) = %v, esperado %v", i+1, got, expected)
+		}
+	}
+
+	if got := s.backoff(100); got != 10*time.Second {
+		t.Errorf("backoff(100) = %v, esperado o teto de 10s", got)
+	}
+}
+
+func TestWebhookDeliverySigned(t *testing.T) {
+	type received struct {
+		body      []byte
+		signature string
+		event     string
+		delivery  string
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{
+			body:      body,
+			signature: r.Header.Get(WebhookSignatureHeader),
+			event:     r.Header.Get("X-Whatz-Event"),
+			delivery:  r.Header.Get("X-Whatz-Delivery"),
+		}
+	}))
+	defer server.Close()
+
+	s := newTestWebhookService(t, WebhookConfig{})
+	webhook, err := s.Register(server.URL, "segredo", nil, "", "admin")
+	if err != nil {
+		t.Fatalf("erro ao cadastrar webhook: %v", err)
+	}
+
+	s.publish(t)
+	s.deliver()
+
+	request := <-requests
+	if request.signature != SignWebhookPayload("segredo", request.body) {
+		t.Errorf("assinatura %q não confere com o corpo", request.signature)
+	}
+	if request.event != "new_message" {
+		t.Errorf("X-Whatz-Event = %q", request.event)
+	}
+
+	deliveries := deliveriesOf(t, s, webhook.ID)
+	if len(deliveries) != 1 || deliveries[0].Status != models.WebhookDeliveryDelivered {
+		t.Fatalf("entregas = %+v, esperada uma entregue", deliveries)
+	}
+	if request.delivery != deliveries[0].ID {
+		t.Errorf("X-Whatz-Delivery = %q, esperado %q", request.delivery, deliveries[0].ID)
+	}
+}
+
+func TestWebhookDeliveryGivesUpAfterMaxAttempts(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	// Com espera de 1ms, a próxima tentativa (gravada em segundos) vence na hora
+	s := newTestWebhookService(t, WebhookConfig{MaxAttempts: 3, RetryBase: time.Millisecond, RetryMax: time.Millisecond})
+	webhook, err := s.Register(server.URL, "segredo", nil, "", "admin")
+	if err != nil {
+		t.Fatalf("erro ao cadastrar webhook: %v", err)
+	}
+
+	s.publish(t)
+	for i := 0; i < 5; i++ {
+		s.deliver()
+	}
+
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("endpoint recebeu %d requisições, esperado 3", got)
+	}
+
+	deliveries := deliveriesOf(t, s, webhook.ID)
+	if len(deliveries) != 1 {
+		t.Fatalf("esperada uma entrega, obtidas %d", len(deliveries))
+	}
+	delivery, err := s.GetDelivery(webhook.ID, deliveries[0].ID)
+	if err != nil {
+		t.Fatalf("erro ao buscar entrega: %v", err)
+	}
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 3 || len(delivery.AttemptLog) != 3 {
+		t.Fatalf("entrega = status %s, %d tentativas, %d registros; esperado failed com 3", delivery.Status, delivery.Attempts, len(delivery.AttemptLog))
+	}
+	if delivery.AttemptLog[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("status registrado = %d", delivery.AttemptLog[0].StatusCode)
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	s := newTestWebhookService(t, WebhookConfig{})
+	webhook, err := s.Register(server.URL, "segredo", nil, "", "admin")
+	if err != nil {
+		t.Fatalf("erro ao cadastrar webhook: %v", err)
+	}
+
+	s.publish(t)
+	s.deliver()
+
+	deliveryID := deliveriesOf(t, s, webhook.ID)[0].ID
+	requeued, err := s.Redeliver(webhook.ID, deliveryID)
+	if err != nil {
+		t.Fatalf("erro ao reenviar: %v", err)
+	}
+	if requeued.Status != models.WebhookDeliveryPending || requeued.Attempts != 0 {
+		t.Fatalf("após Redeliver: status %s, %d tentativas", requeued.Status, requeued.Attempts)
+	}
+
+	s.deliver()
+
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("endpoint recebeu %d requisições, esperado 2", got)
+	}
+	delivery, err := s.GetDelivery(webhook.ID, deliveryID)
+	if err != nil {
+		t.Fatalf("erro ao buscar entrega: %v", err)
+	}
+	if delivery.Status != models.WebhookDeliveryDelivered || len(delivery.AttemptLog) != 2 {
+		t.Fatalf("entrega = status %s, %d registros; esperado delivered com 2", delivery.Status, len(delivery.AttemptLog))
+	}
+
+	if _, err := s.Redeliver(webhook.ID, "inexistente"); err != ErrWebhookDeliveryMissing {
+		t.Fatalf("Redeliver de entrega inexistente: %v", err)
+	}
+}
+
+// waitFor espera a condição por até 2s
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tempo esgotado esperando %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhookFailingEndpointDoesNotStallOthers(t *testing.T) {
+	var slowHits atomic.Int32
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowHits.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	var healthyHits atomic.Int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyHits.Add(1)
+	}))
+	defer healthy.Close()
+
+	s := newTestWebhookService(t, WebhookConfig{Timeout: 200 * time.Millisecond, RetryBase: time.Minute})
+	slowWebhook, err := s.Register(slow.URL, "segredo", nil, "", "admin")
+	if err != nil {
+		t.Fatalf("erro ao cadastrar webhook: %v", err)
+	}
+	healthyWebhook, err := s.Register(healthy.URL, "segredo", nil, "", "admin")
+	if err != nil {
+		t.Fatalf("erro ao cadastrar webhook: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		s.publish(t)
+	}
+
+	start := time.Now()
+	s.deliver()
+
+	if got := healthyHits.Load(); got != 3 {
+		t.Errorf("endpoint saudável recebeu %d requisições, esperado 3", got)
+	}
+	// Após a primeira falha (timeout), o restante da fila do endpoint lento é adiado
+	if got := slowHits.Load(); got != 1 {
+		t.Errorf("endpoint lento recebeu %d requisições, esperado 1", got)
+	}
+
+	for _, delivery := range deliveriesOf(t, s, healthyWebhook.ID) {
+		if delivery.Status != models.WebhookDeliveryDelivered {
+			t.Errorf("entrega %s do endpoint saudável ficou %s", delivery.ID, delivery.Status)
+		}
+	}
+	for _, delivery := range deliveriesOf(t, s, slowWebhook.ID) {
+		if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttempt == nil || !delivery.NextAttempt.After(start) {
+			t.Errorf("entrega %s do endpoint lento deveria estar adiada: %+v", delivery.ID, delivery)
+		}
+	}
+}
+
+func TestWebhookSlowSuccessfulEndpointDoesNotStallOthers(t *testing.T) {
+	var slowHits atomic.Int32
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowHits.Add(1)
+		<-release
+	}))
+	defer slow.Close()
+
+	var healthyHits atomic.Int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyHits.Add(1)
+	}))
+	defer healthy.Close()
+
+	s := newTestWebhookService(t, WebhookConfig{Timeout: 10 * time.Second})
+	slowWebhook, err := s.Register(slow.URL, "segredo", nil, "", "admin")
+	if err != nil {
+		t.Fatalf("erro ao cadastrar webhook: %v", err)
+	}
+	if _, err := s.Register(healthy.URL, "segredo", nil, "", "admin"); err != nil {
+		t.Fatalf("erro ao cadastrar webhook: %v", err)
+	}
+
+	s.publish(t)
+	s.processDue()
+	waitFor(t, "a primeira entrega do endpoint saudável", func() bool { return healthyHits.Load() == 1 })
+
+	// Com o endpoint lento ainda respondendo, novos eventos chegam ao saudável sem esperar por ele
+	s.publish(t)
+	s.processDue()
+	waitFor(t, "a segunda entrega do endpoint saudável", func() bool { return healthyHits.Load() == 2 })
+
+	if got := slowHits.Load(); got != 1 {
+		t.Fatalf("endpoint lento recebeu %d requisições, esperado 1 (um worker por webhook)", got)
+	}
+
+	close(release)
+	s.workers.Wait()
+	s.deliver()
+
+	if got := slowHits.Load(); got != 2 {
+		t.Errorf("endpoint lento recebeu %d requisições, esperado 2", got)
+	}
+	for _, delivery := range deliveriesOf(t, s, slowWebhook.ID) {
+		if delivery.Status != models.WebhookDeliveryDelivered {
+			t.Errorf("entrega %s do endpoint lento ficou %s", delivery.ID, delivery.Status)
+		}
+	}
+}
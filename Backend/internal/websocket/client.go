@@ -0,0 +1,243 @@
+package websocket
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	fiberws "github.com/gofiber/websocket/v2"
+	"github.com/google/uuid"
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+// Tamanho da fila de envio de cada cliente
+const sendQueueSize = 256
+
+// Tempo máximo de espera por espaço na fila durante o replay
+const resumeEnqueueTimeout = 5 * time.Second
+
+// Client representa uma conexão WebSocket. Cada cliente é dono da própria fila de envio:
+// a fila nunca é fechada, e o encerramento é sinalizado uma única vez pelo canal done.
+type Client struct {
+	ID              string
+	SessionID       string
+	UserID          string
+	RoomID          string
+	ProtocolVersion int
+	Hub             *Hub
+	// Token de API da conexão; nil quando o usuário foi identificado por user_id
+	Token      *models.APIToken
+	writeMutex sync.Mutex // Proteger escrita na conexão WebSocket
+
+	// Nome exibido; pode mudar durante a conexão quando o perfil é atualizado
+	profileMutex sync.RWMutex
+	username     string
+
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// Código e motivo enviados no frame de fechamento (definidos uma única vez em close)
+	closeCode   int
+	closeReason string
+
+	// Estado de retomada: enquanto resuming, eventos sequenciados ficam em pending
+	// até o replay do histórico perdido terminar
+	resumeMutex sync.Mutex
+	resuming    bool
+	pending     []sequencedFrame
+	lastSeq     int64
+}
+
+type sequencedFrame struct {
+	seq  int64
+	data []byte
+}
+
+func newClient(hub *Hub, id, userID, username, roomID string, protocolVersion int) *Client {
+	return &Client{
+		ID:              id,
+		SessionID:       uuid.New().String(),
+		UserID:          userID,
+		username:        username,
+		RoomID:          roomID,
+		ProtocolVersion: protocolVersion,
+		Hub:             hub,
+		send:            make(chan []byte, sendQueueSize),
+		done:            make(chan struct{}),
+	}
+}
+
+// Can indica se a conexão pode realizar a ação protegida pelo escopo. Conexões sem token
+// não têm restrição de escopo.
+func (c *Client) Can(scope string) bool {
+	return c.Token == nil || c.Token.HasScope(scope)
+}
+
+// Username retorna o nome atual do usuário da conexão
+func (c *Client) Username() string {
+	c.profileMutex.RLock()
+	defer c.profileMutex.RUnlock()
+	return c.username
+}
+
+func (c *Client) setUsername(username string) {
+	c.profileMutex.Lock()
+	c.username = username
+	c.profileMutex.Unlock()
+}
+
+// close encerra o cliente; chamadas seguintes são ignoradas e mantêm o primeiro motivo
+func (c *Client) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
+
+func (c *Client) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// trySend enfileira sem bloquear. Um cliente que não acompanha o ritmo é desconectado com too_slow.
+func (c *Client) trySend(data []byte) bool {
+	if c.closed() {
+		return false
+	}
+
+	select {
+	case c.send <- data:
+		return true
+	default:
+		log.Printf("🐢 Fila de envio de %s cheia, desconectando", c.Username())
+		c.close(CloseTooSlow, "too_slow")
+		return false
+	}
+}
+
+// sendBlocking aguarda espaço na fila; usado no replay, onde nenhum evento pode ser perdido
+func (c *Client) sendBlocking(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	case <-c.done:
+		return false
+	case <-time.After(resumeEnqueueTimeout):
+		log.Printf("❌ Tempo esgotado ao reenviar eventos para %s", c.Username())
+		c.close(CloseTooSlow, "too_slow")
+		return false
+	}
+}
+
+// writePump escreve a fila de envio e os pings na conexão até o cliente ser encerrado
+func (c *Client) writePump(conn *fiberws.Conn, config Config) {
+	ticker := time.NewTicker(config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.Hub.unregister <- c
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			// Cliente encerrado: informar o motivo no frame de fechamento
+			closeCode := c.closeCode
+			if closeCode == 0 {
+				closeCode = fiberws.CloseNormalClosure
+			}
+			c.writeMutex.Lock()
+			conn.WriteControl(fiberws.CloseMessage, fiberws.FormatCloseMessage(closeCode, c.closeReason), time.Now().Add(closeWriteWait))
+			c.writeMutex.Unlock()
+			return
+
+		case <-ticker.C:
+			c.writeMutex.Lock()
+			err := conn.WriteControl(fiberws.PingMessage, nil, time.Now().Add(config.WriteWait))
+			c.writeMutex.Unlock()
+
+			if err != nil {
+				log.Printf("❌ Erro ao enviar ping para %s: %v", c.Username(), err)
+				return
+			}
+
+		case message := <-c.send:
+			// Usar mutex para proteger escrita na conexão
+			c.writeMutex.Lock()
+			conn.SetWriteDeadline(time.Now().Add(config.WriteWait))
+			err := conn.WriteMessage(fiberws.TextMessage, message)
+			c.writeMutex.Unlock()
+
+			if err != nil {
+				log.Printf("❌ Erro ao enviar mensagem: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// deliver entrega um evento sequenciado, retendo-o se o cliente estiver retomando a sessão
+func (c *Client) deliver(seq int64, data []byte) {
+	c.resumeMutex.Lock()
+	defer c.resumeMutex.Unlock()
+
+	if c.resuming {
+		c.pending = append(c.pending, sequencedFrame{seq: seq, data: data})
+		return
+	}
+
+	if c.trySend(data) {
+		c.lastSeq = seq
+	}
+}
+
+// beginResume passa a reter eventos sequenciados enquanto o histórico perdido é reenviado.
+// Se a retenção já começou (ex.: antes do registro no hub), os eventos retidos são mantidos.
+func (c *Client) beginResume() {
+	c.resumeMutex.Lock()
+	if !c.resuming {
+		c.resuming = true
+		c.pending = nil
+	}
+	c.resumeMutex.Unlock()
+}
+
+// endResume libera os eventos retidos que não foram cobertos pelo replay e volta à entrega ao vivo.
+// Os envios bloqueantes acontecem fora do lock para não travar o Publish; o evento final
+// (resume_complete) é enfileirado antes de qualquer novo evento ao vivo.
+func (c *Client) endResume(replayedSeq int64, final []byte) {
+	lastSeq := replayedSeq
+
+	for {
+		c.resumeMutex.Lock()
+		batch := c.pending
+		c.pending = nil
+
+		if len(batch) == 0 {
+			if final != nil {
+				c.trySend(final)
+			}
+			c.lastSeq = lastSeq
+			c.resuming = false
+			c.resumeMutex.Unlock()
+			return
+		}
+		c.resumeMutex.Unlock()
+
+		for _, frame := range batch {
+			if frame.seq <= lastSeq {
+				continue
+			}
+			if !c.sendBlocking(frame.data) {
+				return
+			}
+			lastSeq = frame.seq
+		}
+	}
+}
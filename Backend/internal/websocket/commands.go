@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rafael-bit/whatz/internal/services"
+)
+
+// runCommand executa um comando de barra enviado em "send_message". Mensagens geradas pelo
+// comando seguem o caminho normal (message_ack para o autor e new_message para a sala);
+// respostas efêmeras vão apenas para o cliente, em "command_response".
+func (h *Handler) runCommand(client *Client, correlationID, name, args string) {
+	start := time.Now()
+
+	user, err := h.userRepo.GetByID(client.UserID)
+	if err != nil || user == nil {
+		log.Printf("❌ Erro ao buscar usuário do comando: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao executar comando")
+		return
+	}
+
+	room, err := h.roomRepo.GetByID(client.RoomID)
+	if err != nil || room == nil {
+		log.Printf("❌ Erro ao buscar sala do comando: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao executar comando")
+		return
+	}
+
+	result, err := h.commands.Execute(&services.CommandInvocation{Name: name, Args: args, User: user, Room: room})
+	switch {
+	case errors.Is(err, services.ErrUnknownCommand):
+		h.sendError(client, correlationID, ErrCodeUnknownCommand, "Comando desconhecido: /"+name)
+		return
+	case errors.Is(err, services.ErrCommandFailed):
+		h.sendError(client, correlationID, ErrCodeCommandFailed, err.Error())
+		return
+	case err != nil:
+		log.Printf("❌ Erro ao executar /%s: %v", name, err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao executar comando")
+		return
+	}
+
+	if result.Post != nil {
+		// Mensagens geradas por comandos (ex.: /me, /shrug) passam pelos mesmos filtros da sala
+		moderation := h.moderate(client, correlationID, result.Post)
+		if moderation == nil {
+			return
+		}
+
+		if result.Apply != nil {
+			if err := result.Apply(); err != nil {
+				log.Printf("❌ Erro ao aplicar /%s: %v", name, err)
+				h.sendError(client, correlationID, ErrCodeInternal, "Erro ao executar comando")
+				return
+			}
+		}
+
+		if err := h.messageRepo.Create(result.Post); err != nil {
+			log.Printf("❌ Erro ao salvar mensagem do comando: %v", err)
+			h.sendError(client, correlationID, ErrCodeInternal, "Erro ao salvar mensagem")
+			return
+		}
+		h.moderation.Flag(result.Post, moderation)
+
+		h.sendToClient(client, &WSMessage{
+			Type: "message_ack",
+			ID:   correlationID,
+			Payload: MessageAckPayload{
+				MessageID: result.Post.ID,
+				CreatedAt: result.Post.CreatedAt,
+			},
+		})
+		h.publishMessage(result.Post)
+	}
+
+	// Sem mensagem publicada, a resposta (mesmo vazia) confirma a execução ao cliente
+	if result.Reply != "" || result.Post == nil {
+		h.sendToClient(client, &WSMessage{
+			Type: "command_response",
+			ID:   correlationID,
+			Payload: CommandResponsePayload{
+				Command: "/" + name,
+				Text:    result.Reply,
+			},
+		})
+	}
+
+	log.Printf("⌨️ Comando /%s de %s executado em %v", name, user.Username, time.Since(start))
+}
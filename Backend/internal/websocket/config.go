@@ -0,0 +1,47 @@
+package websocket
+
+import (
+	"time"
+)
+
+// Config define os tempos de keepalive e limites das conexões WebSocket
+type Config struct {
+	// Intervalo entre pings enviados pelo servidor; deve ser menor que PongWait
+	PingInterval time.Duration
+	// Tempo máximo sem receber pong (ou qualquer frame) antes de considerar a conexão morta
+	PongWait time.Duration
+	// Tempo máximo para concluir uma escrita na conexão
+	WriteWait time.Duration
+	// Tamanho máximo, em bytes, de um frame recebido do cliente
+	MaxMessageSize int64
+}
+
+// DefaultConfig retorna a configuração padrão de keepalive
+func DefaultConfig() Config {
+	return Config{
+		PingInterval:   30 * time.Second,
+		PongWait:       60 * time.Second,
+		WriteWait:      10 * time.Second,
+		MaxMessageSize: 64 * 1024,
+	}
+}
+
+// normalize corrige valores ausentes ou inconsistentes usando os padrões
+func (c Config) normalize() Config {
+	defaults := DefaultConfig()
+
+	if c.PongWait <= 0 {
+		c.PongWait = defaults.PongWait
+	}
+	if c.PingInterval <= 0 || c.PingInterval >= c.PongWait {
+		c.PingInterval = c.PongWait * 9 / 10
+	}
+	if c.WriteWait <= 0 {
+		c.WriteWait = defaults.WriteWait
+	}
+	if c.MaxMessageSize <= 0 {
+		c.MaxMessageSize = defaults.MaxMessageSize
+	}
+
+	return c
+}
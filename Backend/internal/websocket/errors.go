@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	fiberws "github.com/gofiber/websocket/v2"
+)
+
+// Códigos de erro enviados no payload do evento "error"
+const (
+	ErrCodeInvalidJSON        = "invalid_json"
+	ErrCodeUnknownType        = "unknown_type"
+	ErrCodeInvalidPayload     = "invalid_payload"
+	ErrCodeEmptyContent       = "empty_content"
+	ErrCodeContentTooLong     = "content_too_long"
+	ErrCodeMissingParams      = "missing_params"
+	ErrCodeUserNotFound       = "user_not_found"
+	ErrCodeRoomNotFound       = "room_not_found"
+	ErrCodeUnsupportedVersion = "unsupported_version"
+	ErrCodeMessageNotFound    = "message_not_found"
+	ErrCodeForbidden          = "forbidden"
+	ErrCodeResumeUnavailable  = "resume_unavailable"
+	ErrCodeLegalHold          = "legal_hold"
+	ErrCodeRoomArchived       = "room_archived"
+	ErrCodeUnknownCommand     = "unknown_command"
+	ErrCodeCommandFailed      = "command_failed"
+	ErrCodeMessageBlocked     = "message_blocked"
+	ErrCodeInternal           = "internal_error"
+)
+
+// Códigos de fechamento da aplicação (faixa 4000-4999 da RFC 6455)
+const (
+	CloseUserNotFound       = 4001
+	CloseRoomNotFound       = 4002
+	CloseSessionReplaced    = 4003
+	CloseUnsupportedVersion = 4004
+	CloseTooSlow            = 4005
+	CloseAccountDeleted     = 4006
+)
+
+// Tempo máximo para escrever frames de controle antes de desistir
+const closeWriteWait = time.Second
+
+// sendError envia um evento "error" apenas para o cliente que originou a requisição
+func (h *Handler) sendError(client *Client, correlationID, code, message string) {
+	h.sendToClient(client, &WSMessage{
+		Type: "error",
+		ID:   correlationID,
+		Payload: ErrorPayload{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+func (h *Handler) sendToClient(client *Client, message *WSMessage) bool {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("❌ Erro ao serializar evento %s: %v", message.Type, err)
+		return false
+	}
+
+	return client.trySend(data)
+}
+
+// closeWithError informa o motivo ao cliente e encerra a conexão com o código de fechamento adequado.
+// Usado antes do cliente ser registrado no hub, quando ainda não existe goroutine de escrita.
+func closeWithError(c *fiberws.Conn, closeCode int, code, message string) {
+	data, err := json.Marshal(&WSMessage{
+		Type: "error",
+		Payload: ErrorPayload{
+			Code:    code,
+			Message: message,
+		},
+	})
+	if err == nil {
+		c.SetWriteDeadline(time.Now().Add(closeWriteWait))
+		c.WriteMessage(fiberws.TextMessage, data)
+	}
+
+	c.WriteControl(fiberws.CloseMessage, fiberws.FormatCloseMessage(closeCode, code), time.Now().Add(closeWriteWait))
+	c.Close()
+}
@@ -2,27 +2,47 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"time"
 
 	fiberws "github.com/gofiber/websocket/v2"
+	"github.com/rafael-bit/whatz/internal/middleware"
 	"github.com/rafael-bit/whatz/internal/models"
 	"github.com/rafael-bit/whatz/internal/repository"
+	"github.com/rafael-bit/whatz/internal/services"
 )
 
 type Handler struct {
-	hub         *Hub
-	userRepo    *repository.UserRepository
-	messageRepo *repository.MessageRepository
-	roomRepo    *repository.RoomRepository
+	hub           *Hub
+	userRepo      *repository.UserRepository
+	messageRepo   *repository.MessageRepository
+	roomRepo      *repository.RoomRepository
+	eventRepo     *repository.EventRepository
+	pinRepo       *repository.PinRepository
+	mentions      *services.MentionService
+	notifications *services.NotificationService
+	commands      *services.CommandService
+	unfurls       *services.UnfurlService
+	moderation    *services.ModerationService
+	config        Config
 }
 
-func NewHandler(hub *Hub, userRepo *repository.UserRepository, messageRepo *repository.MessageRepository, roomRepo *repository.RoomRepository) *Handler {
+func NewHandler(hub *Hub, userRepo *repository.UserRepository, messageRepo *repository.MessageRepository, roomRepo *repository.RoomRepository, eventRepo *repository.EventRepository, pinRepo *repository.PinRepository, mentions *services.MentionService, notifications *services.NotificationService, commands *services.CommandService, unfurls *services.UnfurlService, moderation *services.ModerationService, config Config) *Handler {
 	return &Handler{
-		hub:         hub,
-		userRepo:    userRepo,
-		messageRepo: messageRepo,
-		roomRepo:    roomRepo,
+		hub:           hub,
+		userRepo:      userRepo,
+		messageRepo:   messageRepo,
+		roomRepo:      roomRepo,
+		eventRepo:     eventRepo,
+		pinRepo:       pinRepo,
+		mentions:      mentions,
+		notifications: notifications,
+		commands:      commands,
+		unfurls:       unfurls,
+		moderation:    moderation,
+		config:        config.normalize(),
 	}
 }
 
@@ -34,9 +54,28 @@ func (h *Handler) HandleWebSocket(c *fiberws.Conn) {
 	userID := c.Query("user_id")
 	roomID := c.Query("room_id")
 
+	// Conexões autenticadas por token (validado no upgrade) pertencem ao dono do token
+	token, _ := c.Locals(middleware.TokenLocalsKey).(*models.APIToken)
+	if token != nil {
+		if userID != "" && userID != token.UserID {
+			log.Printf("❌ user_id %s não corresponde ao token %s", userID, token.ID)
+			closeWithError(c, fiberws.ClosePolicyViolation, ErrCodeForbidden, "user_id não corresponde ao token")
+			return
+		}
+		userID = token.UserID
+	}
+
 	if userID == "" || roomID == "" {
 		log.Printf("❌ Parâmetros obrigatórios não fornecidos: user_id=%s, room_id=%s", userID, roomID)
-		c.Close()
+		closeWithError(c, fiberws.ClosePolicyViolation, ErrCodeMissingParams, "user_id e room_id são obrigatórios")
+		return
+	}
+
+	// Negociar versão do protocolo (subprotocolo whatz.vN ou ?protocol_version=N)
+	version, err := negotiateVersion(c.Subprotocol(), c.Query("protocol_version"))
+	if err != nil {
+		log.Printf("❌ %v", err)
+		closeWithError(c, CloseUnsupportedVersion, ErrCodeUnsupportedVersion, err.Error())
 		return
 	}
 
@@ -44,13 +83,20 @@ func (h *Handler) HandleWebSocket(c *fiberws.Conn) {
 	user, err := h.userRepo.GetByID(userID)
 	if err != nil {
 		log.Printf("❌ Erro ao buscar usuário: %v", err)
-		c.Close()
+		closeWithError(c, fiberws.CloseInternalServerErr, ErrCodeInternal, "Erro ao buscar usuário")
 		return
 	}
 
 	if user == nil {
 		log.Printf("❌ Usuário não encontrado: %s", userID)
-		c.Close()
+		closeWithError(c, CloseUserNotFound, ErrCodeUserNotFound, "Usuário não encontrado")
+		return
+	}
+
+	// Contas de serviço só se conectam com token de API
+	if user.IsBot() && token == nil {
+		log.Printf("❌ Conta de serviço %s sem token de API", user.Username)
+		closeWithError(c, fiberws.ClosePolicyViolation, ErrCodeForbidden, "Contas de serviço exigem token de API")
 		return
 	}
 
@@ -58,199 +104,465 @@ func (h *Handler) HandleWebSocket(c *fiberws.Conn) {
 	room, err := h.roomRepo.GetByID(roomID)
 	if err != nil {
 		log.Printf("❌ Erro ao buscar sala: %v", err)
-		c.Close()
+		closeWithError(c, fiberws.CloseInternalServerErr, ErrCodeInternal, "Erro ao buscar sala")
 		return
 	}
 
 	if room == nil {
 		log.Printf("❌ Sala não encontrada: %s", roomID)
-		c.Close()
+		closeWithError(c, CloseRoomNotFound, ErrCodeRoomNotFound, "Sala não encontrada")
 		return
 	}
 
-	// Check if user is already connected and disconnect previous connection
-	h.hub.mutex.RLock()
-	var existingClient *Client
-	for client := range h.hub.clients {
-		if client.UserID == user.ID {
-			existingClient = client
-			break
-		}
-	}
-	h.hub.mutex.RUnlock()
-
-	if existingClient != nil {
-		log.Printf("⚠️ User %s is already connected, disconnecting previous connection", user.Username)
-
-		// Disconnect existing client gracefully
-		h.hub.unregister <- existingClient
-
-		// Wait for disconnection to complete
-		time.Sleep(500 * time.Millisecond)
-
-		// Verify that the client was removed
-		h.hub.mutex.RLock()
-		stillConnected := false
-		for client := range h.hub.clients {
-			if client.UserID == user.ID {
-				stillConnected = true
-				log.Printf("⚠️ User %s still connected, forcing disconnection", user.Username)
-				h.hub.unregister <- client
-			}
-		}
-		h.hub.mutex.RUnlock()
-
-		if stillConnected {
-			// Wait a bit more to ensure cleanup
-			time.Sleep(300 * time.Millisecond)
-		}
+	// Uma sessão por usuário: encerrar conexões anteriores antes de registrar a nova
+	if replaced := h.hub.DisconnectUser(user.ID, CloseSessionReplaced, "session_replaced"); replaced > 0 {
+		log.Printf("⚠️ User %s was already connected, %d previous connection(s) closed", user.Username, replaced)
 	}
 
 	// Criar cliente
-	client := &Client{
-		ID:       c.RemoteAddr().String(),
-		UserID:   user.ID,
-		Username: user.Username,
-		RoomID:   room.ID,
-		Conn: &Connection{
-			Send: make(chan []byte, 256),
-		},
-		Hub: h.hub,
+	client := newClient(h.hub, c.RemoteAddr().String(), user.ID, user.Username, room.ID, version)
+	client.Token = token
+
+	// Com ?last_seq=N, reter os eventos desde o registro: os publicados antes do replay
+	// seriam entregues ao vivo e repetidos por ele
+	lastSeq, resuming := parseLastSeq(c.Query("last_seq"))
+	if resuming {
+		client.beginResume()
 	}
 
-	// Registrar cliente no hub
+	// Registrar cliente no hub (o hub marca o usuário como online)
 	h.hub.register <- client
 
-	// Atualizar status do usuário para online
-	h.userRepo.UpdateStatus(user.ID, "online")
-
-	// Goroutine para enviar mensagens para o cliente
-	go func() {
-		defer func() {
-			h.hub.unregister <- client
-			c.Close()
-		}()
-
-		for {
-			select {
-			case message, ok := <-client.Conn.Send:
-				if !ok {
-					return
-				}
-
-				// Usar mutex para proteger escrita na conexão
-				client.writeMutex.Lock()
-				err := c.WriteMessage(fiberws.TextMessage, message)
-				client.writeMutex.Unlock()
-
-				if err != nil {
-					log.Printf("❌ Erro ao enviar mensagem: %v", err)
-					return
-				}
-			}
-		}
-	}()
+	// Goroutine para enviar mensagens e pings para o cliente
+	go client.writePump(c, h.config)
 
 	// Send welcome message and history
 	log.Printf("📤 Sending welcome message to %s", user.Username)
 	h.sendWelcomeMessage(client, room)
 
-	log.Printf("📤 Sending message history to %s", user.Username)
-	h.sendMessageHistory(client, room.ID)
+	// Cliente reconectando com ?last_seq=N recebe apenas os eventos perdidos
+	if resuming {
+		log.Printf("🔁 Resuming session for %s from seq %d", user.Username, lastSeq)
+		h.resume(client, "", lastSeq)
+	} else {
+		log.Printf("📤 Sending message history to %s", user.Username)
+		h.sendMessageHistory(client, room.ID)
+	}
 
 	log.Printf("✅ Client %s connected successfully in %v", user.Username, time.Since(start))
 
+	// Cada pong (ou frame recebido) renova o prazo de leitura; sem eles a conexão é considerada morta
+	c.SetReadLimit(h.config.MaxMessageSize)
+	c.SetReadDeadline(time.Now().Add(h.config.PongWait))
+	c.SetPongHandler(func(string) error {
+		return c.SetReadDeadline(time.Now().Add(h.config.PongWait))
+	})
+
 	// Loop principal para receber mensagens
 	for {
 		messageType, message, err := c.ReadMessage()
 		if err != nil {
-			log.Printf("❌ Erro ao ler mensagem: %v", err)
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				log.Printf("💀 Conexão de %s sem resposta ao ping por %v, encerrando", user.Username, h.config.PongWait)
+			} else {
+				log.Printf("❌ Erro ao ler mensagem: %v", err)
+			}
 			break
 		}
 
+		c.SetReadDeadline(time.Now().Add(h.config.PongWait))
+
 		if messageType == fiberws.TextMessage {
 			h.handleMessage(client, message)
 		}
 	}
 
-	// Atualizar status do usuário para offline
-	h.userRepo.UpdateStatus(user.ID, "offline")
+	// Remover do hub (o hub marca o usuário como offline se não houver outra sessão)
+	h.hub.unregister <- client
 	log.Printf("❌ Cliente %s desconectado", user.Username)
 }
 
 func (h *Handler) handleMessage(client *Client, message []byte) {
 	start := time.Now()
-	log.Printf("📨 Mensagem recebida de %s: %s", client.Username, string(message))
+	log.Printf("📨 Mensagem recebida de %s: %s", client.Username(), string(message))
+
+	// Qualquer evento do cliente conta como atividade para a presença
+	h.hub.Touch(client)
 
-	var wsMessage WSMessage
-	if err := json.Unmarshal(message, &wsMessage); err != nil {
+	var wsMessage InboundMessage
+	if err := decodeStrict(message, &wsMessage); err != nil {
 		log.Printf("❌ Erro ao deserializar mensagem: %v", err)
+		h.sendError(client, "", ErrCodeInvalidJSON, "Mensagem inválida: "+err.Error())
 		return
 	}
 
 	switch wsMessage.Type {
 	case "send_message":
-		h.handleSendMessage(client, wsMessage.Payload)
+		var payload SendMessagePayload
+		if !h.decodeEvent(client, &wsMessage, &payload) {
+			return
+		}
+		h.handleSendMessage(client, wsMessage.ID, &payload)
 	case "typing_start":
+		var payload TypingPayload
+		if !h.decodeEvent(client, &wsMessage, &payload) {
+			return
+		}
 		h.handleTypingStart(client)
 	case "typing_stop":
+		var payload TypingPayload
+		if !h.decodeEvent(client, &wsMessage, &payload) {
+			return
+		}
 		h.handleTypingStop(client)
+	case "edit_message":
+		var payload EditMessagePayload
+		if !h.decodeEvent(client, &wsMessage, &payload) {
+			return
+		}
+		h.handleEditMessage(client, wsMessage.ID, &payload)
+	case "delete_message":
+		var payload DeleteMessagePayload
+		if !h.decodeEvent(client, &wsMessage, &payload) {
+			return
+		}
+		h.handleDeleteMessage(client, wsMessage.ID, &payload)
+	case "resume":
+		var payload ResumePayload
+		if !h.decodeEvent(client, &wsMessage, &payload) {
+			return
+		}
+		h.resume(client, wsMessage.ID, *payload.LastSeq)
 	default:
 		log.Printf("⚠️ Tipo de mensagem desconhecido: %s", wsMessage.Type)
+		h.sendError(client, wsMessage.ID, ErrCodeUnknownType, "Tipo de mensagem desconhecido: "+wsMessage.Type)
 	}
 
 	log.Printf("✅ Mensagem processada em %v", time.Since(start))
 }
 
-func (h *Handler) handleSendMessage(client *Client, payload interface{}) {
+// decodeEvent decodifica o payload do evento, respondendo com "error" quando inválido
+func (h *Handler) decodeEvent(client *Client, wsMessage *InboundMessage, payload interface{}) bool {
+	if err := decodePayload(wsMessage.Payload, payload); err != nil {
+		log.Printf("❌ Payload inválido para %s: %v", wsMessage.Type, err)
+
+		code := ErrCodeInvalidPayload
+		if protocolErr, ok := err.(*ProtocolError); ok {
+			code = protocolErr.Code
+		}
+		h.sendError(client, wsMessage.ID, code, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (h *Handler) handleSendMessage(client *Client, correlationID string, payload *SendMessagePayload) {
 	start := time.Now()
 
-	// Converter payload para map
-	payloadMap, ok := payload.(map[string]interface{})
-	if !ok {
-		log.Printf("❌ Payload inválido para mensagem")
+	if !h.ensureWritable(client, correlationID) {
 		return
 	}
 
-	content, ok := payloadMap["content"].(string)
-	if !ok || content == "" {
-		log.Printf("❌ Conteúdo da mensagem inválido")
+	// Comandos de barra não são gravados como mensagem; a integração pode demorar,
+	// então rodam fora do loop de leitura da conexão
+	if name, args, ok := services.ParseCommand(*payload.Content); ok {
+		go h.runCommand(client, correlationID, name, args)
 		return
 	}
 
+	// Resposta: a mensagem respondida precisa estar na mesma sala
+	var parent *models.Message
+	if payload.ThreadID != "" {
+		if parent = h.findRoomMessage(client, correlationID, payload.ThreadID); parent == nil {
+			return
+		}
+	}
+
 	// Criar nova mensagem
-	message := models.NewMessage(content, client.UserID, client.Username, "", "text", client.RoomID)
+	message := models.NewMessage(services.UnescapeCommand(*payload.Content), client.UserID, client.Username(), "", "text", client.RoomID)
+	if parent != nil {
+		// O fio é identificado pela mensagem raiz
+		message.ThreadID = parent.ID
+		if parent.ThreadID != "" {
+			message.ThreadID = parent.ThreadID
+		}
+	}
+
+	// Filtros de conteúdo da sala: podem bloquear, mascarar ou sinalizar a mensagem
+	moderation := h.moderate(client, correlationID, message)
+	if moderation == nil {
+		return
+	}
 
 	// Salvar no banco de dados
 	if err := h.messageRepo.Create(message); err != nil {
 		log.Printf("❌ Erro ao salvar mensagem: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao salvar mensagem")
 		return
 	}
+	h.moderation.Flag(message, moderation)
+
+	// Confirmar a persistência para o remetente
+	h.sendToClient(client, &WSMessage{
+		Type: "message_ack",
+		ID:   correlationID,
+		Payload: MessageAckPayload{
+			MessageID: message.ID,
+			CreatedAt: message.CreatedAt,
+		},
+	})
 
-	// Broadcast para todos os clientes na sala
-	h.hub.broadcast <- message
+	message, mentioned := h.publishMessage(message)
+	if parent != nil && !mentioned[parent.UserID] {
+		h.notifications.NotifyReply(message, parent)
+	}
 
 	log.Printf("✅ Mensagem enviada com sucesso em %v", time.Since(start))
 }
 
+// moderate aplica os filtros de conteúdo antes da gravação. Se a mensagem for bloqueada
+// (ou a moderação falhar), o remetente recebe o erro e o retorno é nil.
+func (h *Handler) moderate(client *Client, correlationID string, message *models.Message) *services.ModerationResult {
+	result, err := h.moderation.Moderate(message)
+	if err != nil {
+		log.Printf("❌ Erro ao moderar mensagem: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao salvar mensagem")
+		return nil
+	}
+
+	if result.Blocked {
+		h.sendError(client, correlationID, ErrCodeMessageBlocked, "Mensagem bloqueada pela moderação da sala: "+result.Reason)
+		return nil
+	}
+	return result
+}
+
+// PublishMessage publica uma mensagem já gravada fora do WebSocket (ex.: webhooks de entrada)
+// pelo mesmo caminho de "send_message"
+func (h *Handler) PublishMessage(message *models.Message) {
+	h.publishMessage(message)
+}
+
+// publishMessage faz o broadcast de "new_message" na sala e avisa os mencionados.
+// Retorna a mensagem publicada e os usuários mencionados.
+func (h *Handler) publishMessage(message *models.Message) (*models.Message, map[string]bool) {
+	// Publicar com o autor resolvido a partir do cadastro (nome e avatar atuais)
+	if resolved, err := h.messageRepo.GetByID(message.ID); err == nil && resolved != nil {
+		message = resolved
+	}
+
+	// Broadcast para todos os clientes na sala
+	if _, err := h.hub.Publish(message.RoomID, "new_message", message); err != nil {
+		log.Printf("❌ Erro ao publicar mensagem: %v", err)
+	}
+	h.unfurls.Enqueue(message)
+
+	return message, h.notifyMentions(message)
+}
+
+// notifyMentions grava as menções da mensagem e avisa os mencionados em todas as suas sessões.
+// Retorna os usuários mencionados.
+func (h *Handler) notifyMentions(message *models.Message) map[string]bool {
+	online := h.hub.GetOnlineUsers(message.RoomID)
+	onlineUserIDs := make([]string, 0, len(online))
+	for _, user := range online {
+		onlineUserIDs = append(onlineUserIDs, user.UserID)
+	}
+
+	mentions, err := h.mentions.Record(message, onlineUserIDs)
+	if err != nil {
+		log.Printf("❌ Erro ao registrar menções: %v", err)
+		return nil
+	}
+
+	mentioned := make(map[string]bool, len(mentions))
+	for _, mention := range mentions {
+		mentioned[mention.UserID] = true
+		h.hub.SendToUser(mention.UserID, &WSMessage{
+			Type: "mentioned",
+			Payload: MentionedPayload{
+				MentionID: mention.ID,
+				Kind:      mention.Kind,
+				RoomID:    mention.RoomID,
+				Message:   message,
+			},
+		})
+	}
+
+	return mentioned
+}
+
+// ensureWritable recusa alterações em salas arquivadas ou vindas de tokens sem o escopo
+// messages:write, respondendo com "error"
+func (h *Handler) ensureWritable(client *Client, correlationID string) bool {
+	if !client.Can(models.ScopeMessagesWrite) {
+		h.sendError(client, correlationID, ErrCodeForbidden, "Token sem o escopo "+models.ScopeMessagesWrite)
+		return false
+	}
+
+	room, err := h.roomRepo.GetByID(client.RoomID)
+	if err != nil {
+		log.Printf("❌ Erro ao buscar sala: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao buscar sala")
+		return false
+	}
+
+	if room == nil {
+		h.sendError(client, correlationID, ErrCodeRoomNotFound, "Sala não encontrada")
+		return false
+	}
+
+	if room.Archived() {
+		h.sendError(client, correlationID, ErrCodeRoomArchived, "Sala arquivada: somente leitura")
+		return false
+	}
+
+	return true
+}
+
+// findRoomMessage busca uma mensagem da sala do cliente, respondendo com "error" se não existir
+func (h *Handler) findRoomMessage(client *Client, correlationID, messageID string) *models.Message {
+	message, err := h.messageRepo.GetByID(messageID)
+	if err != nil {
+		log.Printf("❌ Erro ao buscar mensagem: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao buscar mensagem")
+		return nil
+	}
+
+	if message == nil || message.RoomID != client.RoomID {
+		h.sendError(client, correlationID, ErrCodeMessageNotFound, "Mensagem não encontrada")
+		return nil
+	}
+
+	return message
+}
+
+// findOwnMessage busca uma mensagem da sala do cliente, respondendo com "error" se não existir ou não for dele
+func (h *Handler) findOwnMessage(client *Client, correlationID, messageID string) *models.Message {
+	message := h.findRoomMessage(client, correlationID, messageID)
+	if message == nil {
+		return nil
+	}
+
+	if message.UserID != client.UserID {
+		h.sendError(client, correlationID, ErrCodeForbidden, "Apenas o autor pode alterar a mensagem")
+		return nil
+	}
+
+	return message
+}
+
+func (h *Handler) handleEditMessage(client *Client, correlationID string, payload *EditMessagePayload) {
+	if !h.ensureWritable(client, correlationID) {
+		return
+	}
+
+	message := h.findOwnMessage(client, correlationID, payload.MessageID)
+	if message == nil {
+		return
+	}
+
+	message.SetContent(*payload.Content)
+	message.UpdatedAt = time.Now().UTC()
+
+	moderation := h.moderate(client, correlationID, message)
+	if moderation == nil {
+		return
+	}
+
+	if err := h.messageRepo.Update(message); err != nil {
+		log.Printf("❌ Erro ao editar mensagem: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao editar mensagem")
+		return
+	}
+	h.moderation.Flag(message, moderation)
+
+	h.sendToClient(client, &WSMessage{
+		Type: "message_ack",
+		ID:   correlationID,
+		Payload: MessageAckPayload{
+			MessageID: message.ID,
+			CreatedAt: message.CreatedAt,
+		},
+	})
+
+	if _, err := h.hub.Publish(client.RoomID, "message_edited", message); err != nil {
+		log.Printf("❌ Erro ao publicar edição: %v", err)
+	}
+	h.unfurls.Enqueue(message)
+}
+
+func (h *Handler) handleDeleteMessage(client *Client, correlationID string, payload *DeleteMessagePayload) {
+	if !h.ensureWritable(client, correlationID) {
+		return
+	}
+
+	message := h.findOwnMessage(client, correlationID, payload.MessageID)
+	if message == nil {
+		return
+	}
+
+	if err := h.messageRepo.Delete(message.ID); err != nil {
+		if errors.Is(err, repository.ErrLegalHold) {
+			h.sendError(client, correlationID, ErrCodeLegalHold, "Mensagem sob retenção legal não pode ser removida")
+			return
+		}
+		log.Printf("❌ Erro ao remover mensagem: %v", err)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao remover mensagem")
+		return
+	}
+
+	h.sendToClient(client, &WSMessage{
+		Type: "message_ack",
+		ID:   correlationID,
+		Payload: MessageAckPayload{
+			MessageID: message.ID,
+			CreatedAt: message.CreatedAt,
+		},
+	})
+
+	if _, err := h.hub.Publish(client.RoomID, "message_deleted", MessageDeletedPayload{
+		MessageID: message.ID,
+		RoomID:    message.RoomID,
+	}); err != nil {
+		log.Printf("❌ Erro ao publicar remoção: %v", err)
+	}
+}
+
 func (h *Handler) handleTypingStart(client *Client) {
-	h.hub.SendTypingIndicator(client.RoomID, client.UserID, client.Username, true)
+	h.hub.SendTypingIndicator(client.RoomID, client.UserID, client.Username(), true)
 }
 
 func (h *Handler) handleTypingStop(client *Client) {
-	h.hub.SendTypingIndicator(client.RoomID, client.UserID, client.Username, false)
+	h.hub.SendTypingIndicator(client.RoomID, client.UserID, client.Username(), false)
 }
 
 func (h *Handler) sendWelcomeMessage(client *Client, room *models.Room) {
+	lastSeq, err := h.eventRepo.GetLastSeq(room.ID)
+	if err != nil {
+		log.Printf("❌ Error fetching last sequence: %v", err)
+	}
+
+	pins, err := h.pinRepo.GetByRoom(room.ID)
+	if err != nil {
+		log.Printf("❌ Error fetching pinned messages: %v", err)
+		pins = []*models.PinnedMessage{}
+	}
+
 	welcomeMessage := &WSMessage{
 		Type: "welcome",
-		Payload: map[string]interface{}{
-			"room": map[string]interface{}{
-				"id":          room.ID,
-				"name":        room.Name,
-				"description": room.Description,
+		Payload: WelcomePayload{
+			ProtocolVersion: client.ProtocolVersion,
+			Room: RoomInfo{
+				ID:          room.ID,
+				Name:        room.Name,
+				Description: room.Description,
+				ArchivedAt:  room.ArchivedAt,
 			},
-			"online_users": h.hub.GetOnlineUsers(room.ID),
+			OnlineUsers: h.hub.GetOnlineUsers(room.ID),
+			LastSeq:     lastSeq,
+			Pins:        pins,
 		},
 	}
 
@@ -260,11 +572,10 @@ func (h *Handler) sendWelcomeMessage(client *Client, room *models.Room) {
 		return
 	}
 
-	select {
-	case client.Conn.Send <- data:
-		log.Printf("✅ Welcome message sent to %s", client.Username)
-	default:
-		log.Printf("❌ Failed to send welcome message to %s (channel full)", client.Username)
+	if client.trySend(data) {
+		log.Printf("✅ Welcome message sent to %s", client.Username())
+	} else {
+		log.Printf("❌ Failed to send welcome message to %s", client.Username())
 	}
 }
 
@@ -287,10 +598,9 @@ func (h *Handler) sendMessageHistory(client *Client, roomID string) {
 		return
 	}
 
-	select {
-	case client.Conn.Send <- data:
-		log.Printf("✅ Message history sent to %s (%d messages)", client.Username, len(messages))
-	default:
-		log.Printf("❌ Failed to send message history to %s (channel full)", client.Username)
+	if client.trySend(data) {
+		log.Printf("✅ Message history sent to %s (%d messages)", client.Username(), len(messages))
+	} else {
+		log.Printf("❌ Failed to send message history to %s", client.Username())
 	}
 }
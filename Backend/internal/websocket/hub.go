@@ -4,48 +4,66 @@ import (
 	"encoding/json"
 	"log"
 	"sync"
+	"time"
 
+	fiberws "github.com/gofiber/websocket/v2"
 	"github.com/rafael-bit/whatz/internal/models"
+	"github.com/rafael-bit/whatz/internal/repository"
 )
 
-type Client struct {
-	ID         string
-	UserID     string
-	Username   string
-	RoomID     string
-	Conn       *Connection
-	Hub        *Hub
-	writeMutex sync.Mutex // Proteger escrita na conexão WebSocket
-}
-
 type Hub struct {
 	clients    map[*Client]bool
-	broadcast  chan *models.Message
 	register   chan *Client
 	unregister chan *Client
 	rooms      map[string]map[*Client]bool
 	mutex      sync.RWMutex
-}
 
-type Connection struct {
-	Send chan []byte
+	eventRepo *repository.EventRepository
+	presence  PresenceTracker
+	sink      EventSink
+	// Garante que eventos sequenciados sejam entregues na mesma ordem em que foram gravados
+	publishMutex sync.Mutex
 }
 
+// WSMessage é o envelope dos eventos enviados pelo servidor
 type WSMessage struct {
-	Type    string      `json:"type"`
+	Type string `json:"type"`
+	// ID de correlação enviado pelo cliente, ecoado nas respostas (ack/error)
+	ID string `json:"id,omitempty"`
+	// Sequência do evento na sala (apenas eventos persistidos no log da sala)
+	Seq     int64       `json:"seq,omitempty"`
 	Payload interface{} `json:"payload"`
 }
 
-func NewHub() *Hub {
+// PresenceTracker recebe os eventos de ciclo de vida e atividade das sessões
+type PresenceTracker interface {
+	Connect(userID, sessionID, roomID string)
+	Disconnect(userID, sessionID string)
+	Touch(userID, sessionID string)
+}
+
+// EventSink recebe cada evento sequenciado depois de gravado no log da sala (ex.: webhooks).
+// É chamado com o lock de publicação: o trabalho pesado deve ficar fora da chamada.
+type EventSink interface {
+	RoomEventPublished(event *models.RoomEvent)
+}
+
+func NewHub(eventRepo *repository.EventRepository, presence PresenceTracker) *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
-		broadcast:  make(chan *models.Message),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		rooms:      make(map[string]map[*Client]bool),
+		eventRepo:  eventRepo,
+		presence:   presence,
 	}
 }
 
+// SetEventSink define quem recebe os eventos sequenciados publicados nas salas
+func (h *Hub) SetEventSink(sink EventSink) {
+	h.sink = sink
+}
+
 func (h *Hub) Run() {
 	log.Printf("🚀 Hub WebSocket iniciado")
 
@@ -64,22 +82,24 @@ func (h *Hub) Run() {
 			}
 			h.mutex.Unlock()
 
-			log.Printf("✅ Cliente %s (%s) conectado na sala %s", client.Username, client.ID, client.RoomID)
+			h.presence.Connect(client.UserID, client.SessionID, client.RoomID)
+
+			log.Printf("✅ Cliente %s (%s) conectado na sala %s", client.Username(), client.ID, client.RoomID)
 
 			// Enviar mensagem de sistema sobre novo usuário
 			h.broadcastToRoom(client.RoomID, &WSMessage{
 				Type: "user_joined",
-				Payload: map[string]string{
-					"user_id":  client.UserID,
-					"username": client.Username,
+				Payload: UserPresencePayload{
+					UserID:   client.UserID,
+					Username: client.Username(),
 				},
 			})
 
 		case client := <-h.unregister:
 			h.mutex.Lock()
-			if _, ok := h.clients[client]; ok {
+			_, registered := h.clients[client]
+			if registered {
 				delete(h.clients, client)
-				close(client.Conn.Send)
 
 				// Remover cliente da sala
 				if client.RoomID != "" && h.rooms[client.RoomID] != nil {
@@ -91,72 +111,188 @@ func (h *Hub) Run() {
 			}
 			h.mutex.Unlock()
 
-			log.Printf("❌ Cliente %s (%s) desconectado da sala %s", client.Username, client.ID, client.RoomID)
+			// Encerrar a escrita do cliente (idempotente: mantém o motivo de um close anterior)
+			client.close(fiberws.CloseNormalClosure, "")
+
+			// Leitor e escritor da conexão podem pedir o desregistro; só o primeiro tem efeito
+			if !registered {
+				continue
+			}
+
+			log.Printf("❌ Cliente %s (%s) desconectado da sala %s", client.Username(), client.ID, client.RoomID)
+
+			// Conexão encerrada (inclusive por falta de pong): o serviço de presença marca offline
+			// se era a última sessão do usuário
+			h.presence.Disconnect(client.UserID, client.SessionID)
 
 			// Enviar mensagem de sistema sobre usuário que saiu
 			if client.RoomID != "" {
 				h.broadcastToRoom(client.RoomID, &WSMessage{
 					Type: "user_left",
-					Payload: map[string]string{
-						"user_id":  client.UserID,
-						"username": client.Username,
+					Payload: UserPresencePayload{
+						UserID:   client.UserID,
+						Username: client.Username(),
 					},
 				})
 			}
+		}
+	}
+}
 
-		case message := <-h.broadcast:
-			h.mutex.RLock()
-			roomClients := h.rooms[message.RoomID]
-			h.mutex.RUnlock()
+// Publish grava o evento no log da sala e o entrega aos clientes conectados com a sequência atribuída
+func (h *Hub) Publish(roomID, eventType string, payload interface{}) (int64, error) {
+	h.publishMutex.Lock()
+	defer h.publishMutex.Unlock()
 
-			if roomClients != nil {
-				wsMessage := &WSMessage{
-					Type:    "new_message",
-					Payload: message,
-				}
+	event, err := h.eventRepo.Append(roomID, eventType, payload)
+	if err != nil {
+		return 0, err
+	}
 
-				data, err := json.Marshal(wsMessage)
-				if err != nil {
-					log.Printf("❌ Erro ao serializar mensagem: %v", err)
-					continue
-				}
+	data, err := json.Marshal(&WSMessage{
+		Type:    eventType,
+		Seq:     event.Seq,
+		Payload: payload,
+	})
+	if err != nil {
+		log.Printf("❌ Erro ao serializar evento %s: %v", eventType, err)
+		return event.Seq, err
+	}
 
-				for client := range roomClients {
-					select {
-					case client.Conn.Send <- data:
-					default:
-						close(client.Conn.Send)
-						delete(roomClients, client)
-					}
-				}
+	clients := h.GetRoomClients(roomID)
+	for _, client := range clients {
+		client.deliver(event.Seq, data)
+	}
 
-				log.Printf("📤 Mensagem enviada para %d clientes na sala %s", len(roomClients), message.RoomID)
-			}
-		}
+	log.Printf("📤 Evento %s #%d enviado para %d clientes na sala %s", eventType, event.Seq, len(clients), roomID)
+
+	if h.sink != nil {
+		h.sink.RoomEventPublished(event)
 	}
+	return event.Seq, nil
 }
 
+// broadcastToRoom envia um evento não sequenciado a partir de uma cópia dos clientes da sala,
+// sem manter o lock do hub durante o fan-out
 func (h *Hub) broadcastToRoom(roomID string, message *WSMessage) {
+	clients := h.GetRoomClients(roomID)
+	if len(clients) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("❌ Erro ao serializar mensagem de sistema: %v", err)
+		return
+	}
+
+	for _, client := range clients {
+		client.trySend(data)
+	}
+}
+
+// DisconnectUser encerra todas as conexões do usuário com o código e motivo informados
+func (h *Hub) DisconnectUser(userID string, code int, reason string) int {
+	clients := h.userClients(userID)
+	for _, client := range clients {
+		client.close(code, reason)
+		h.unregister <- client
+	}
+
+	return len(clients)
+}
+
+// userClients retorna uma cópia das sessões do usuário em todas as salas
+func (h *Hub) userClients(userID string) []*Client {
 	h.mutex.RLock()
-	roomClients := h.rooms[roomID]
-	h.mutex.RUnlock()
+	defer h.mutex.RUnlock()
 
-	if roomClients != nil {
-		data, err := json.Marshal(message)
-		if err != nil {
-			log.Printf("❌ Erro ao serializar mensagem de sistema: %v", err)
-			return
+	var clients []*Client
+	for client := range h.clients {
+		if client.UserID == userID {
+			clients = append(clients, client)
 		}
+	}
+	return clients
+}
 
-		for client := range roomClients {
-			select {
-			case client.Conn.Send <- data:
-			default:
-				close(client.Conn.Send)
-				delete(roomClients, client)
-			}
+// SendToUser envia um evento não sequenciado a todas as sessões do usuário, em qualquer sala.
+// Retorna quantas sessões o receberam.
+func (h *Hub) SendToUser(userID string, message *WSMessage) int {
+	clients := h.userClients(userID)
+	if len(clients) == 0 {
+		return 0
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("❌ Erro ao serializar evento %s: %v", message.Type, err)
+		return 0
+	}
+
+	sent := 0
+	for _, client := range clients {
+		if client.trySend(data) {
+			sent++
 		}
 	}
+	return sent
+}
+
+// DeliverNotification envia a notificação a todas as sessões do usuário
+func (h *Hub) DeliverNotification(notification *models.Notification) {
+	h.SendToUser(notification.UserID, &WSMessage{
+		Type:    "notification",
+		Payload: notification,
+	})
+}
+
+// BroadcastMessageUpdated publica message_updated com as prévias de links da mensagem
+func (h *Hub) BroadcastMessageUpdated(message *models.Message) {
+	if _, err := h.Publish(message.RoomID, "message_updated", message); err != nil {
+		log.Printf("❌ Erro ao publicar message_updated: %v", err)
+	}
+}
+
+// BroadcastMessagePinned publica message_pinned na sala da mensagem
+func (h *Hub) BroadcastMessagePinned(pin *models.PinnedMessage) {
+	payload := MessagePinnedPayload{RoomID: pin.RoomID, MessageID: pin.MessageID, PinnedBy: pin.PinnedBy, PinnedAt: pin.PinnedAt}
+	if _, err := h.Publish(pin.RoomID, "message_pinned", payload); err != nil {
+		log.Printf("❌ Erro ao publicar message_pinned: %v", err)
+	}
+}
+
+// BroadcastMessageUnpinned publica message_unpinned na sala
+func (h *Hub) BroadcastMessageUnpinned(roomID, messageID, unpinnedBy string) {
+	payload := MessageUnpinnedPayload{RoomID: roomID, MessageID: messageID, UnpinnedBy: unpinnedBy}
+	if _, err := h.Publish(roomID, "message_unpinned", payload); err != nil {
+		log.Printf("❌ Erro ao publicar message_unpinned: %v", err)
+	}
+}
+
+// BroadcastRoomArchived publica room_archived (ou room_unarchived, com archivedAt nil) na sala
+func (h *Hub) BroadcastRoomArchived(roomID string, archivedAt *time.Time) {
+	eventType := "room_archived"
+	if archivedAt == nil {
+		eventType = "room_unarchived"
+	}
+
+	if _, err := h.Publish(roomID, eventType, RoomArchivedPayload{RoomID: roomID, ArchivedAt: archivedAt}); err != nil {
+		log.Printf("❌ Erro ao publicar %s: %v", eventType, err)
+	}
+}
+
+// BroadcastRoomUpdated publica room_updated com o nome, a descrição (tópico) e o tipo atuais da sala
+func (h *Hub) BroadcastRoomUpdated(room *models.Room) {
+	payload := RoomUpdatedPayload{RoomID: room.ID, Name: room.Name, Description: room.Description, Type: room.Type}
+	if _, err := h.Publish(room.ID, "room_updated", payload); err != nil {
+		log.Printf("❌ Erro ao publicar room_updated: %v", err)
+	}
+}
+
+// CloseAccountSessions encerra todas as conexões de uma conta removida
+func (h *Hub) CloseAccountSessions(userID string) int {
+	return h.DisconnectUser(userID, CloseAccountDeleted, "account_deleted")
 }
 
 func (h *Hub) GetRoomClients(roomID string) []*Client {
@@ -176,14 +312,14 @@ func (h *Hub) GetRoomClients(roomID string) []*Client {
 	return clients
 }
 
-func (h *Hub) GetOnlineUsers(roomID string) []map[string]string {
+func (h *Hub) GetOnlineUsers(roomID string) []OnlineUser {
 	clients := h.GetRoomClients(roomID)
-	users := make([]map[string]string, 0, len(clients))
+	users := make([]OnlineUser, 0, len(clients))
 
 	for _, client := range clients {
-		users = append(users, map[string]string{
-			"user_id":  client.UserID,
-			"username": client.Username,
+		users = append(users, OnlineUser{
+			UserID:   client.UserID,
+			Username: client.Username(),
 		})
 	}
 
@@ -193,12 +329,65 @@ func (h *Hub) GetOnlineUsers(roomID string) []map[string]string {
 func (h *Hub) SendTypingIndicator(roomID, userID, username string, isTyping bool) {
 	message := &WSMessage{
 		Type: "typing_indicator",
-		Payload: map[string]interface{}{
-			"user_id":   userID,
-			"username":  username,
-			"is_typing": isTyping,
+		Payload: TypingIndicatorPayload{
+			UserID:   userID,
+			Username: username,
+			IsTyping: isTyping,
 		},
 	}
 
 	h.broadcastToRoom(roomID, message)
 }
+
+// BroadcastPresence envia presence_changed para as salas onde o usuário está (ou estava) conectado.
+// Os contatos do usuário são os demais participantes dessas salas.
+func (h *Hub) BroadcastPresence(roomIDs []string, userID, status string, lastSeenAt time.Time) {
+	message := &WSMessage{
+		Type: "presence_changed",
+		Payload: PresenceChangedPayload{
+			UserID:     userID,
+			Status:     status,
+			LastSeenAt: lastSeenAt,
+		},
+	}
+
+	for _, roomID := range roomIDs {
+		h.broadcastToRoom(roomID, message)
+	}
+}
+
+// BroadcastProfileUpdate atualiza o nome nas conexões do usuário e avisa todos os clientes
+// conectados, para que mensagens e listas de presença exibam o novo nome e avatar
+func (h *Hub) BroadcastProfileUpdate(user *models.User) {
+	h.mutex.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		if client.UserID == user.ID {
+			client.setUsername(user.Username)
+		}
+		clients = append(clients, client)
+	}
+	h.mutex.RUnlock()
+
+	data, err := json.Marshal(&WSMessage{
+		Type: "user_updated",
+		Payload: UserUpdatedPayload{
+			UserID:   user.ID,
+			Username: user.Username,
+			Avatar:   user.Avatar,
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Erro ao serializar atualização de perfil: %v", err)
+		return
+	}
+
+	for _, client := range clients {
+		client.trySend(data)
+	}
+}
+
+// Touch registra atividade do cliente no serviço de presença
+func (h *Hub) Touch(client *Client) {
+	h.presence.Touch(client.UserID, client.SessionID)
+}
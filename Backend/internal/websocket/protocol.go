@@ -0,0 +1,353 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rafael-bit/whatz/internal/models"
+)
+
+// ProtocolVersion é a versão atual do protocolo WebSocket
+const ProtocolVersion = 1
+
+// SupportedProtocolVersions lista as versões aceitas na negociação do handshake
+var SupportedProtocolVersions = []int{1}
+
+// Prefixo do subprotocolo WebSocket (Sec-WebSocket-Protocol: whatz.v1)
+const subprotocolPrefix = "whatz.v"
+
+// Subprotocols retorna os subprotocolos anunciados pelo servidor no upgrade
+func Subprotocols() []string {
+	protocols := make([]string, 0, len(SupportedProtocolVersions))
+	for _, version := range SupportedProtocolVersions {
+		protocols = append(protocols, subprotocolPrefix+strconv.Itoa(version))
+	}
+	return protocols
+}
+
+// negotiateVersion escolhe a versão do protocolo a partir do subprotocolo negociado
+// ou do parâmetro protocol_version. Sem nenhum dos dois, usa a versão atual.
+func negotiateVersion(subprotocol, requested string) (int, error) {
+	if subprotocol != "" {
+		requested = strings.TrimPrefix(subprotocol, subprotocolPrefix)
+	}
+	if requested == "" {
+		return ProtocolVersion, nil
+	}
+
+	version, err := strconv.Atoi(requested)
+	if err != nil {
+		return 0, fmt.Errorf("versão de protocolo inválida: %s", requested)
+	}
+
+	for _, supported := range SupportedProtocolVersions {
+		if version == supported {
+			return version, nil
+		}
+	}
+
+	return 0, fmt.Errorf("versão de protocolo não suportada: %d", version)
+}
+
+// InboundMessage é o envelope dos eventos enviados pelo cliente.
+// O payload é mantido bruto e decodificado de acordo com o tipo do evento.
+type InboundMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ProtocolError é um erro de validação com código legível por máquina
+type ProtocolError struct {
+	Code    string
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Message
+}
+
+type validator interface {
+	Validate() error
+}
+
+// decodeStrict decodifica JSON rejeitando campos desconhecidos e dados extras
+func decodeStrict(data []byte, dst interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return err
+	}
+
+	if decoder.More() {
+		return fmt.Errorf("dados extras após o objeto JSON")
+	}
+
+	return nil
+}
+
+// decodePayload decodifica e valida o payload de um evento do cliente
+func decodePayload(raw json.RawMessage, dst interface{}) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = json.RawMessage("{}")
+	}
+
+	if err := decodeStrict(raw, dst); err != nil {
+		return &ProtocolError{Code: ErrCodeInvalidPayload, Message: "Payload inválido: " + err.Error()}
+	}
+
+	if v, ok := dst.(validator); ok {
+		return v.Validate()
+	}
+
+	return nil
+}
+
+// ===== Eventos Cliente → Servidor =====
+
+// SendMessagePayload é o payload do evento "send_message"
+type SendMessagePayload struct {
+	Content *string `json:"content"`
+	// Mensagem respondida (opcional); o autor dela recebe uma notificação
+	ThreadID string `json:"thread_id,omitempty"`
+}
+
+func (p *SendMessagePayload) Validate() error {
+	if p.Content == nil {
+		return &ProtocolError{Code: ErrCodeInvalidPayload, Message: "Campo content é obrigatório"}
+	}
+	return validateContent(*p.Content)
+}
+
+// validateContent recusa conteúdo vazio ou maior que models.MaxMessageLength
+func validateContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return &ProtocolError{Code: ErrCodeEmptyContent, Message: "Conteúdo da mensagem não pode ser vazio"}
+	}
+	if utf8.RuneCountInString(content) > models.MaxMessageLength {
+		return &ProtocolError{Code: ErrCodeContentTooLong, Message: fmt.Sprintf("Conteúdo da mensagem excede %d caracteres", models.MaxMessageLength)}
+	}
+	return nil
+}
+
+// TypingPayload é o payload (vazio) dos eventos "typing_start" e "typing_stop"
+type TypingPayload struct{}
+
+// EditMessagePayload é o payload do evento "edit_message"
+type EditMessagePayload struct {
+	MessageID string  `json:"message_id"`
+	Content   *string `json:"content"`
+}
+
+func (p *EditMessagePayload) Validate() error {
+	if p.MessageID == "" {
+		return &ProtocolError{Code: ErrCodeInvalidPayload, Message: "Campo message_id é obrigatório"}
+	}
+	if p.Content == nil {
+		return &ProtocolError{Code: ErrCodeInvalidPayload, Message: "Campo content é obrigatório"}
+	}
+	return validateContent(*p.Content)
+}
+
+// DeleteMessagePayload é o payload do evento "delete_message"
+type DeleteMessagePayload struct {
+	MessageID string `json:"message_id"`
+}
+
+func (p *DeleteMessagePayload) Validate() error {
+	if p.MessageID == "" {
+		return &ProtocolError{Code: ErrCodeInvalidPayload, Message: "Campo message_id é obrigatório"}
+	}
+	return nil
+}
+
+// ResumePayload é o payload do evento "resume": última sequência recebida pelo cliente
+type ResumePayload struct {
+	LastSeq *int64 `json:"last_seq"`
+}
+
+func (p *ResumePayload) Validate() error {
+	if p.LastSeq == nil || *p.LastSeq < 0 {
+		return &ProtocolError{Code: ErrCodeInvalidPayload, Message: "Campo last_seq é obrigatório e não pode ser negativo"}
+	}
+	return nil
+}
+
+// ===== Eventos Servidor → Cliente =====
+
+// RoomInfo descreve a sala no evento "welcome"
+type RoomInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Presente quando a sala está arquivada (somente leitura)
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// OnlineUser descreve um usuário conectado à sala
+type OnlineUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// WelcomePayload é o payload do evento "welcome"
+type WelcomePayload struct {
+	ProtocolVersion int          `json:"protocol_version"`
+	Room            RoomInfo     `json:"room"`
+	OnlineUsers     []OnlineUser `json:"online_users"`
+	// Última sequência de eventos da sala no momento da conexão
+	LastSeq int64 `json:"last_seq"`
+	// Mensagens fixadas atualmente na sala
+	Pins []*models.PinnedMessage `json:"pins"`
+}
+
+// MessageDeletedPayload é o payload do evento "message_deleted"
+type MessageDeletedPayload struct {
+	MessageID string `json:"message_id"`
+	RoomID    string `json:"room_id"`
+}
+
+// MentionedPayload é o payload do evento "mentioned", enviado às sessões do usuário mencionado
+// em qualquer sala em que ele esteja conectado
+type MentionedPayload struct {
+	MentionID string          `json:"mention_id"`
+	Kind      string          `json:"kind"`
+	RoomID    string          `json:"room_id"`
+	Message   *models.Message `json:"message"`
+}
+
+// CommandResponsePayload é o payload do evento "command_response": resposta efêmera de um
+// comando de barra, enviada apenas à conexão que o executou
+type CommandResponsePayload struct {
+	Command string `json:"command"`
+	Text    string `json:"text"`
+}
+
+// MessagePinnedPayload é o payload do evento "message_pinned". Leva só a referência à
+// mensagem: o log de eventos não guarda uma cópia do conteúdo fixado.
+type MessagePinnedPayload struct {
+	RoomID    string    `json:"room_id"`
+	MessageID string    `json:"message_id"`
+	PinnedBy  string    `json:"pinned_by"`
+	PinnedAt  time.Time `json:"pinned_at"`
+}
+
+// MessageUnpinnedPayload é o payload do evento "message_unpinned"
+type MessageUnpinnedPayload struct {
+	RoomID     string `json:"room_id"`
+	MessageID  string `json:"message_id"`
+	UnpinnedBy string `json:"unpinned_by"`
+}
+
+// RoomUpdatedPayload é o payload do evento "room_updated"
+type RoomUpdatedPayload struct {
+	RoomID      string `json:"room_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+}
+
+// RoomArchivedPayload é o payload dos eventos "room_archived" e "room_unarchived"
+type RoomArchivedPayload struct {
+	RoomID     string     `json:"room_id"`
+	ArchivedAt *time.Time `json:"archived_at"`
+}
+
+// ResumeCompletePayload é o payload do evento "resume_complete"
+type ResumeCompletePayload struct {
+	LastSeq  int64 `json:"last_seq"`
+	Replayed int   `json:"replayed"`
+}
+
+// UserPresencePayload é o payload dos eventos "user_joined" e "user_left"
+type UserPresencePayload struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// PresenceChangedPayload é o payload do evento "presence_changed"
+type PresenceChangedPayload struct {
+	UserID     string    `json:"user_id"`
+	Status     string    `json:"status"` // online, away, offline
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// UserUpdatedPayload é o payload do evento "user_updated"
+type UserUpdatedPayload struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+// TypingIndicatorPayload é o payload do evento "typing_indicator"
+type TypingIndicatorPayload struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsTyping bool   `json:"is_typing"`
+}
+
+// ErrorPayload é o payload do evento "error"
+type ErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// MessageAckPayload é o payload do evento "message_ack"
+type MessageAckPayload struct {
+	MessageID string    `json:"message_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Direção dos eventos do protocolo
+const (
+	DirectionClient = "client_to_server"
+	DirectionServer = "server_to_client"
+)
+
+// EventDefinition descreve um evento do protocolo e o tipo do seu payload
+type EventDefinition struct {
+	Type        string
+	Direction   string
+	Description string
+	Payload     reflect.Type
+}
+
+// Events é o catálogo de eventos do protocolo, usado para gerar o schema
+var Events = []EventDefinition{
+	{"send_message", DirectionClient, "Envia uma mensagem para a sala", reflect.TypeOf(SendMessagePayload{})},
+	{"typing_start", DirectionClient, "Indica que o usuário começou a digitar", reflect.TypeOf(TypingPayload{})},
+	{"typing_stop", DirectionClient, "Indica que o usuário parou de digitar", reflect.TypeOf(TypingPayload{})},
+	{"edit_message", DirectionClient, "Edita uma mensagem do próprio usuário", reflect.TypeOf(EditMessagePayload{})},
+	{"delete_message", DirectionClient, "Remove uma mensagem do próprio usuário", reflect.TypeOf(DeleteMessagePayload{})},
+	{"resume", DirectionClient, "Retoma a sessão reenviando os eventos após last_seq", reflect.TypeOf(ResumePayload{})},
+
+	{"welcome", DirectionServer, "Enviado ao conectar, com dados da sala e usuários online", reflect.TypeOf(WelcomePayload{})},
+	{"message_history", DirectionServer, "Últimas mensagens da sala", reflect.TypeOf([]models.Message{})},
+	{"new_message", DirectionServer, "Nova mensagem na sala (sequenciado)", reflect.TypeOf(models.Message{})},
+	{"message_edited", DirectionServer, "Mensagem editada (sequenciado)", reflect.TypeOf(models.Message{})},
+	{"message_updated", DirectionServer, "Prévias de links da mensagem atualizadas (sequenciado)", reflect.TypeOf(models.Message{})},
+	{"message_deleted", DirectionServer, "Mensagem removida (sequenciado)", reflect.TypeOf(MessageDeletedPayload{})},
+	{"notification", DirectionServer, "Nova notificação do usuário (menção, resposta, convite ou mudança de role), em qualquer sala", reflect.TypeOf(models.Notification{})},
+	{"mentioned", DirectionServer, "O usuário foi mencionado (@username, @room ou @here), em qualquer sala", reflect.TypeOf(MentionedPayload{})},
+	{"message_pinned", DirectionServer, "Mensagem fixada na sala (sequenciado)", reflect.TypeOf(MessagePinnedPayload{})},
+	{"message_unpinned", DirectionServer, "Mensagem desafixada da sala (sequenciado)", reflect.TypeOf(MessageUnpinnedPayload{})},
+	{"room_updated", DirectionServer, "Nome, tópico ou tipo da sala alterados (sequenciado)", reflect.TypeOf(RoomUpdatedPayload{})},
+	{"room_archived", DirectionServer, "Sala arquivada; novas mensagens são recusadas (sequenciado)", reflect.TypeOf(RoomArchivedPayload{})},
+	{"room_unarchived", DirectionServer, "Sala reativada (sequenciado)", reflect.TypeOf(RoomArchivedPayload{})},
+	{"resume_complete", DirectionServer, "Fim do replay; a partir daqui os eventos são ao vivo", reflect.TypeOf(ResumeCompletePayload{})},
+	{"command_response", DirectionServer, "Resposta efêmera de um comando de barra, só para quem o executou", reflect.TypeOf(CommandResponsePayload{})},
+	{"message_ack", DirectionServer, "Confirma a persistência de uma mensagem enviada", reflect.TypeOf(MessageAckPayload{})},
+	{"user_joined", DirectionServer, "Usuário entrou na sala", reflect.TypeOf(UserPresencePayload{})},
+	{"user_left", DirectionServer, "Usuário saiu da sala", reflect.TypeOf(UserPresencePayload{})},
+	{"presence_changed", DirectionServer, "Status de presença do usuário mudou (online, away, offline)", reflect.TypeOf(PresenceChangedPayload{})},
+	{"user_updated", DirectionServer, "Usuário alterou nome ou avatar", reflect.TypeOf(UserUpdatedPayload{})},
+	{"typing_indicator", DirectionServer, "Usuário começou ou parou de digitar", reflect.TypeOf(TypingIndicatorPayload{})},
+	{"error", DirectionServer, "Erro ao processar um evento do cliente", reflect.TypeOf(ErrorPayload{})},
+}
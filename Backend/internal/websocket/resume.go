@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+)
+
+// Quantidade máxima de eventos reenviados numa retomada; acima disso o cliente recebe o histórico completo
+const maxResumeEvents = 1000
+
+func parseLastSeq(value string) (int64, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, false
+	}
+
+	return seq, true
+}
+
+// resume reenvia, em ordem, os eventos da sala posteriores a lastSeq e só então volta à entrega ao vivo.
+// Eventos publicados durante o replay ficam retidos no cliente e são liberados sem duplicação.
+func (h *Handler) resume(client *Client, correlationID string, lastSeq int64) {
+	client.beginResume()
+
+	events, err := h.eventRepo.GetSince(client.RoomID, lastSeq, maxResumeEvents+1)
+	if err != nil {
+		log.Printf("❌ Erro ao buscar eventos para retomada: %v", err)
+		client.endResume(lastSeq, nil)
+		h.sendError(client, correlationID, ErrCodeInternal, "Erro ao retomar sessão")
+		return
+	}
+
+	if len(events) > maxResumeEvents {
+		log.Printf("⚠️ %s perdeu mais de %d eventos, enviando histórico completo", client.Username(), maxResumeEvents)
+		lastSeq, _ = h.eventRepo.GetLastSeq(client.RoomID)
+		client.endResume(lastSeq, nil)
+		h.sendError(client, correlationID, ErrCodeResumeUnavailable, "Eventos perdidos demais para retomar; histórico reenviado")
+		h.sendMessageHistory(client, client.RoomID)
+		return
+	}
+
+	replayedSeq := lastSeq
+	replayed := 0
+	for _, event := range events {
+		data, err := json.Marshal(&WSMessage{
+			Type:    event.Type,
+			Seq:     event.Seq,
+			Payload: json.RawMessage(event.Payload),
+		})
+		if err != nil {
+			log.Printf("❌ Erro ao serializar evento #%d: %v", event.Seq, err)
+			break
+		}
+
+		if !client.sendBlocking(data) {
+			break
+		}
+		replayedSeq = event.Seq
+		replayed++
+	}
+
+	complete, err := json.Marshal(&WSMessage{
+		Type: "resume_complete",
+		ID:   correlationID,
+		Payload: ResumeCompletePayload{
+			LastSeq:  replayedSeq,
+			Replayed: replayed,
+		},
+	})
+	if err != nil {
+		complete = nil
+	}
+
+	client.endResume(replayedSeq, complete)
+	log.Printf("✅ %d eventos reenviados para %s (até #%d)", replayed, client.Username(), replayedSeq)
+}
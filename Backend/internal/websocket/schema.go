@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// GenerateSchema gera um JSON Schema (draft 2020-12) do protocolo a partir do catálogo de eventos
+func GenerateSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+	envelopes := make([]interface{}, 0, len(Events))
+
+	for _, event := range Events {
+		envelopeName := "event." + event.Type
+
+		properties := map[string]interface{}{
+			"type": map[string]interface{}{"const": event.Type},
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Identificador de correlação definido pelo cliente",
+			},
+			"seq": map[string]interface{}{
+				"type":        "integer",
+				"description": "Sequência do evento na sala (apenas eventos sequenciados)",
+			},
+			"payload": schemaFor(event.Payload, defs),
+		}
+
+		envelope := map[string]interface{}{
+			"type":                 "object",
+			"description":          event.Description,
+			"x-direction":          event.Direction,
+			"properties":           properties,
+			"required":             []string{"type"},
+			"additionalProperties": false,
+		}
+		if event.Direction == DirectionServer {
+			envelope["required"] = []string{"type", "payload"}
+		}
+
+		defs[envelopeName] = envelope
+		envelopes = append(envelopes, map[string]interface{}{"$ref": "#/$defs/" + envelopeName})
+	}
+
+	return map[string]interface{}{
+		"$schema":                       "https://json-schema.org/draft/2020-12/schema",
+		"$id":                           "https://github.com/rafael-bit/whatz/ws-protocol.schema.json",
+		"title":                         "Whatz WebSocket Protocol",
+		"x-protocol-version":            ProtocolVersion,
+		"x-supported-protocol-versions": SupportedProtocolVersions,
+		"x-subprotocols":                Subprotocols(),
+		"oneOf":                         envelopes,
+		"$defs":                         defs,
+	}
+}
+
+// schemaFor converte um tipo Go em JSON Schema, registrando structs nomeadas em defs
+func schemaFor(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		return schemaFor(t.Elem(), defs)
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := defs[name]; !ok {
+			defs[name] = nil // reserva o nome para evitar recursão infinita
+			defs[name] = structSchema(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		properties[name] = schemaFor(field.Type, defs)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+		pkg = pkg[idx+1:]
+	}
+	return pkg + "." + t.Name()
+}
+
+// SchemaHandler godoc
+// @Summary Schema do protocolo WebSocket
+// @Description Retorna o JSON Schema dos eventos trocados no WebSocket
+// @Tags websocket
+// @Produce json
+// @Success 200 {object} map[string]interface{} "JSON Schema do protocolo"
+// @Router /ws/schema [get]
+func SchemaHandler(ctx *fiber.Ctx) error {
+	return ctx.JSON(GenerateSchema())
+}
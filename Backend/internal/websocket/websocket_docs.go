@@ -9,6 +9,9 @@
 //   - Query Parameters:
 //   - user_id: ID do usuário
 //   - room_id: ID da sala (opcional)
+//   - protocol_version: versão do protocolo (opcional, padrão: atual)
+//   - Subprotocolo: Sec-WebSocket-Protocol: whatz.v1 (alternativa ao protocol_version)
+//   - Schema completo dos eventos: GET /api/v1/ws/schema
 //
 // 2. Tipos de Mensagens:
 //
@@ -63,10 +66,24 @@
 //	  "room_id": "room-uuid"
 //	}
 //
-//	d) Erro:
+//	d) Erro (o "id" ecoa o identificador de correlação enviado pelo cliente):
 //	{
 //	  "type": "error",
-//	  "message": "Descrição do erro"
+//	  "id": "c1",
+//	  "payload": {
+//	    "code": "empty_content",
+//	    "message": "Descrição do erro"
+//	  }
+//	}
+//
+//	e) Confirmação de envio:
+//	{
+//	  "type": "message_ack",
+//	  "id": "c1",
+//	  "payload": {
+//	    "message_id": "message-uuid",
+//	    "created_at": "2024-01-01T12:00:00Z"
+//	  }
 //	}
 //
 // 4. Códigos de Status:
@@ -75,8 +92,15 @@
 //   - 1002: Erro de protocolo
 //   - 1003: Tipo de dados não suportado
 //   - 1006: Conexão anormal
+//   - 1008: Parâmetros obrigatórios ausentes (missing_params)
 //   - 1009: Mensagem muito grande
 //   - 1011: Erro interno do servidor
+//   - 4001: Usuário não encontrado (user_not_found)
+//   - 4002: Sala não encontrada (room_not_found)
+//   - 4003: Sessão substituída por nova conexão (session_replaced)
+//   - 4004: Versão de protocolo não suportada (unsupported_version)
+//   - 4005: Cliente lento, fila de envio cheia (too_slow)
+//   - 4006: Conta do usuário removida (account_deleted)
 //
 // 5. Exemplo de Uso com JavaScript:
 //
@@ -25,7 +25,7 @@ func main() {
 	defer db.Close()
 
 	// Limpar todas as tabelas
-	tables := []string{"messages", "rooms", "users"}
+	tables := []string{"moderation_flags", "moderation_rules", "link_previews", "slash_commands", "api_tokens", "incoming_webhooks", "webhook_attempts", "webhook_deliveries", "webhooks", "notification_preferences", "notifications", "mentions", "pinned_messages", "legal_holds", "messages_archive", "data_exports", "room_events", "messages", "rooms", "users"}
 
 	for _, table := range tables {
 		_, err := db.Exec(fmt.Sprintf("DELETE FROM %s", table))